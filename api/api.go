@@ -9,6 +9,7 @@ import (
 
 	"personae-fasti/data"
 	"personae-fasti/opt"
+	"personae-fasti/webhook"
 
 	"github.com/rs/cors"
 )
@@ -20,6 +21,11 @@ type APIServer struct {
 	server     *http.Server
 	storage    *data.Storage
 	fileServer *opt.FileServer
+	telegram   *opt.Telegram
+	webhooks   *webhook.Dispatcher
+	events     *eventHub
+	// Blind updates are refused, see readIfMatch
+	requireVersions bool
 }
 
 type APIError struct {
@@ -86,14 +92,15 @@ func (api *APIServer) Respond(r *http.Request, w http.ResponseWriter, status int
 	return nil
 }
 
-func InitServer(c *opt.Conf, s *data.Storage) *APIServer {
+func InitServer(c *opt.Conf, s *data.Storage, d *webhook.Dispatcher) *APIServer {
 
 	router := http.NewServeMux()
 
 	crs := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Authorization", "Content-Type", "AccessKey"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "AccessKey", "Last-Event-ID", "If-Match"},
+		ExposedHeaders:   []string{"ETag"},
 		AllowCredentials: true,
 	})
 
@@ -104,6 +111,11 @@ func InitServer(c *opt.Conf, s *data.Storage) *APIServer {
 		},
 		storage:    s,
 		fileServer: &c.FileServer,
+		telegram:   &c.Telegram,
+		webhooks:   d,
+		events:     newEventHub(s),
+
+		requireVersions: c.App.RequireVersions,
 	}
 
 	api.SetHandlers(router)
@@ -0,0 +1,394 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"personae-fasti/data"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxArchiveSize = 256 * 1024 * 1024
+	// The zip archive unpacked may be as big as the JSON one
+	maxArchiveUnpackedSize = maxArchiveSize
+	maxBulkImportSize      = 8 * 1024 * 1024
+)
+
+// GET /game/export
+func (api *APIServer) handleExportGame(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	if p.CurrentGame.GMID != p.ID {
+		return api.HandleErrorString("only GM may export the game").WithCode(http.StatusForbidden)
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "zip" {
+		return api.HandleErrorString(fmt.Sprintf("unknown export format %s", format)).WithCode(http.StatusBadRequest)
+	}
+
+	archive, err := api.storage.ExportGame(p.CurrentGameID)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	if r.URL.Query().Get("images") != "false" {
+		err = api.fillArchiveImages(archive)
+		if err != nil {
+			return api.HandleError(err).WithCode(http.StatusBadGateway)
+		}
+	}
+
+	fileName := fmt.Sprintf("game_%d_%s", archive.Game.ID, archive.Exported.Format("20060102_150405"))
+
+	if format == "zip" {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+".zip"))
+		w.WriteHeader(http.StatusOK)
+
+		err = writeArchiveZip(w, archive)
+		if err != nil {
+			log.Printf("failed to write game archive: %v", err)
+		}
+		return nil
+	}
+
+	// Archive is not passed to Respond to keep it out of the request log
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+".json"))
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(archive)
+	if err != nil {
+		log.Printf("failed to write game archive: %v", err)
+	}
+	return nil
+}
+
+// fillArchiveImages downloads images of archived entities from the file server
+func (api *APIServer) fillArchiveImages(archive *data.GameArchive) error {
+	imageIDs := map[string][]int{}
+	for _, char := range archive.Chars {
+		imageIDs[data.CharEntity] = append(imageIDs[data.CharEntity], char.ID)
+	}
+	for _, npc := range archive.NPCs {
+		imageIDs[data.NPCEntity] = append(imageIDs[data.NPCEntity], npc.ID)
+	}
+	for _, location := range archive.Locations {
+		imageIDs[data.LocationEntity] = append(imageIDs[data.LocationEntity], location.ID)
+	}
+	for _, item := range archive.Items {
+		imageIDs[data.ItemEntity] = append(imageIDs[data.ItemEntity], item.ID)
+	}
+	for _, faction := range archive.Factions {
+		imageIDs[data.FactionEntity] = append(imageIDs[data.FactionEntity], faction.ID)
+	}
+
+	for imageType, ids := range imageIDs {
+		for _, id := range ids {
+			image, found, err := api.getFileServerImage(imageType, id)
+			if err != nil {
+				return err
+			} else if found {
+				archive.Images[fmt.Sprintf("%s_%d", imageType, id)] = image
+			}
+		}
+	}
+
+	return nil
+}
+
+func (api *APIServer) getFileServerImage(imageType string, id int) (string, bool, error) {
+	uri := fmt.Sprintf("%s/file/%s/%s_%d", api.fileServer.Addr, api.fileServer.Proj, imageType, id)
+
+	req, _ := http.NewRequest(http.MethodGet, uri, nil)
+	req.Header.Add("Authorization", api.fileServer.Pass)
+
+	client := http.Client{Timeout: 30 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", false, fmt.Errorf("cannot send image get request: %w", err)
+	}
+	defer res.Body.Close()
+
+	resBody, _ := io.ReadAll(res.Body)
+	switch {
+	case res.StatusCode == http.StatusOK || res.StatusCode == http.StatusCreated:
+		return string(resBody), true, nil
+	case res.StatusCode == http.StatusNotFound:
+		return "", false, nil
+	default:
+		return "", false, fmt.Errorf("file server error: %s", string(resBody))
+	}
+}
+
+// writeArchiveZip stores the archive as game.json with images as separate files
+func writeArchiveZip(w io.Writer, archive *data.GameArchive) error {
+	zw := zip.NewWriter(w)
+
+	images := archive.Images
+	archive.Images = map[string]string{}
+	defer func() { archive.Images = images }()
+
+	gameFile, err := zw.Create("game.json")
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(gameFile)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(archive)
+	if err != nil {
+		return err
+	}
+
+	for key, image := range images {
+		imageFile, err := zw.Create("images/" + key)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(imageFile, image)
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// POST /game/import
+func (api *APIServer) handleImportGame(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxArchiveSize))
+	if err != nil {
+		return api.HandleErrorString(fmt.Sprintf("cannot read archive: %v", err)).WithCode(http.StatusRequestEntityTooLarge)
+	}
+
+	archive, err := readArchive(body)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	report, err := api.storage.ImportGame(archive, r.URL.Query().Get("name"), p, dryRun)
+	if err != nil {
+		if errors.Is(err, data.ErrArchiveVersion) {
+			return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+		}
+		return api.HandleError(err)
+	}
+
+	if !dryRun {
+		api.restoreArchiveImages(archive, report)
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+
+	return api.Respond(r, w, status, report)
+}
+
+// readArchive accepts both the JSON archive and the zip one
+func readArchive(body []byte) (*data.GameArchive, error) {
+	var archive data.GameArchive
+
+	if !bytes.HasPrefix(body, []byte("PK")) {
+		err := json.Unmarshal(body, &archive)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse archive: %w", err)
+		}
+		if archive.Images == nil {
+			archive.Images = map[string]string{}
+		}
+		return &archive, nil
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot open zip archive: %w", err)
+	}
+
+	budget := int64(maxArchiveUnpackedSize)
+
+	gameFile, err := zr.Open("game.json")
+	if err != nil {
+		return nil, fmt.Errorf("zip archive has no game.json: %w", err)
+	}
+	game, err := readZipFile(gameFile, &budget)
+	gameFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read game.json: %w", err)
+	}
+
+	err = json.Unmarshal(game, &archive)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse game.json: %w", err)
+	}
+	if archive.Images == nil {
+		archive.Images = map[string]string{}
+	}
+
+	for _, file := range zr.File {
+		key, ok := strings.CutPrefix(file.Name, "images/")
+		if !ok || key == "" {
+			continue
+		}
+
+		imageFile, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("cannot open image %s: %w", key, err)
+		}
+		image, err := readZipFile(imageFile, &budget)
+		imageFile.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read image %s: %w", key, err)
+		}
+		archive.Images[key] = string(image)
+	}
+
+	return &archive, nil
+}
+
+// readZipFile reads the file of the zip archive taking its size from the
+// budget of the whole unpacked archive, so a zip bomb stops at the limit
+func readZipFile(file io.Reader, budget *int64) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(file, *budget+1))
+	if err != nil {
+		return nil, err
+	} else if int64(len(content)) > *budget {
+		return nil, fmt.Errorf("archive is bigger than %d bytes unpacked", maxArchiveUnpackedSize)
+	}
+	*budget -= int64(len(content))
+
+	return content, nil
+}
+
+// restoreArchiveImages uploads archive images under the new entity IDs.
+// The game is already imported, so failures are only reported
+func (api *APIServer) restoreArchiveImages(archive *data.GameArchive, report *data.ImportReport) {
+	for key, image := range archive.Images {
+		imageType, idString, _ := strings.Cut(key, "_")
+		id, _ := strconv.Atoi(idString)
+
+		newID := report.IDs[imageType][id]
+		if newID == 0 {
+			report.Conflicts = append(report.Conflicts, data.ImportConflict{
+				Type:    "image",
+				Message: fmt.Sprintf("image %s belongs to a missing entity", key),
+			})
+			continue
+		}
+
+		err := api.postFileServerImage(imageType, newID, image)
+		if err != nil {
+			report.Conflicts = append(report.Conflicts, data.ImportConflict{
+				Type:    "image",
+				ID:      newID,
+				Message: fmt.Sprintf("image %s is not restored: %v", key, err),
+			})
+		}
+	}
+}
+
+func (api *APIServer) postFileServerImage(imageType string, id int, image string) error {
+	uri := fmt.Sprintf("%s/file/%s/%s_%d", api.fileServer.Addr, api.fileServer.Proj, imageType, id)
+
+	req, _ := http.NewRequest(http.MethodPost, uri, strings.NewReader(image))
+	req.Header.Add("Authorization", api.fileServer.Pass)
+
+	client := http.Client{Timeout: 30 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot send image post request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		resBody, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("file server error: %s", string(resBody))
+	}
+
+	return nil
+}
+
+// GET /game/vault
+func (api *APIServer) handleExportVault(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	notes, err := api.storage.ExportVault(p.CurrentGame, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	folder := strings.TrimSpace(strings.NewReplacer("/", "-", "\\", "-", "\"", "'").Replace(p.CurrentGame.Name))
+	if folder == "" {
+		folder = fmt.Sprintf("game_%d", p.CurrentGameID)
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", folder+".zip"))
+	w.WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(w)
+	for _, note := range notes {
+		noteFile, err := zw.Create(folder + "/" + note.Path)
+		if err == nil {
+			_, err = io.WriteString(noteFile, note.Content)
+		}
+		if err != nil {
+			log.Printf("failed to write vault note %s: %v", note.Path, err)
+			return nil
+		}
+	}
+
+	err = zw.Close()
+	if err != nil {
+		log.Printf("failed to write vault: %v", err)
+	}
+	return nil
+}
+
+// POST /import/{type}
+func (api *APIServer) handleBulkImport(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	entityType := r.PathValue("type")
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = data.CSVFormat
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "text/markdown") {
+			format = data.MarkdownFormat
+		}
+	}
+
+	defaultHidden := false
+	if hidden := r.URL.Query().Get("hidden"); hidden != "" {
+		var err error
+		defaultHidden, err = strconv.ParseBool(hidden)
+		if err != nil {
+			return api.HandleErrorString(fmt.Sprintf("hidden must be true or false, got %q", hidden)).WithCode(http.StatusBadRequest)
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBulkImportSize))
+	if err != nil {
+		return api.HandleErrorString(fmt.Sprintf("cannot read import file: %v", err)).WithCode(http.StatusRequestEntityTooLarge)
+	}
+
+	report, err := api.storage.BulkImport(entityType, format, body, defaultHidden, p)
+	if errors.Is(err, data.ErrBulkImportRows) {
+		return api.Respond(r, w, http.StatusUnprocessableEntity, report)
+	} else if errors.Is(err, data.ErrBulkImportFile) {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	} else if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusCreated, report)
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadZipFile(t *testing.T) {
+	budget := int64(10)
+
+	content, err := readZipFile(strings.NewReader("123456"), &budget)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	} else if string(content) != "123456" {
+		t.Errorf("content is %q, want 123456", content)
+	} else if budget != 4 {
+		t.Errorf("budget is %d, want 4", budget)
+	}
+
+	content, err = readZipFile(strings.NewReader("1234"), &budget)
+	if err != nil {
+		t.Fatalf("read up to the budget failed: %v", err)
+	} else if budget != 0 {
+		t.Errorf("budget is %d, want 0", budget)
+	}
+
+	_, err = readZipFile(bytes.NewReader([]byte{0}), &budget)
+	if err == nil || !strings.Contains(err.Error(), "unpacked") {
+		t.Errorf("error is %v, want the unpacked size limit", err)
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/data"
+)
+
+// getChar returns the char of the path the player sees
+func (api *APIServer) getChar(r *http.Request, p *data.Player) (*data.Char, *APIError) {
+	charID := getPathValueInt(r, "id")
+	if charID < 0 {
+		return nil, api.HandleError(fmt.Errorf("error parsing id: char id is invalid"))
+	}
+
+	char, err := api.storage.GetCharByID(charID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	} else if char == nil {
+		return nil, api.HandleErrorString(fmt.Sprintf("no character with id %d", charID)).WithCode(http.StatusNotFound)
+	} else if char.GameID != p.CurrentGameID {
+		return nil, api.HandleErrorString(fmt.Sprintf("char %d is not allowed to request for the game %d", char.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	} else if char.HiddenBy != 0 && char.HiddenBy != p.ID {
+		return nil, api.HandleErrorString(fmt.Sprintf("char %d is not allowed to request for the player %d", char.ID, p.ID)).WithCode(http.StatusForbidden)
+	}
+
+	return char, nil
+}
+
+// respondCharChange responds the char page after the change or the
+// current one on a version conflict
+func (api *APIServer) respondCharChange(w http.ResponseWriter, r *http.Request, p *data.Player, charID int, err error) *APIError {
+	if err != nil && !errors.Is(err, data.ErrVersionConflict) {
+		return api.HandleError(err)
+	}
+
+	charPage, apiErr := api.getCharPage(charID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+	if err != nil {
+		return api.respondVersionConflict(w, r, charPage.Char.Version, charPage)
+	}
+
+	setETag(w, charPage.Char.Version)
+	return api.Respond(r, w, http.StatusOK, charPage)
+}
+
+// POST /char/{id}/transfer
+func (api *APIServer) handleTransferChar(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	if !p.IsGM() {
+		return api.HandleErrorString("only GM may transfer chars").WithCode(http.StatusForbidden)
+	}
+
+	var charTransfer reqData.CharTransfer
+	err := ReadJsonBody(r, &charTransfer)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+	if apiErr := api.readIfMatch(r, &charTransfer.Version); apiErr != nil {
+		return apiErr
+	}
+
+	char, apiErr := api.getChar(r, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	char, err = api.storage.TransferChar(char, &charTransfer, p)
+	if err != nil && !errors.Is(err, data.ErrVersionConflict) {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	} else if err == nil && char.HiddenBy != 0 && char.HiddenBy != p.ID {
+		// The hidden char is seen only by its new owner now
+		return api.Respond(r, w, http.StatusOK, nil)
+	}
+
+	return api.respondCharChange(w, r, p, char.ID, err)
+}
+
+// POST /char/{id}/status
+func (api *APIServer) handleSetCharStatus(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var statusUpdate reqData.CharStatusUpdate
+	err := ReadJsonBody(r, &statusUpdate)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+	if apiErr := api.readIfMatch(r, &statusUpdate.Version); apiErr != nil {
+		return apiErr
+	}
+
+	char, apiErr := api.getChar(r, p)
+	if apiErr != nil {
+		return apiErr
+	} else if char.PlayerID != p.ID && !p.IsGM() {
+		return api.HandleErrorString("only the owner of the char or GM may change its status").WithCode(http.StatusForbidden)
+	}
+
+	_, err = api.storage.SetCharStatus(char, &statusUpdate, p)
+	if err != nil && !errors.Is(err, data.ErrVersionConflict) {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	return api.respondCharChange(w, r, p, char.ID, err)
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/api/models/respData"
+	"personae-fasti/data"
+)
+
+// getRecord returns the record of the player's game if the player sees it
+func (api *APIServer) getRecord(r *http.Request, p *data.Player) (*data.Record, *APIError) {
+	recordID := getPathValueInt(r, "id")
+	if recordID < 0 {
+		return nil, api.HandleErrorString("error parsing id: record id is invalid").WithCode(http.StatusBadRequest)
+	}
+
+	record, err := api.storage.GetRecordForPlayer(recordID, p)
+	if err != nil {
+		return nil, api.HandleError(err)
+	} else if record == nil {
+		return nil, api.HandleErrorString(fmt.Sprintf("no record with id %d", recordID)).WithCode(http.StatusNotFound)
+	} else if record.GameID != p.CurrentGameID {
+		return nil, api.HandleErrorString(fmt.Sprintf("record %d is not allowed to request for the game %d", record.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	return record, nil
+}
+
+// getComment returns the comment of the record if the player sees it
+func (api *APIServer) getComment(record *data.Record, commentID int, p *data.Player) (*data.Comment, *APIError) {
+	comment, err := api.storage.GetCommentByID(commentID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	} else if comment == nil || comment.RecordID != record.ID || (comment.HiddenBy != 0 && comment.HiddenBy != p.ID) {
+		return nil, api.HandleErrorString(fmt.Sprintf("no comment with id %d in the record %d", commentID, record.ID)).WithCode(http.StatusNotFound)
+	}
+
+	return comment, nil
+}
+
+func (api *APIServer) respondRecordComments(w http.ResponseWriter, r *http.Request, p *data.Player, status int, record *data.Record) *APIError {
+	comments, err := api.storage.GetRecordComments(record, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	records := []data.Record{*record}
+	if err := api.storage.FillRecordFeedback(records, p); err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, status, respData.RecordComments{
+		Comments:  respData.CommentToCommentInfoArray(comments),
+		Reactions: records[0].Reactions,
+	})
+}
+
+// GET /record/{id}/comments
+func (api *APIServer) handleGetRecordComments(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	record, apiErr := api.getRecord(r, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	return api.respondRecordComments(w, r, p, http.StatusOK, record)
+}
+
+// POST /record/{id}/comment
+func (api *APIServer) handlePostComment(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	record, apiErr := api.getRecord(r, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var commentCreate reqData.CommentCreate
+	err := ReadJsonBody(r, &commentCreate)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	_, err = api.storage.CreateComment(record, &commentCreate, p)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	return api.respondRecordComments(w, r, p, http.StatusCreated, record)
+}
+
+// PUT /record/{id}/comment
+func (api *APIServer) handleChangeComment(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	record, apiErr := api.getRecord(r, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var commentUpdate reqData.CommentUpdate
+	err := ReadJsonBody(r, &commentUpdate)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+	if apiErr := api.readIfMatch(r, &commentUpdate.Version); apiErr != nil {
+		return apiErr
+	}
+
+	comment, apiErr := api.getComment(record, commentUpdate.ID, p)
+	if apiErr != nil {
+		return apiErr
+	} else if comment.PlayerID != p.ID {
+		return api.HandleErrorString(fmt.Sprintf("comment %d is not allowed to edit for the player %d", comment.ID, p.ID)).WithCode(http.StatusForbidden)
+	}
+
+	err = api.storage.UpdateComment(record, comment, &commentUpdate, p)
+	if errors.Is(err, data.ErrVersionConflict) {
+		current, apiErr := api.getComment(record, commentUpdate.ID, p)
+		if apiErr != nil {
+			return apiErr
+		}
+		return api.respondVersionConflict(w, r, current.Version, respData.CommentToCommentInfo(current))
+	} else if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	setETag(w, comment.Version)
+	return api.respondRecordComments(w, r, p, http.StatusOK, record)
+}
+
+// DELETE /record/{id}/comment/{commentID}
+func (api *APIServer) handleDeleteComment(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	record, apiErr := api.getRecord(r, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	commentID := getPathValueInt(r, "commentID")
+	if commentID < 0 {
+		return api.HandleErrorString("error parsing id: comment id is invalid").WithCode(http.StatusBadRequest)
+	}
+
+	comment, apiErr := api.getComment(record, commentID, p)
+	if apiErr != nil {
+		return apiErr
+	} else if comment.PlayerID != p.ID && p.CurrentGame.GMID != p.ID {
+		return api.HandleErrorString(fmt.Sprintf("comment %d is not allowed to delete for the player %d", comment.ID, p.ID)).WithCode(http.StatusForbidden)
+	}
+
+	err := api.storage.DeleteComment(record, comment, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.respondRecordComments(w, r, p, http.StatusOK, record)
+}
+
+// POST /record/{id}/reaction
+func (api *APIServer) handlePostReaction(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	record, apiErr := api.getRecord(r, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var reactionSet reqData.ReactionSet
+	err := ReadJsonBody(r, &reactionSet)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	err = api.storage.SetReaction(record, &reactionSet, p)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	return api.respondRecordComments(w, r, p, http.StatusOK, record)
+}
+
+// GET /comments/unread
+func (api *APIServer) handleGetUnreadComments(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	comments, err := api.storage.GetUnreadComments(p.CurrentGame, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, respData.CommentToCommentInfoArray(comments))
+}
+
+// POST /comments/read
+func (api *APIServer) handleReadComments(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	err := api.storage.MarkCommentsRead(p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, nil)
+}
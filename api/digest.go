@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/data"
+)
+
+// GET /digest
+func (api *APIServer) handleGetDigest(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+		if strings.HasPrefix(r.Header.Get("Accept"), "text/markdown") {
+			format = data.MarkdownFormat
+		}
+	}
+	if format != "json" && format != data.MarkdownFormat {
+		return api.HandleErrorString(fmt.Sprintf("digest format %q is not supported", format)).WithCode(http.StatusBadRequest)
+	}
+
+	var since time.Time
+	if sinceValue := r.URL.Query().Get("since"); sinceValue != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, sinceValue)
+		if err != nil {
+			return api.HandleErrorString(fmt.Sprintf("since %q is not an RFC 3339 time", sinceValue)).WithCode(http.StatusBadRequest)
+		}
+	} else {
+		var err error
+		since, err = api.storage.GetDigestSince(p.CurrentGame, p)
+		if err != nil {
+			return api.HandleError(err)
+		}
+	}
+
+	digest, err := api.storage.GetDigest(p.CurrentGame, p, since)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	if format == data.MarkdownFormat {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(digest.Markdown())); err != nil {
+			log.Printf("failed to write digest: %v", err)
+		}
+		return nil
+	}
+
+	// Digest is not passed to Respond to keep the records out of the request log
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(digest); err != nil {
+		log.Printf("failed to write digest: %v", err)
+	}
+	return nil
+}
+
+// POST /digest/seen
+func (api *APIServer) handleDigestSeen(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var digestSeen reqData.DigestSeen
+	err := ReadJsonBody(r, &digestSeen)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	seen := time.Now().UTC()
+	if digestSeen.Until != nil {
+		if digestSeen.Until.After(seen) {
+			return api.HandleErrorString("digest cannot be seen in the future").WithCode(http.StatusBadRequest)
+		}
+		seen = *digestSeen.Until
+	}
+
+	err = api.storage.MarkDigestSeen(p.CurrentGame, p, seen)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, nil)
+}
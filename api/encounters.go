@@ -0,0 +1,300 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/api/models/respData"
+	"personae-fasti/data"
+)
+
+// getEncounter checks the encounter is of the player's game. Only the GM
+// may manage encounters, players only follow them
+func (api *APIServer) getEncounter(r *http.Request, p *data.Player, manage bool) (*data.Encounter, *APIError) {
+	encounterID := getPathValueInt(r, "id")
+	if encounterID < 0 {
+		return nil, api.HandleErrorString("error parsing id: encounter id is invalid").WithCode(http.StatusBadRequest)
+	}
+
+	if manage && p.CurrentGame.GMID != p.ID {
+		return nil, api.HandleErrorString("only GM may manage encounters").WithCode(http.StatusForbidden)
+	}
+
+	encounter, err := api.storage.GetEncounterByID(encounterID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	} else if encounter == nil {
+		return nil, api.HandleErrorString(fmt.Sprintf("no encounter with id %d", encounterID)).WithCode(http.StatusNotFound)
+	} else if encounter.GameID != p.CurrentGameID {
+		return nil, api.HandleErrorString(fmt.Sprintf("encounter %d is not allowed to request for the game %d", encounter.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	return encounter, nil
+}
+
+// encounterError is 409 for the ended encounter and 422 for the rest
+func (api *APIServer) encounterError(err error) *APIError {
+	if errors.Is(err, data.ErrEncounterEnded) {
+		return api.HandleError(err).WithCode(http.StatusConflict)
+	}
+
+	return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+}
+
+func (api *APIServer) respondEncounter(w http.ResponseWriter, r *http.Request, p *data.Player, status int, encounter *data.Encounter) *APIError {
+	encounterInfo := respData.EncounterToEncounterInfo(encounter, p.CurrentGame.GMID == p.ID)
+
+	lastChangeID, err := api.storage.GetEncounterLastChangeID(encounter)
+	if err != nil {
+		return api.HandleError(err)
+	}
+	encounterInfo.LastChangeID = lastChangeID
+
+	return api.Respond(r, w, status, encounterInfo)
+}
+
+// GET /encounters
+func (api *APIServer) handleGetEncounters(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	sessionNumber := 0
+	if session := r.URL.Query().Get("session"); session != "" {
+		number, err := strconv.Atoi(session)
+		if err != nil || number < 1 {
+			return api.HandleErrorString(fmt.Sprintf("session number %q is invalid", session)).WithCode(http.StatusBadRequest)
+		}
+		sessionNumber = number
+	}
+
+	encounters, err := api.storage.GetSessionEncounters(p.CurrentGame, sessionNumber)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, respData.EncounterToEncounterInfoArray(encounters, p.CurrentGame.GMID == p.ID))
+}
+
+// POST /encounter
+func (api *APIServer) handleCreateEncounter(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	if p.CurrentGame.GMID != p.ID {
+		return api.HandleErrorString("only GM may manage encounters").WithCode(http.StatusForbidden)
+	}
+
+	var encounterCreate reqData.EncounterCreate
+	err := ReadJsonBody(r, &encounterCreate)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	encounter, err := api.storage.CreateEncounter(&encounterCreate, p)
+	if errors.Is(err, data.ErrNoSession) {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	} else if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.respondEncounter(w, r, p, http.StatusCreated, encounter)
+}
+
+// GET /encounter/{id}
+func (api *APIServer) handleGetEncounter(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	encounter, apiErr := api.getEncounter(r, p, false)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	return api.respondEncounter(w, r, p, http.StatusOK, encounter)
+}
+
+// POST /encounter/{id}/combatant
+func (api *APIServer) handleAddCombatant(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	encounter, apiErr := api.getEncounter(r, p, true)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var combatantCreate reqData.CombatantCreate
+	err := ReadJsonBody(r, &combatantCreate)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	// Chars and NPCs are named after themselves, hidden NPCs stay hidden
+	if combatantCreate.CharID != 0 {
+		char, err := api.storage.GetCharByID(combatantCreate.CharID)
+		if err != nil || char == nil {
+			return api.HandleErrorString(fmt.Sprintf("no character with id %d", combatantCreate.CharID)).WithCode(http.StatusNotFound)
+		} else if char.GameID != p.CurrentGameID {
+			return api.HandleErrorString(fmt.Sprintf("char %d is not allowed to request for the game %d", char.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+		}
+		if combatantCreate.Name == "" {
+			combatantCreate.Name = char.Name
+		}
+	} else if combatantCreate.NPCID != 0 {
+		npc, err := api.storage.GetNPCByID(combatantCreate.NPCID)
+		if err != nil || npc == nil {
+			return api.HandleErrorString(fmt.Sprintf("no npc with id %d", combatantCreate.NPCID)).WithCode(http.StatusNotFound)
+		} else if npc.GameID != p.CurrentGameID {
+			return api.HandleErrorString(fmt.Sprintf("npc %d is not allowed to request for the game %d", npc.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+		}
+		if combatantCreate.Name == "" {
+			combatantCreate.Name = npc.Name
+		}
+		combatantCreate.Hidden = combatantCreate.Hidden || npc.HiddenBy != 0
+	}
+
+	_, err = api.storage.AddCombatant(encounter, &combatantCreate, p)
+	if err != nil {
+		return api.encounterError(err)
+	}
+
+	return api.respondEncounter(w, r, p, http.StatusCreated, encounter)
+}
+
+// PUT /encounter/{id}/combatant
+func (api *APIServer) handleUpdateCombatant(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	encounter, apiErr := api.getEncounter(r, p, true)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var combatantUpdate reqData.CombatantUpdate
+	err := ReadJsonBody(r, &combatantUpdate)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	err = api.storage.UpdateCombatant(encounter, &combatantUpdate, p)
+	if err != nil {
+		return api.encounterError(err)
+	}
+
+	return api.respondEncounter(w, r, p, http.StatusOK, encounter)
+}
+
+// DELETE /encounter/{id}/combatant/{combatantID}
+func (api *APIServer) handleRemoveCombatant(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	encounter, apiErr := api.getEncounter(r, p, true)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	combatantID := getPathValueInt(r, "combatantID")
+	if combatantID < 0 {
+		return api.HandleErrorString("error parsing id: combatant id is invalid").WithCode(http.StatusBadRequest)
+	}
+
+	err := api.storage.RemoveCombatant(encounter, combatantID, p)
+	if err != nil {
+		return api.encounterError(err)
+	}
+
+	return api.respondEncounter(w, r, p, http.StatusOK, encounter)
+}
+
+// POST /encounter/{id}/next
+func (api *APIServer) handleNextTurn(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	encounter, apiErr := api.getEncounter(r, p, true)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	err := api.storage.NextTurn(encounter, p)
+	if err != nil {
+		return api.encounterError(err)
+	}
+
+	return api.respondEncounter(w, r, p, http.StatusOK, encounter)
+}
+
+// POST /encounter/{id}/damage
+func (api *APIServer) handleEncounterDamage(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	encounter, apiErr := api.getEncounter(r, p, true)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var damage reqData.EncounterDamage
+	err := ReadJsonBody(r, &damage)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	err = api.storage.ApplyDamage(encounter, &damage, p)
+	if err != nil {
+		return api.encounterError(err)
+	}
+
+	return api.respondEncounter(w, r, p, http.StatusOK, encounter)
+}
+
+// POST /encounter/{id}/condition
+func (api *APIServer) handleEncounterCondition(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	encounter, apiErr := api.getEncounter(r, p, true)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var condition reqData.EncounterCondition
+	err := ReadJsonBody(r, &condition)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	err = api.storage.SetCondition(encounter, &condition, p)
+	if err != nil {
+		return api.encounterError(err)
+	}
+
+	return api.respondEncounter(w, r, p, http.StatusOK, encounter)
+}
+
+// POST /encounter/{id}/end
+func (api *APIServer) handleEndEncounter(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	encounter, apiErr := api.getEncounter(r, p, true)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	_, err := api.storage.EndEncounter(encounter, p)
+	if err != nil {
+		return api.encounterError(err)
+	}
+
+	return api.respondEncounter(w, r, p, http.StatusOK, encounter)
+}
+
+// GET /encounter/{id}/changes
+func (api *APIServer) handleGetEncounterChanges(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	encounter, apiErr := api.getEncounter(r, p, false)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var since int64
+	if sinceValue := r.URL.Query().Get("since"); sinceValue != "" {
+		number, err := strconv.ParseInt(sinceValue, 10, 64)
+		if err != nil || number < 0 {
+			return api.HandleErrorString(fmt.Sprintf("change id %q is invalid", sinceValue)).WithCode(http.StatusBadRequest)
+		}
+		since = number
+	}
+
+	gm := p.CurrentGame.GMID == p.ID
+	changes, lastChangeID, err := api.storage.GetEncounterChanges(encounter, since, gm)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	encounterChanges := respData.EncounterChanges{
+		Changes:      changes,
+		LastChangeID: lastChangeID,
+	}
+	if len(changes) > 0 {
+		encounterChanges.Encounter = respData.EncounterToEncounterInfo(encounter, gm)
+		encounterChanges.Encounter.LastChangeID = lastChangeID
+	}
+
+	return api.Respond(r, w, http.StatusOK, encounterChanges)
+}
@@ -15,16 +15,36 @@ import (
 func (api *APIServer) SetHandlers(router *http.ServeMux) {
 
 	router.HandleFunc("GET /login/{accesskey}", api.HTTPWrapper(api.handleLogin))
+	router.HandleFunc("POST /login/telegram", api.HTTPWrapper(api.handleTelegramLogin))
+
+	router.HandleFunc("GET /events", api.HTTPWrapper(api.StreamWrapper(api.handleEvents)))
 
 	router.HandleFunc("GET /records", api.HTTPWrapper(api.PlayerWrapper(api.handleGetRecords)))
 	router.HandleFunc("POST /record", api.HTTPWrapper(api.PlayerWrapper(api.handlePostRecord)))
 	router.HandleFunc("PUT /record", api.HTTPWrapper(api.PlayerWrapper(api.handleChangeRecord)))
 	router.HandleFunc("DELETE /record/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleDeleteRecord)))
+	router.HandleFunc("GET /record/{id}/comments", api.HTTPWrapper(api.PlayerWrapper(api.handleGetRecordComments)))
+	router.HandleFunc("POST /record/{id}/comment", api.HTTPWrapper(api.PlayerWrapper(api.handlePostComment)))
+	router.HandleFunc("PUT /record/{id}/comment", api.HTTPWrapper(api.PlayerWrapper(api.handleChangeComment)))
+	router.HandleFunc("DELETE /record/{id}/comment/{commentID}", api.HTTPWrapper(api.PlayerWrapper(api.handleDeleteComment)))
+	router.HandleFunc("POST /record/{id}/reaction", api.HTTPWrapper(api.PlayerWrapper(api.handlePostReaction)))
+	router.HandleFunc("GET /comments/unread", api.HTTPWrapper(api.PlayerWrapper(api.handleGetUnreadComments)))
+	router.HandleFunc("POST /comments/read", api.HTTPWrapper(api.PlayerWrapper(api.handleReadComments)))
+
+	router.HandleFunc("GET /notifications", api.HTTPWrapper(api.PlayerWrapper(api.handleGetNotifications)))
+	router.HandleFunc("POST /notifications/read", api.HTTPWrapper(api.PlayerWrapper(api.handleReadNotifications)))
+	router.HandleFunc("GET /digest", api.HTTPWrapper(api.PlayerWrapper(api.handleGetDigest)))
+	router.HandleFunc("POST /digest/seen", api.HTTPWrapper(api.PlayerWrapper(api.handleDigestSeen)))
 
 	router.HandleFunc("GET /chars", api.HTTPWrapper(api.PlayerWrapper(api.handleGetChars)))
 	router.HandleFunc("GET /char/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleGetCharByID)))
 	router.HandleFunc("POST /char", api.HTTPWrapper(api.PlayerWrapper(api.handleCreateChar)))
 	router.HandleFunc("PUT /char", api.HTTPWrapper(api.PlayerWrapper(api.handleUpdateChar)))
+	router.HandleFunc("GET /char/{id}/rewards", api.HTTPWrapper(api.PlayerWrapper(api.handleGetCharRewards)))
+	router.HandleFunc("POST /char/{id}/transfer", api.HTTPWrapper(api.PlayerWrapper(api.handleTransferChar)))
+	router.HandleFunc("POST /char/{id}/status", api.HTTPWrapper(api.PlayerWrapper(api.handleSetCharStatus)))
+	router.HandleFunc("PUT /char/{id}/sheet", api.HTTPWrapper(api.PlayerWrapper(api.handlePutCharSheet)))
+	router.HandleFunc("GET /sheet/templates", api.HTTPWrapper(api.PlayerWrapper(api.handleGetSheetTemplates)))
 
 	router.HandleFunc("GET /npcs", api.HTTPWrapper(api.PlayerWrapper(api.handleGetNPCs)))
 	router.HandleFunc("GET /npc/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleGetNPCByID)))
@@ -36,13 +56,51 @@ func (api *APIServer) SetHandlers(router *http.ServeMux) {
 	router.HandleFunc("POST /location", api.HTTPWrapper(api.PlayerWrapper(api.handleCreateLocation)))
 	router.HandleFunc("PUT /location", api.HTTPWrapper(api.PlayerWrapper(api.handleUpdateLocation)))
 
+	router.HandleFunc("GET /items", api.HTTPWrapper(api.PlayerWrapper(api.handleGetItems)))
+	router.HandleFunc("GET /item/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleGetItemByID)))
+	router.HandleFunc("POST /item", api.HTTPWrapper(api.PlayerWrapper(api.handleCreateItem)))
+	router.HandleFunc("PUT /item", api.HTTPWrapper(api.PlayerWrapper(api.handleUpdateItem)))
+	router.HandleFunc("DELETE /item/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleDeleteItem)))
+	router.HandleFunc("POST /item/transfer", api.HTTPWrapper(api.PlayerWrapper(api.handleTransferItem)))
+
+	router.HandleFunc("GET /factions", api.HTTPWrapper(api.PlayerWrapper(api.handleGetFactions)))
+	router.HandleFunc("GET /faction/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleGetFactionByID)))
+	router.HandleFunc("POST /faction", api.HTTPWrapper(api.PlayerWrapper(api.handleCreateFaction)))
+	router.HandleFunc("PUT /faction", api.HTTPWrapper(api.PlayerWrapper(api.handleUpdateFaction)))
+	router.HandleFunc("POST /faction/standing", api.HTTPWrapper(api.PlayerWrapper(api.handleChangeFactionStanding)))
+
 	router.HandleFunc("GET /quests", api.HTTPWrapper(api.PlayerWrapper(api.handleGetQuests)))
 	router.HandleFunc("GET /quest/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleGetQuestByID)))
 	router.HandleFunc("POST /quest", api.HTTPWrapper(api.PlayerWrapper(api.handleCreateQuest)))
 	router.HandleFunc("PUT /quest", api.HTTPWrapper(api.PlayerWrapper(api.handleUpdateQuest)))
 	router.HandleFunc("DELETE /quest/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleDeleteQuest)))
+	router.HandleFunc("POST /quest/{id}/complete", api.HTTPWrapper(api.PlayerWrapper(api.handleCompleteQuest)))
 
 	router.HandleFunc("PATCH /quest/tasks", api.HTTPWrapper(api.PlayerWrapper(api.handlePatchQuestTasks)))
+	router.HandleFunc("GET /quest/task/{id}/progress", api.HTTPWrapper(api.PlayerWrapper(api.handleGetQuestTaskProgress)))
+
+	router.HandleFunc("POST /gmnote", api.HTTPWrapper(api.PlayerWrapper(api.handleCreateGMNote)))
+	router.HandleFunc("PUT /gmnote", api.HTTPWrapper(api.PlayerWrapper(api.handleUpdateGMNote)))
+	router.HandleFunc("DELETE /gmnote/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleDeleteGMNote)))
+
+	router.HandleFunc("GET /tags", api.HTTPWrapper(api.PlayerWrapper(api.handleGetTags)))
+	router.HandleFunc("PUT /tag", api.HTTPWrapper(api.PlayerWrapper(api.handleRenameTag)))
+	router.HandleFunc("POST /tag/merge", api.HTTPWrapper(api.PlayerWrapper(api.handleMergeTags)))
+
+	router.HandleFunc("POST /roll", api.HTTPWrapper(api.PlayerWrapper(api.handleRoll)))
+	router.HandleFunc("GET /rolls", api.HTTPWrapper(api.PlayerWrapper(api.handleGetRolls)))
+
+	router.HandleFunc("GET /encounters", api.HTTPWrapper(api.PlayerWrapper(api.handleGetEncounters)))
+	router.HandleFunc("POST /encounter", api.HTTPWrapper(api.PlayerWrapper(api.handleCreateEncounter)))
+	router.HandleFunc("GET /encounter/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleGetEncounter)))
+	router.HandleFunc("POST /encounter/{id}/combatant", api.HTTPWrapper(api.PlayerWrapper(api.handleAddCombatant)))
+	router.HandleFunc("PUT /encounter/{id}/combatant", api.HTTPWrapper(api.PlayerWrapper(api.handleUpdateCombatant)))
+	router.HandleFunc("DELETE /encounter/{id}/combatant/{combatantID}", api.HTTPWrapper(api.PlayerWrapper(api.handleRemoveCombatant)))
+	router.HandleFunc("POST /encounter/{id}/next", api.HTTPWrapper(api.PlayerWrapper(api.handleNextTurn)))
+	router.HandleFunc("POST /encounter/{id}/damage", api.HTTPWrapper(api.PlayerWrapper(api.handleEncounterDamage)))
+	router.HandleFunc("POST /encounter/{id}/condition", api.HTTPWrapper(api.PlayerWrapper(api.handleEncounterCondition)))
+	router.HandleFunc("POST /encounter/{id}/end", api.HTTPWrapper(api.PlayerWrapper(api.handleEndEncounter)))
+	router.HandleFunc("GET /encounter/{id}/changes", api.HTTPWrapper(api.PlayerWrapper(api.handleGetEncounterChanges)))
 
 	router.HandleFunc("GET /suggestions", api.HTTPWrapper(api.PlayerWrapper(api.handleGetSuggestions)))
 
@@ -51,6 +109,34 @@ func (api *APIServer) SetHandlers(router *http.ServeMux) {
 
 	router.HandleFunc("POST /game/session/new", api.HTTPWrapper(api.PlayerWrapper(api.handleStartNewGameSession)))
 	router.HandleFunc("PUT /game/settings", api.HTTPWrapper(api.PlayerWrapper(api.handlePutGameSettings)))
+	router.HandleFunc("PUT /game/session", api.HTTPWrapper(api.PlayerWrapper(api.handlePutGameSession)))
+	router.HandleFunc("GET /game/calendar", api.HTTPWrapper(api.PlayerWrapper(api.handleGetGameCalendar)))
+	router.HandleFunc("PUT /game/calendar", api.HTTPWrapper(api.PlayerWrapper(api.handlePutGameCalendar)))
+	router.HandleFunc("GET /game/export", api.HTTPWrapper(api.PlayerWrapper(api.handleExportGame)))
+	router.HandleFunc("POST /game/import", api.HTTPWrapper(api.PlayerWrapper(api.handleImportGame)))
+	router.HandleFunc("GET /game/vault", api.HTTPWrapper(api.PlayerWrapper(api.handleExportVault)))
+	router.HandleFunc("POST /import/{type}", api.HTTPWrapper(api.PlayerWrapper(api.handleBulkImport)))
+	router.HandleFunc("GET /print/{type}/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handlePrint)))
+
+	router.HandleFunc("GET /game/webhooks", api.HTTPWrapper(api.PlayerWrapper(api.handleGetWebhooks)))
+	router.HandleFunc("POST /game/webhook", api.HTTPWrapper(api.PlayerWrapper(api.handleCreateWebhook)))
+	router.HandleFunc("PUT /game/webhook", api.HTTPWrapper(api.PlayerWrapper(api.handleUpdateWebhook)))
+	router.HandleFunc("DELETE /game/webhook/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleDeleteWebhook)))
+	router.HandleFunc("GET /game/webhook/{id}/deliveries", api.HTTPWrapper(api.PlayerWrapper(api.handleGetWebhookDeliveries)))
+	router.HandleFunc("POST /game/webhook/{id}/test", api.HTTPWrapper(api.PlayerWrapper(api.handleTestWebhook)))
+
+	router.HandleFunc("POST /telegram/link", api.HTTPWrapper(api.PlayerWrapper(api.handleCreateTelegramLink)))
+	router.HandleFunc("DELETE /telegram/link", api.HTTPWrapper(api.PlayerWrapper(api.handleDeleteTelegramLink)))
+
+	router.HandleFunc("GET /game/fields", api.HTTPWrapper(api.PlayerWrapper(api.handleGetGameFields)))
+	router.HandleFunc("POST /game/field", api.HTTPWrapper(api.PlayerWrapper(api.handleCreateGameField)))
+	router.HandleFunc("PUT /game/field", api.HTTPWrapper(api.PlayerWrapper(api.handleUpdateGameField)))
+	router.HandleFunc("DELETE /game/field/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleDeleteGameField)))
+
+	router.HandleFunc("GET /timeline", api.HTTPWrapper(api.PlayerWrapper(api.handleGetTimeline)))
+	router.HandleFunc("POST /timeline/event", api.HTTPWrapper(api.PlayerWrapper(api.handleCreateTimelineEvent)))
+	router.HandleFunc("PUT /timeline/event", api.HTTPWrapper(api.PlayerWrapper(api.handleUpdateTimelineEvent)))
+	router.HandleFunc("DELETE /timeline/event/{id}", api.HTTPWrapper(api.PlayerWrapper(api.handleDeleteTimelineEvent)))
 
 	router.HandleFunc("GET /image/{type}/{id}", api.HTTPWrapper(api.handleGetImage))
 	router.HandleFunc("POST /image/{type}/{id}", api.HTTPWrapper(api.handlePostImage))
@@ -73,16 +159,7 @@ func (api *APIServer) handleLogin(w http.ResponseWriter, r *http.Request) *APIEr
 		}
 	}
 
-	loginInfo := respData.LoginInfo{
-		AccessKey: player.AccessKey,
-		Player: respData.PlayerInfo{
-			ID:       player.ID,
-			Username: player.Username,
-		},
-		CurrentGame: *respData.GameToGameFullInfo(player.CurrentGame),
-	}
-
-	return api.Respond(r, w, http.StatusOK, loginInfo)
+	return api.Respond(r, w, http.StatusOK, respData.FormLoginInfo(player))
 }
 
 // GET /records
@@ -92,6 +169,12 @@ func (api *APIServer) handleGetRecords(w http.ResponseWriter, r *http.Request, p
 		return api.HandleError(err)
 	}
 
+	if ids, filtered, apiErr := api.getFilteredIDs(r, p, data.RecordEntity); apiErr != nil {
+		return apiErr
+	} else if filtered {
+		records = filterByIDs(records, ids, func(r data.Record) int { return r.ID })
+	}
+
 	players, err := api.storage.GetCurrentGamePlayers(p.CurrentGame)
 	if err != nil {
 		return api.HandleError(err)
@@ -147,9 +230,20 @@ func (api *APIServer) handleChangeRecord(w http.ResponseWriter, r *http.Request,
 	if err != nil {
 		return api.HandleError(err)
 	}
+	if apiErr := api.readIfMatch(r, &recordUpdate.Version); apiErr != nil {
+		return apiErr
+	}
 
 	err = api.storage.UpdateRecord(&recordUpdate, p)
-	if err != nil {
+	if errors.Is(err, data.ErrVersionConflict) {
+		record, err := api.storage.GetRecordForPlayer(recordUpdate.ID, p)
+		if err != nil {
+			return api.HandleError(err)
+		} else if record == nil {
+			return api.HandleErrorString(fmt.Sprintf("no record with id %d", recordUpdate.ID)).WithCode(http.StatusNotFound)
+		}
+		return api.respondVersionConflict(w, r, record.Version, record)
+	} else if err != nil {
 		return api.HandleError(err)
 	}
 
@@ -195,13 +289,34 @@ func (api *APIServer) handleGetChars(w http.ResponseWriter, r *http.Request, p *
 		return api.HandleError(err)
 	}
 
+	chars, err = api.storage.GetAllowedChars(chars, p.ID)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	if ids, filtered, apiErr := api.getFilteredIDs(r, p, data.CharEntity); apiErr != nil {
+		return apiErr
+	} else if filtered {
+		chars = filterByIDs(chars, ids, func(c data.Char) int { return c.ID })
+	}
+
 	players, err := api.storage.GetCurrentGamePlayers(p.CurrentGame)
 	if err != nil {
 		return api.HandleError(err)
 	}
 
+	activeChars, formerChars := []data.Char{}, []data.Char{}
+	for _, char := range chars {
+		if char.Former() {
+			formerChars = append(formerChars, char)
+		} else {
+			activeChars = append(activeChars, char)
+		}
+	}
+
 	gameChars := respData.GameChars{
-		Chars:       respData.CharToCharInfoArray(chars),
+		Chars:       respData.CharToCharInfoArray(activeChars),
+		FormerChars: respData.CharToCharInfoArray(formerChars),
 		Players:     respData.PlayersToPlayersInfoArray(players),
 		CurrentGame: *respData.GameToGameInfo(p.CurrentGame),
 	}
@@ -216,15 +331,25 @@ func (api *APIServer) handleGetCharByID(w http.ResponseWriter, r *http.Request,
 		return api.HandleError(fmt.Errorf("error parsing id: char id is invalid"))
 	}
 
+	charPage, apiErr := api.getCharPage(charID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	setETag(w, charPage.Char.Version)
+	return api.Respond(r, w, http.StatusOK, charPage)
+}
+
+func (api *APIServer) getCharPage(charID int, p *data.Player) (*respData.CharPage, *APIError) {
 	char, err := api.storage.GetCharByID(charID)
 	if err != nil {
-		return api.HandleError(err)
+		return nil, api.HandleError(err)
 	} else if char == nil {
-		return api.HandleErrorString(fmt.Sprintf("no character with id %d", charID)).WithCode(http.StatusNotFound)
+		return nil, api.HandleErrorString(fmt.Sprintf("no character with id %d", charID)).WithCode(http.StatusNotFound)
 	} else if char.GameID != p.CurrentGameID {
-		return api.HandleErrorString(fmt.Sprintf("char %d is not allowed to request for the game %d", char.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+		return nil, api.HandleErrorString(fmt.Sprintf("char %d is not allowed to request for the game %d", char.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
 	} else if char.HiddenBy != 0 && char.HiddenBy != p.ID {
-		return api.HandleErrorString(fmt.Sprintf("char %d is not allowed to request for the player %d", char.ID, p.ID)).WithCode(http.StatusForbidden)
+		return nil, api.HandleErrorString(fmt.Sprintf("char %d is not allowed to request for the player %d", char.ID, p.ID)).WithCode(http.StatusForbidden)
 	}
 	// ++ Add char check ++//
 
@@ -233,12 +358,34 @@ func (api *APIServer) handleGetCharByID(w http.ResponseWriter, r *http.Request,
 		records, err = api.storage.GetAllowedRecords(char.Records, p.ID)
 	}
 
+	charFullInfo := respData.CharToCharFullInfo(char)
+	charFullInfo.Fields, err = api.storage.GetEntityFields(data.CharEntity, char.ID, p)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+	charFullInfo.Tags, err = api.storage.GetEntityTags(data.CharEntity, char.ID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+
+	sheet, apiErr := api.charSheet(char.ID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	gmNotes, apiErr := api.pageGMNotes(p, data.CharEntity, char.ID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
 	charPage := respData.CharPage{
-		Char:    *respData.CharToCharFullInfo(char),
+		Char:    *charFullInfo,
+		Sheet:   sheet,
 		Records: records, // ** change to mention API type ** //
+		GMNotes: gmNotes,
 	}
 
-	return api.Respond(r, w, http.StatusOK, charPage)
+	return &charPage, nil
 }
 
 // POST /char
@@ -249,13 +396,27 @@ func (api *APIServer) handleCreateChar(w http.ResponseWriter, r *http.Request, p
 		return api.HandleError(err)
 	}
 
+	fieldValues, err := api.storage.ValidateEntityFields(p.CurrentGameID, data.CharEntity, charCreate.Fields, true, p)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
 	char, err := api.storage.CreateChar(&charCreate, p)
 	if err != nil {
 		return api.HandleError(err)
 	}
 
-	charFullInfo := respData.CharToCharFullInfo(char)
-	return api.Respond(r, w, http.StatusCreated, charFullInfo)
+	err = api.storage.SetEntityFields(p.CurrentGameID, data.CharEntity, char.ID, fieldValues)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	err = api.storage.UpdateEntityTags(p.CurrentGameID, data.CharEntity, char.ID, charCreate.Tags)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.respondEntityFullInfo(w, r, p, http.StatusCreated, data.CharEntity, char.ID, respData.CharToCharFullInfo(char))
 }
 
 // PUT /char
@@ -265,6 +426,9 @@ func (api *APIServer) handleUpdateChar(w http.ResponseWriter, r *http.Request, p
 	if err != nil {
 		return api.HandleError(err)
 	}
+	if apiErr := api.readIfMatch(r, &charUpdate.Version); apiErr != nil {
+		return apiErr
+	}
 
 	char, err := api.storage.GetCharByID(charUpdate.ID)
 	if err != nil {
@@ -276,13 +440,61 @@ func (api *APIServer) handleUpdateChar(w http.ResponseWriter, r *http.Request, p
 	}
 	// ++ Add char check ++//
 
+	fieldValues, err := api.storage.ValidateEntityFields(p.CurrentGameID, data.CharEntity, charUpdate.Fields, false, p)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
 	char, err = api.storage.UpdateChar(&charUpdate, char, p)
+	if errors.Is(err, data.ErrVersionConflict) {
+		charPage, apiErr := api.getCharPage(char.ID, p)
+		if apiErr != nil {
+			return apiErr
+		}
+		return api.respondVersionConflict(w, r, charPage.Char.Version, charPage)
+	} else if err != nil {
+		return api.HandleError(err)
+	}
+
+	err = api.storage.SetEntityFields(p.CurrentGameID, data.CharEntity, char.ID, fieldValues)
 	if err != nil {
 		return api.HandleError(err)
 	}
 
-	charFullInfo := respData.CharToCharFullInfo(char)
-	return api.Respond(r, w, http.StatusOK, charFullInfo)
+	err = api.storage.UpdateEntityTags(p.CurrentGameID, data.CharEntity, char.ID, charUpdate.Tags)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	setETag(w, char.Version)
+	return api.respondEntityFullInfo(w, r, p, http.StatusOK, data.CharEntity, char.ID, respData.CharToCharFullInfo(char))
+}
+
+// GET /char/{id}/rewards
+func (api *APIServer) handleGetCharRewards(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	charID := getPathValueInt(r, "id")
+	if charID < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: char id is invalid"))
+	}
+
+	char, err := api.storage.GetCharByID(charID)
+	if err != nil {
+		return api.HandleError(err)
+	} else if char == nil {
+		return api.HandleErrorString(fmt.Sprintf("no character with id %d", charID)).WithCode(http.StatusNotFound)
+	} else if char.GameID != p.CurrentGameID {
+		return api.HandleErrorString(fmt.Sprintf("char %d is not allowed to request for the game %d", char.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	} else if char.HiddenBy != 0 && char.HiddenBy != p.ID {
+		return api.HandleErrorString(fmt.Sprintf("char %d is not allowed to request for the player %d", char.ID, p.ID)).WithCode(http.StatusForbidden)
+	}
+
+	grants, err := api.storage.GetCharRewardGrants(char, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	charRewards := respData.FormCharRewardSummary(char, grants)
+	return api.Respond(r, w, http.StatusOK, charRewards)
 }
 
 // GET /npcs
@@ -297,6 +509,12 @@ func (api *APIServer) handleGetNPCs(w http.ResponseWriter, r *http.Request, p *d
 		return api.HandleError(err)
 	}
 
+	if ids, filtered, apiErr := api.getFilteredIDs(r, p, data.NPCEntity); apiErr != nil {
+		return apiErr
+	} else if filtered {
+		npcs = filterByIDs(npcs, ids, func(n data.NPC) int { return n.ID })
+	}
+
 	gameNPCs := respData.GameNPCs{
 		NPCs:        respData.NPCToNPCInfoArray(npcs),
 		CurrentGame: *respData.GameToGameInfo(p.CurrentGame),
@@ -312,15 +530,25 @@ func (api *APIServer) handleGetNPCByID(w http.ResponseWriter, r *http.Request, p
 		return api.HandleError(fmt.Errorf("error parsing id: npc id is invalid"))
 	}
 
+	npcPage, apiErr := api.getNPCPage(npcID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	setETag(w, npcPage.NPC.Version)
+	return api.Respond(r, w, http.StatusOK, npcPage)
+}
+
+func (api *APIServer) getNPCPage(npcID int, p *data.Player) (*respData.NPCPage, *APIError) {
 	npc, err := api.storage.GetNPCByID(npcID)
 	if err != nil {
-		return api.HandleError(err)
+		return nil, api.HandleError(err)
 	} else if npc == nil {
-		return api.HandleErrorString(fmt.Sprintf("no npc with id %d", npcID)).WithCode(http.StatusNotFound)
+		return nil, api.HandleErrorString(fmt.Sprintf("no npc with id %d", npcID)).WithCode(http.StatusNotFound)
 	} else if npc.GameID != p.CurrentGameID {
-		return api.HandleErrorString(fmt.Sprintf("npc %d is not allowed to request for the game %d", npc.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+		return nil, api.HandleErrorString(fmt.Sprintf("npc %d is not allowed to request for the game %d", npc.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
 	} else if npc.HiddenBy != 0 && npc.HiddenBy != p.ID {
-		return api.HandleErrorString(fmt.Sprintf("npc %d is not allowed to request for the player %d", npc.ID, p.ID)).WithCode(http.StatusForbidden)
+		return nil, api.HandleErrorString(fmt.Sprintf("npc %d is not allowed to request for the player %d", npc.ID, p.ID)).WithCode(http.StatusForbidden)
 	}
 
 	records := []data.Record{}
@@ -328,12 +556,28 @@ func (api *APIServer) handleGetNPCByID(w http.ResponseWriter, r *http.Request, p
 		records, err = api.storage.GetAllowedRecords(npc.Records, p.ID)
 	}
 
+	npcFullInfo := respData.NPCToNPCFullInfo(npc)
+	npcFullInfo.Fields, err = api.storage.GetEntityFields(data.NPCEntity, npc.ID, p)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+	npcFullInfo.Tags, err = api.storage.GetEntityTags(data.NPCEntity, npc.ID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+
+	gmNotes, apiErr := api.pageGMNotes(p, data.NPCEntity, npc.ID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
 	npcPage := respData.NPCPage{
-		NPC:     *respData.NPCToNPCFullInfo(npc),
+		NPC:     *npcFullInfo,
 		Records: records, // ** change to mention API type ** //
+		GMNotes: gmNotes,
 	}
 
-	return api.Respond(r, w, http.StatusOK, npcPage)
+	return &npcPage, nil
 }
 
 // POST /npc
@@ -344,13 +588,27 @@ func (api *APIServer) handleCreateNPC(w http.ResponseWriter, r *http.Request, p
 		return api.HandleError(err)
 	}
 
+	fieldValues, err := api.storage.ValidateEntityFields(p.CurrentGameID, data.NPCEntity, npcCreate.Fields, true, p)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
 	npc, err := api.storage.CreateNPC(&npcCreate, p)
 	if err != nil {
 		return api.HandleError(err)
 	}
 
-	npcFullInfo := respData.NPCToNPCFullInfo(npc)
-	return api.Respond(r, w, http.StatusCreated, npcFullInfo)
+	err = api.storage.SetEntityFields(p.CurrentGameID, data.NPCEntity, npc.ID, fieldValues)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	err = api.storage.UpdateEntityTags(p.CurrentGameID, data.NPCEntity, npc.ID, npcCreate.Tags)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.respondEntityFullInfo(w, r, p, http.StatusCreated, data.NPCEntity, npc.ID, respData.NPCToNPCFullInfo(npc))
 }
 
 // PUT /npc
@@ -360,6 +618,9 @@ func (api *APIServer) handleUpdateNPC(w http.ResponseWriter, r *http.Request, p
 	if err != nil {
 		return api.HandleError(err)
 	}
+	if apiErr := api.readIfMatch(r, &npcUpdate.Version); apiErr != nil {
+		return apiErr
+	}
 
 	npc, err := api.storage.GetNPCByID(npcUpdate.ID)
 	if err != nil {
@@ -371,13 +632,34 @@ func (api *APIServer) handleUpdateNPC(w http.ResponseWriter, r *http.Request, p
 	}
 	// ++ Add char check ++//
 
+	fieldValues, err := api.storage.ValidateEntityFields(p.CurrentGameID, data.NPCEntity, npcUpdate.Fields, false, p)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
 	npc, err = api.storage.UpdateNPC(&npcUpdate, npc, p)
+	if errors.Is(err, data.ErrVersionConflict) {
+		npcPage, apiErr := api.getNPCPage(npc.ID, p)
+		if apiErr != nil {
+			return apiErr
+		}
+		return api.respondVersionConflict(w, r, npcPage.NPC.Version, npcPage)
+	} else if err != nil {
+		return api.HandleError(err)
+	}
+
+	err = api.storage.SetEntityFields(p.CurrentGameID, data.NPCEntity, npc.ID, fieldValues)
 	if err != nil {
 		return api.HandleError(err)
 	}
 
-	npcFullInfo := respData.NPCToNPCFullInfo(npc)
-	return api.Respond(r, w, http.StatusOK, npcFullInfo)
+	err = api.storage.UpdateEntityTags(p.CurrentGameID, data.NPCEntity, npc.ID, npcUpdate.Tags)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	setETag(w, npc.Version)
+	return api.respondEntityFullInfo(w, r, p, http.StatusOK, data.NPCEntity, npc.ID, respData.NPCToNPCFullInfo(npc))
 }
 
 // GET /locations
@@ -392,6 +674,12 @@ func (api *APIServer) handleGetLocations(w http.ResponseWriter, r *http.Request,
 		return api.HandleError(err)
 	}
 
+	if ids, filtered, apiErr := api.getFilteredIDs(r, p, data.LocationEntity); apiErr != nil {
+		return apiErr
+	} else if filtered {
+		locations = filterByIDs(locations, ids, func(l data.Location) int { return l.ID })
+	}
+
 	gameLocations := respData.GameLocations{
 		Locations:   respData.LocationToLocationInfoArray(locations),
 		CurrentGame: *respData.GameToGameInfo(p.CurrentGame),
@@ -407,15 +695,25 @@ func (api *APIServer) handleGetLocationByID(w http.ResponseWriter, r *http.Reque
 		return api.HandleError(fmt.Errorf("error parsing id: location id is invalid"))
 	}
 
+	locationPage, apiErr := api.getLocationPage(locationID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	setETag(w, locationPage.Location.Version)
+	return api.Respond(r, w, http.StatusOK, locationPage)
+}
+
+func (api *APIServer) getLocationPage(locationID int, p *data.Player) (*respData.LocationPage, *APIError) {
 	location, err := api.storage.GetLocationByID(locationID)
 	if err != nil {
-		return api.HandleError(err)
+		return nil, api.HandleError(err)
 	} else if location == nil {
-		return api.HandleErrorString(fmt.Sprintf("no location with id %d", locationID)).WithCode(http.StatusNotFound)
+		return nil, api.HandleErrorString(fmt.Sprintf("no location with id %d", locationID)).WithCode(http.StatusNotFound)
 	} else if location.GameID != p.CurrentGameID {
-		return api.HandleErrorString(fmt.Sprintf("location %d is not allowed to request for the game %d", location.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+		return nil, api.HandleErrorString(fmt.Sprintf("location %d is not allowed to request for the game %d", location.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
 	} else if location.HiddenBy != 0 && location.HiddenBy != p.ID {
-		return api.HandleErrorString(fmt.Sprintf("location %d is not allowed to request for the player %d", location.ID, p.ID)).WithCode(http.StatusForbidden)
+		return nil, api.HandleErrorString(fmt.Sprintf("location %d is not allowed to request for the player %d", location.ID, p.ID)).WithCode(http.StatusForbidden)
 	}
 
 	// ** change to appropriate model field and join with location request ** //
@@ -431,7 +729,7 @@ func (api *APIServer) handleGetLocationByID(w http.ResponseWriter, r *http.Reque
 
 	locationChildren, err := api.storage.GetLocationChildren(location)
 	if err != nil {
-		return api.HandleError(err)
+		return nil, api.HandleError(err)
 	}
 
 	records := []data.Record{}
@@ -439,17 +737,33 @@ func (api *APIServer) handleGetLocationByID(w http.ResponseWriter, r *http.Reque
 		records, err = api.storage.GetAllowedRecords(location.Records, p.ID)
 	}
 
+	locationFullInfo := respData.LocationToLocationFullInfo(location)
+	locationFullInfo.Fields, err = api.storage.GetEntityFields(data.LocationEntity, location.ID, p)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+	locationFullInfo.Tags, err = api.storage.GetEntityTags(data.LocationEntity, location.ID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+
+	gmNotes, apiErr := api.pageGMNotes(p, data.LocationEntity, location.ID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
 	locationPage := respData.LocationPage{
-		Location: *respData.LocationToLocationFullInfo(location),
+		Location: *locationFullInfo,
 		Records:  records, // ** change to mention API type ** //
 		Includes: respData.LocationToLocationInfoArray(locationChildren),
+		GMNotes:  gmNotes,
 	}
 
 	if locationParent != nil {
 		locationPage.Parent = respData.LocationToLocationInfo(locationParent)
 	}
 
-	return api.Respond(r, w, http.StatusOK, locationPage)
+	return &locationPage, nil
 }
 
 // POST /location
@@ -460,13 +774,27 @@ func (api *APIServer) handleCreateLocation(w http.ResponseWriter, r *http.Reques
 		return api.HandleError(err)
 	}
 
+	fieldValues, err := api.storage.ValidateEntityFields(p.CurrentGameID, data.LocationEntity, locationCreate.Fields, true, p)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
 	location, err := api.storage.CreateLocation(&locationCreate, p)
 	if err != nil {
 		return api.HandleError(err)
 	}
 
-	locationFullInfo := respData.LocationToLocationFullInfo(location)
-	return api.Respond(r, w, http.StatusCreated, locationFullInfo)
+	err = api.storage.SetEntityFields(p.CurrentGameID, data.LocationEntity, location.ID, fieldValues)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	err = api.storage.UpdateEntityTags(p.CurrentGameID, data.LocationEntity, location.ID, locationCreate.Tags)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.respondEntityFullInfo(w, r, p, http.StatusCreated, data.LocationEntity, location.ID, respData.LocationToLocationFullInfo(location))
 }
 
 // PUT /location
@@ -476,6 +804,9 @@ func (api *APIServer) handleUpdateLocation(w http.ResponseWriter, r *http.Reques
 	if err != nil {
 		return api.HandleError(err)
 	}
+	if apiErr := api.readIfMatch(r, &locationUpdate.Version); apiErr != nil {
+		return apiErr
+	}
 
 	location, err := api.storage.GetLocationByID(locationUpdate.ID)
 	if err != nil {
@@ -487,123 +818,161 @@ func (api *APIServer) handleUpdateLocation(w http.ResponseWriter, r *http.Reques
 	}
 	// ++ Add char check ++//
 
+	fieldValues, err := api.storage.ValidateEntityFields(p.CurrentGameID, data.LocationEntity, locationUpdate.Fields, false, p)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
 	location, err = api.storage.UpdateLocation(&locationUpdate, location, p)
+	if errors.Is(err, data.ErrVersionConflict) {
+		locationPage, apiErr := api.getLocationPage(location.ID, p)
+		if apiErr != nil {
+			return apiErr
+		}
+		return api.respondVersionConflict(w, r, locationPage.Location.Version, locationPage)
+	} else if err != nil {
+		return api.HandleError(err)
+	}
+
+	err = api.storage.SetEntityFields(p.CurrentGameID, data.LocationEntity, location.ID, fieldValues)
 	if err != nil {
 		return api.HandleError(err)
 	}
 
-	locationFullInfo := respData.LocationToLocationFullInfo(location)
-	return api.Respond(r, w, http.StatusOK, locationFullInfo)
+	err = api.storage.UpdateEntityTags(p.CurrentGameID, data.LocationEntity, location.ID, locationUpdate.Tags)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	setETag(w, location.Version)
+	return api.respondEntityFullInfo(w, r, p, http.StatusOK, data.LocationEntity, location.ID, respData.LocationToLocationFullInfo(location))
 }
 
-// GET /quests
-func (api *APIServer) handleGetQuests(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
-	quests, err := api.storage.GetCurrentGameQuests(p.CurrentGame)
+// GET /items
+func (api *APIServer) handleGetItems(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	items, err := api.storage.GetCurrentGameItems(p.CurrentGame)
 	if err != nil {
 		return api.HandleError(err)
 	}
 
-	quests, err = api.storage.GetAllowedQuests(quests, p.ID)
+	items, err = api.storage.GetAllowedItems(items, p.ID)
 	if err != nil {
 		return api.HandleError(err)
 	}
 
-	gameQuests := respData.GameQuests{
-		Quests:      respData.QuestToQuestInfoArray(quests),
+	gameItems := respData.GameItems{
+		Items:       respData.ItemToItemInfoArray(items),
 		CurrentGame: *respData.GameToGameInfo(p.CurrentGame),
 	}
 
-	return api.Respond(r, w, http.StatusOK, gameQuests)
+	return api.Respond(r, w, http.StatusOK, gameItems)
 }
 
-// GET /quest/{id}
-func (api *APIServer) handleGetQuestByID(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
-	questID := getPathValueInt(r, "id")
-	if questID < 0 {
-		return api.HandleError(fmt.Errorf("error parsing id: quest id is invalid"))
+// GET /item/{id}
+func (api *APIServer) handleGetItemByID(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	itemID := getPathValueInt(r, "id")
+	if itemID < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: item id is invalid"))
 	}
 
-	quest, err := api.storage.GetQuestByID(questID)
-	if err != nil {
-		return api.HandleError(err)
-	} else if quest == nil {
-		return api.HandleErrorString(fmt.Sprintf("no quest with id %d", questID)).WithCode(http.StatusNotFound)
-	} else if quest.GameID != p.CurrentGameID {
-		return api.HandleErrorString(fmt.Sprintf("quest %d is not allowed to request for the game %d", quest.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	itemPage, apiErr := api.getItemPage(itemID, p)
+	if apiErr != nil {
+		return apiErr
 	}
-	// ++ Add char check ++//
 
-	tasks, err := api.storage.GetTasksByQuest(quest)
+	setETag(w, itemPage.Item.Version)
+	return api.Respond(r, w, http.StatusOK, itemPage)
+}
+
+func (api *APIServer) getItemPage(itemID int, p *data.Player) (*respData.ItemPage, *APIError) {
+	item, apiErr := api.getAllowedItem(itemID, p)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	transfers, err := api.storage.GetItemTransfers(item)
 	if err != nil {
-		return api.HandleError(err)
+		return nil, api.HandleError(err)
 	}
 
 	records := []data.Record{}
-	if len(quest.Records) > 0 {
-		records, err = api.storage.GetAllowedRecords(quest.Records, p.ID)
+	if len(item.Records) > 0 {
+		records, err = api.storage.GetAllowedRecords(item.Records, p.ID)
 	}
 
-	questPage := respData.QuestPage{
-		Quest:   *respData.QuestToQuestFullInfo(quest),
-		Tasks:   respData.TaskToTaskFullInfoArray(tasks),
-		Records: records, // ** change to mention API type ** //
+	itemPage := respData.ItemPage{
+		Item:      *respData.ItemToItemFullInfo(item),
+		Transfers: respData.ItemTransferToItemTransferInfoArray(transfers),
+		Records:   records, // ** change to mention API type ** //
 	}
 
-	return api.Respond(r, w, http.StatusOK, questPage)
+	return &itemPage, nil
 }
 
-// POST /quest
-func (api *APIServer) handleCreateQuest(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
-	var questCreateData reqData.QuestCreateData
-	err := ReadJsonBody(r, &questCreateData)
+// POST /item
+func (api *APIServer) handleCreateItem(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var itemCreate reqData.ItemCreate
+	err := ReadJsonBody(r, &itemCreate)
 	if err != nil {
 		return api.HandleError(err)
 	}
 
-	quest, err := api.storage.CreateQuest(&questCreateData.Quest, questCreateData.Tasks, p)
+	item, err := api.storage.CreateItem(&itemCreate, p)
 	if err != nil {
 		return api.HandleError(err)
 	}
 
-	questFullInfo := respData.QuestToQuestFullInfo(quest)
-	return api.Respond(r, w, http.StatusCreated, questFullInfo)
+	itemFullInfo := respData.ItemToItemFullInfo(item)
+	return api.Respond(r, w, http.StatusCreated, itemFullInfo)
 }
 
-// PUT /quest
-func (api *APIServer) handleUpdateQuest(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
-	var questUpdate reqData.QuestUpdateData
-	err := ReadJsonBody(r, &questUpdate)
+// PUT /item
+func (api *APIServer) handleUpdateItem(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var itemUpdate reqData.ItemUpdate
+	err := ReadJsonBody(r, &itemUpdate)
 	if err != nil {
 		return api.HandleError(err)
 	}
+	if apiErr := api.readIfMatch(r, &itemUpdate.Version); apiErr != nil {
+		return apiErr
+	}
 
-	quest, err := api.storage.GetQuestByID(questUpdate.Quest.ID)
-	if err != nil {
-		return api.HandleError(err)
-	} else if quest == nil {
-		return api.HandleErrorString(fmt.Sprintf("no quest with id %d", questUpdate.Quest.ID)).WithCode(http.StatusNotFound)
-	} else if quest.GameID != p.CurrentGameID {
-		return api.HandleErrorString(fmt.Sprintf("quest %d is not allowed to request for the game %d", quest.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	item, apiErr := api.getAllowedItem(itemUpdate.ID, p)
+	if apiErr != nil {
+		return apiErr
 	}
-	// ++ Add char check ++//
 
-	quest, err = api.storage.UpdateQuest(&questUpdate.Quest, questUpdate.Tasks, quest, p)
-	if err != nil {
+	item, err = api.storage.UpdateItem(&itemUpdate, item, p)
+	if errors.Is(err, data.ErrVersionConflict) {
+		itemPage, apiErr := api.getItemPage(itemUpdate.ID, p)
+		if apiErr != nil {
+			return apiErr
+		}
+		return api.respondVersionConflict(w, r, itemPage.Item.Version, itemPage)
+	} else if errors.Is(err, data.ErrItemQuantity) {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	} else if err != nil {
 		return api.HandleError(err)
 	}
 
-	questFullInfo := respData.QuestToQuestFullInfo(quest)
-	return api.Respond(r, w, http.StatusOK, questFullInfo)
+	itemFullInfo := respData.ItemToItemFullInfo(item)
+	setETag(w, itemFullInfo.Version)
+	return api.Respond(r, w, http.StatusOK, itemFullInfo)
 }
 
-// DELETE /quest
-func (api *APIServer) handleDeleteQuest(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
-	questID := getPathValueInt(r, "id")
-	if questID < 0 {
-		return api.HandleError(fmt.Errorf("error parsing id: quest id is invalid"))
+// DELETE /item/{id}
+func (api *APIServer) handleDeleteItem(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	itemID := getPathValueInt(r, "id")
+	if itemID < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: item id is invalid"))
 	}
 
-	err := api.storage.DeleteQuest(questID, p)
+	item, apiErr := api.getAllowedItem(itemID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	err := api.storage.DeleteItem(item)
 	if err != nil {
 		return api.HandleError(err)
 	}
@@ -611,35 +980,555 @@ func (api *APIServer) handleDeleteQuest(w http.ResponseWriter, r *http.Request,
 	return api.Respond(r, w, http.StatusOK, nil)
 }
 
-// PATCH /quest/tasks/
-func (api *APIServer) handlePatchQuestTasks(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
-	var tasksPatch reqData.QuestTasksPatch
-	err := ReadJsonBody(r, &tasksPatch)
+// POST /item/transfer
+func (api *APIServer) handleTransferItem(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var itemTransfer reqData.ItemTransfer
+	err := ReadJsonBody(r, &itemTransfer)
 	if err != nil {
 		return api.HandleError(err)
 	}
 
-	if tasksPatch.QuestID < 0 {
-		return api.HandleError(fmt.Errorf("error parsing id: quest id is invalid"))
+	item, apiErr := api.getAllowedItem(itemTransfer.ItemID, p)
+	if apiErr != nil {
+		return apiErr
 	}
 
-	quest, err := api.storage.GetQuestByID(tasksPatch.QuestID)
+	item, err = api.storage.TransferItem(&itemTransfer, item, p)
 	if err != nil {
-		return api.HandleError(err)
-	} else if quest == nil {
-		return api.HandleErrorString(fmt.Sprintf("no quest with id %d", tasksPatch.QuestID)).WithCode(http.StatusNotFound)
-	} else if quest.GameID != p.CurrentGameID {
-		return api.HandleErrorString(fmt.Sprintf("quest %d is not allowed to request for the game %d", quest.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
 	}
-	// ++ Add char check ++//
 
-	tasks, err := api.storage.UpdateQuestTasks(tasksPatch.Tasks, quest, p)
+	itemFullInfo := respData.ItemToItemFullInfo(item)
+	return api.Respond(r, w, http.StatusOK, itemFullInfo)
+}
+
+func (api *APIServer) getAllowedItem(itemID int, p *data.Player) (*data.Item, *APIError) {
+	item, err := api.storage.GetItemByID(itemID)
 	if err != nil {
-		return api.HandleError(err)
+		return nil, api.HandleError(err)
+	} else if item == nil || item.Deleted != nil {
+		return nil, api.HandleErrorString(fmt.Sprintf("no item with id %d", itemID)).WithCode(http.StatusNotFound)
+	} else if item.GameID != p.CurrentGameID {
+		return nil, api.HandleErrorString(fmt.Sprintf("item %d is not allowed to request for the game %d", item.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	} else if item.HiddenBy != 0 && item.HiddenBy != p.ID {
+		return nil, api.HandleErrorString(fmt.Sprintf("item %d is not allowed to request for the player %d", item.ID, p.ID)).WithCode(http.StatusForbidden)
 	}
 
-	tasksArrayFullInfo := respData.TaskToTaskFullInfoArray(tasks)
-	return api.Respond(r, w, http.StatusOK, tasksArrayFullInfo)
+	return item, nil
+}
+
+// GET /factions
+func (api *APIServer) handleGetFactions(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	factions, err := api.storage.GetCurrentGameFactions(p.CurrentGame)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	factions, err = api.storage.GetAllowedFactions(factions, p.ID)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	gameFactions := respData.GameFactions{
+		Factions:    respData.FactionToFactionInfoArray(factions),
+		CurrentGame: *respData.GameToGameInfo(p.CurrentGame),
+	}
+
+	return api.Respond(r, w, http.StatusOK, gameFactions)
+}
+
+// GET /faction/{id}
+func (api *APIServer) handleGetFactionByID(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	factionID := getPathValueInt(r, "id")
+	if factionID < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: faction id is invalid"))
+	}
+
+	factionPage, apiErr := api.getFactionPage(factionID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	setETag(w, factionPage.Faction.Version)
+	return api.Respond(r, w, http.StatusOK, factionPage)
+}
+
+func (api *APIServer) getFactionPage(factionID int, p *data.Player) (*respData.FactionPage, *APIError) {
+	faction, apiErr := api.getAllowedFaction(factionID, p)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	standings, err := api.storage.GetFactionStandings(faction)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+
+	standingChanges, err := api.storage.GetFactionStandingChanges(faction)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+
+	records := []data.Record{}
+	if len(faction.Records) > 0 {
+		records, err = api.storage.GetAllowedRecords(faction.Records, p.ID)
+	}
+
+	factionPage := respData.FactionPage{
+		Faction:     *respData.FactionToFactionFullInfo(faction),
+		Members:     respData.FactionMemberToFactionMemberInfoArray(data.AllowedFactionMembers(faction, p.ID)),
+		Standings:   respData.FactionStandingToFactionStandingInfoArray(data.AllowedFactionStandings(standings, p.ID)),
+		StandingLog: respData.FactionStandingChangeToInfoArray(data.AllowedFactionStandingChanges(standingChanges, p.ID)),
+		Records:     records, // ** change to mention API type ** //
+	}
+
+	return &factionPage, nil
+}
+
+// POST /faction
+func (api *APIServer) handleCreateFaction(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var factionCreate reqData.FactionCreate
+	err := ReadJsonBody(r, &factionCreate)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	faction, err := api.storage.CreateFaction(&factionCreate, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	factionFullInfo := respData.FactionToFactionFullInfo(faction)
+	return api.Respond(r, w, http.StatusCreated, factionFullInfo)
+}
+
+// PUT /faction
+func (api *APIServer) handleUpdateFaction(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var factionUpdate reqData.FactionUpdate
+	err := ReadJsonBody(r, &factionUpdate)
+	if err != nil {
+		return api.HandleError(err)
+	}
+	if apiErr := api.readIfMatch(r, &factionUpdate.Version); apiErr != nil {
+		return apiErr
+	}
+
+	faction, apiErr := api.getAllowedFaction(factionUpdate.ID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	faction, err = api.storage.UpdateFaction(&factionUpdate, faction, p)
+	if errors.Is(err, data.ErrVersionConflict) {
+		factionPage, apiErr := api.getFactionPage(factionUpdate.ID, p)
+		if apiErr != nil {
+			return apiErr
+		}
+		return api.respondVersionConflict(w, r, factionPage.Faction.Version, factionPage)
+	} else if err != nil {
+		return api.HandleError(err)
+	}
+
+	factionFullInfo := respData.FactionToFactionFullInfo(faction)
+	setETag(w, factionFullInfo.Version)
+	return api.Respond(r, w, http.StatusOK, factionFullInfo)
+}
+
+// POST /faction/standing
+func (api *APIServer) handleChangeFactionStanding(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	if p.CurrentGame.GMID != p.ID {
+		return api.HandleErrorString("only GM may change faction standings").WithCode(http.StatusForbidden)
+	}
+
+	var standingChange reqData.FactionStandingChange
+	err := ReadJsonBody(r, &standingChange)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	faction, apiErr := api.getAllowedFaction(standingChange.FactionID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	standing, err := api.storage.ChangeFactionStanding(&standingChange, faction, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	standingInfo := respData.FactionStandingToFactionStandingInfoArray([]data.FactionStanding{*standing})[0]
+	return api.Respond(r, w, http.StatusOK, standingInfo)
+}
+
+func (api *APIServer) getAllowedFaction(factionID int, p *data.Player) (*data.Faction, *APIError) {
+	faction, err := api.storage.GetFactionByID(factionID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	} else if faction == nil || faction.Deleted != nil {
+		return nil, api.HandleErrorString(fmt.Sprintf("no faction with id %d", factionID)).WithCode(http.StatusNotFound)
+	} else if faction.GameID != p.CurrentGameID {
+		return nil, api.HandleErrorString(fmt.Sprintf("faction %d is not allowed to request for the game %d", faction.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	} else if faction.HiddenBy != 0 && faction.HiddenBy != p.ID {
+		return nil, api.HandleErrorString(fmt.Sprintf("faction %d is not allowed to request for the player %d", faction.ID, p.ID)).WithCode(http.StatusForbidden)
+	}
+
+	return faction, nil
+}
+
+// GET /quests
+func (api *APIServer) handleGetQuests(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	quests, err := api.storage.GetCurrentGameQuests(p.CurrentGame)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	quests, err = api.storage.GetAllowedQuests(quests, p.ID)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	if ids, filtered, apiErr := api.getFilteredIDs(r, p, data.QuestEntity); apiErr != nil {
+		return apiErr
+	} else if filtered {
+		quests = filterByIDs(quests, ids, func(q data.Quest) int { return q.ID })
+	}
+
+	gameQuests := respData.GameQuests{
+		Quests:      respData.QuestToQuestInfoArray(quests),
+		CurrentGame: *respData.GameToGameInfo(p.CurrentGame),
+	}
+
+	return api.Respond(r, w, http.StatusOK, gameQuests)
+}
+
+// GET /quest/{id}
+func (api *APIServer) handleGetQuestByID(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	questID := getPathValueInt(r, "id")
+	if questID < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: quest id is invalid"))
+	}
+
+	questPage, apiErr := api.getQuestPage(questID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	setETag(w, questPage.Quest.Version)
+	return api.Respond(r, w, http.StatusOK, questPage)
+}
+
+func (api *APIServer) getQuestPage(questID int, p *data.Player) (*respData.QuestPage, *APIError) {
+	quest, err := api.storage.GetQuestByID(questID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	} else if quest == nil {
+		return nil, api.HandleErrorString(fmt.Sprintf("no quest with id %d", questID)).WithCode(http.StatusNotFound)
+	} else if quest.GameID != p.CurrentGameID {
+		return nil, api.HandleErrorString(fmt.Sprintf("quest %d is not allowed to request for the game %d", quest.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	}
+	// ++ Add char check ++//
+
+	tasks, err := api.storage.GetTasksByQuest(quest)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+
+	records := []data.Record{}
+	if len(quest.Records) > 0 {
+		records, err = api.storage.GetAllowedRecords(quest.Records, p.ID)
+	}
+
+	questFullInfo := respData.QuestToQuestFullInfo(quest)
+	questFullInfo.Tags, err = api.storage.GetEntityTags(data.QuestEntity, quest.ID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+
+	gmNotes, apiErr := api.pageGMNotes(p, data.QuestEntity, quest.ID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	questPage := respData.QuestPage{
+		Quest:   *questFullInfo,
+		Tasks:   respData.TaskToTaskFullInfoArray(tasks),
+		Rewards: respData.RewardToRewardInfoArray(data.AllowedQuestRewards(quest, p)),
+		Records: records, // ** change to mention API type ** //
+		GMNotes: gmNotes,
+	}
+
+	return &questPage, nil
+}
+
+// POST /quest
+func (api *APIServer) handleCreateQuest(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var questCreateData reqData.QuestCreateData
+	err := ReadJsonBody(r, &questCreateData)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	quest, err := api.storage.CreateQuest(&questCreateData.Quest, questCreateData.Tasks, questCreateData.Rewards, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	err = api.storage.UpdateEntityTags(p.CurrentGameID, data.QuestEntity, quest.ID, questCreateData.Quest.Tags)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	questFullInfo := respData.QuestToQuestFullInfo(quest)
+	questFullInfo.Tags, err = api.storage.GetEntityTags(data.QuestEntity, quest.ID)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusCreated, questFullInfo)
+}
+
+// PUT /quest
+func (api *APIServer) handleUpdateQuest(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var questUpdate reqData.QuestUpdateData
+	err := ReadJsonBody(r, &questUpdate)
+	if err != nil {
+		return api.HandleError(err)
+	}
+	if apiErr := api.readIfMatch(r, &questUpdate.Quest.Version); apiErr != nil {
+		return apiErr
+	}
+
+	quest, err := api.storage.GetQuestByID(questUpdate.Quest.ID)
+	if err != nil {
+		return api.HandleError(err)
+	} else if quest == nil {
+		return api.HandleErrorString(fmt.Sprintf("no quest with id %d", questUpdate.Quest.ID)).WithCode(http.StatusNotFound)
+	} else if quest.GameID != p.CurrentGameID {
+		return api.HandleErrorString(fmt.Sprintf("quest %d is not allowed to request for the game %d", quest.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	}
+	// ++ Add char check ++//
+
+	quest, err = api.storage.UpdateQuest(&questUpdate.Quest, questUpdate.Tasks, questUpdate.Rewards, quest, p)
+	if errors.Is(err, data.ErrVersionConflict) {
+		questPage, apiErr := api.getQuestPage(questUpdate.Quest.ID, p)
+		if apiErr != nil {
+			return apiErr
+		}
+		return api.respondVersionConflict(w, r, questPage.Quest.Version, questPage)
+	} else if err != nil {
+		return api.HandleError(err)
+	}
+
+	err = api.storage.UpdateEntityTags(p.CurrentGameID, data.QuestEntity, quest.ID, questUpdate.Quest.Tags)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	questFullInfo := respData.QuestToQuestFullInfo(quest)
+	questFullInfo.Tags, err = api.storage.GetEntityTags(data.QuestEntity, quest.ID)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	setETag(w, questFullInfo.Version)
+	return api.Respond(r, w, http.StatusOK, questFullInfo)
+}
+
+// DELETE /quest
+func (api *APIServer) handleDeleteQuest(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	questID := getPathValueInt(r, "id")
+	if questID < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: quest id is invalid"))
+	}
+
+	err := api.storage.DeleteQuest(questID, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, nil)
+}
+
+// POST /quest/{id}/complete
+func (api *APIServer) handleCompleteQuest(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	if p.CurrentGame.GMID != p.ID {
+		return api.HandleErrorString("only GM may complete quests").WithCode(http.StatusForbidden)
+	}
+
+	questID := getPathValueInt(r, "id")
+	if questID < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: quest id is invalid"))
+	}
+
+	var questComplete reqData.QuestComplete
+	err := ReadJsonBody(r, &questComplete)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	quest, err := api.storage.GetQuestByID(questID)
+	if err != nil {
+		return api.HandleError(err)
+	} else if quest == nil {
+		return api.HandleErrorString(fmt.Sprintf("no quest with id %d", questID)).WithCode(http.StatusNotFound)
+	} else if quest.GameID != p.CurrentGameID {
+		return api.HandleErrorString(fmt.Sprintf("quest %d is not allowed to request for the game %d", quest.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	grants, err := api.storage.CompleteQuest(quest, questComplete.Successful, p)
+	if err == data.ErrQuestFinished {
+		return api.HandleError(err).WithCode(http.StatusConflict)
+	} else if err != nil {
+		return api.HandleError(err)
+	}
+
+	rewardSummary := respData.FormQuestRewardSummary(quest, grants)
+	return api.Respond(r, w, http.StatusOK, rewardSummary)
+}
+
+// PATCH /quest/tasks/
+func (api *APIServer) handlePatchQuestTasks(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var tasksPatch reqData.QuestTasksPatch
+	err := ReadJsonBody(r, &tasksPatch)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	if tasksPatch.QuestID < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: quest id is invalid"))
+	}
+
+	quest, err := api.storage.GetQuestByID(tasksPatch.QuestID)
+	if err != nil {
+		return api.HandleError(err)
+	} else if quest == nil {
+		return api.HandleErrorString(fmt.Sprintf("no quest with id %d", tasksPatch.QuestID)).WithCode(http.StatusNotFound)
+	} else if quest.GameID != p.CurrentGameID {
+		return api.HandleErrorString(fmt.Sprintf("quest %d is not allowed to request for the game %d", quest.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	}
+	// ++ Add char check ++//
+
+	tasks, err := api.storage.UpdateQuestTasks(&tasksPatch, quest, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	tasksArrayFullInfo := respData.TaskToTaskFullInfoArray(tasks)
+	return api.Respond(r, w, http.StatusOK, tasksArrayFullInfo)
+}
+
+// GET /quest/task/{id}/progress
+func (api *APIServer) handleGetQuestTaskProgress(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	taskID := getPathValueInt(r, "id")
+	if taskID < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: task id is invalid"))
+	}
+
+	task, err := api.storage.GetTaskByID(taskID)
+	if err != nil {
+		return api.HandleError(err)
+	} else if task == nil {
+		return api.HandleErrorString(fmt.Sprintf("no task with id %d", taskID)).WithCode(http.StatusNotFound)
+	} else if task.GameID != p.CurrentGameID {
+		return api.HandleErrorString(fmt.Sprintf("task %d is not allowed to request for the game %d", task.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	} else if (task.HiddenBy != 0 && task.HiddenBy != p.ID) || (task.Quest != nil && task.Quest.HiddenBy != 0 && task.Quest.HiddenBy != p.ID) {
+		return api.HandleErrorString(fmt.Sprintf("task %d is not allowed to request for the player %d", task.ID, p.ID)).WithCode(http.StatusForbidden)
+	}
+
+	progress, err := api.storage.GetTaskProgress(task)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	taskProgress := respData.QuestTaskTimeline{
+		Task:     *respData.TaskToTaskFullInfo(task),
+		Progress: respData.TaskProgressToTaskProgressInfoArray(progress),
+	}
+
+	return api.Respond(r, w, http.StatusOK, taskProgress)
+}
+
+// GET /tags
+func (api *APIServer) handleGetTags(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	tags, err := api.storage.GetGameTags(p.CurrentGameID, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	gameTags := respData.GameTags{
+		Tags:        respData.TagUsageToTagInfoArray(tags),
+		CurrentGame: *respData.GameToGameInfo(p.CurrentGame),
+	}
+
+	return api.Respond(r, w, http.StatusOK, gameTags)
+}
+
+// PUT /tag
+func (api *APIServer) handleRenameTag(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var tagRename reqData.TagRename
+	err := ReadJsonBody(r, &tagRename)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	tag, apiErr := api.getGMTag(tagRename.ID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	tag, err = api.storage.RenameTag(tag, tagRename.Name)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, respData.TagInfo{ID: tag.ID, Name: tag.Name})
+}
+
+// POST /tag/merge
+func (api *APIServer) handleMergeTags(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var tagMerge reqData.TagMerge
+	err := ReadJsonBody(r, &tagMerge)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	target, apiErr := api.getGMTag(tagMerge.TargetID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var sources []*data.Tag
+	for _, sourceID := range tagMerge.SourceIDs {
+		source, apiErr := api.getGMTag(sourceID, p)
+		if apiErr != nil {
+			return apiErr
+		}
+		sources = append(sources, source)
+	}
+
+	target, err = api.storage.MergeTags(sources, target)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, respData.TagInfo{ID: target.ID, Name: target.Name})
+}
+
+func (api *APIServer) getGMTag(tagID int, p *data.Player) (*data.Tag, *APIError) {
+	if p.CurrentGame.GMID != p.ID {
+		return nil, api.HandleErrorString("only GM may rename or merge tags").WithCode(http.StatusForbidden)
+	}
+
+	tag, err := api.storage.GetTagByID(tagID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	} else if tag == nil {
+		return nil, api.HandleErrorString(fmt.Sprintf("no tag with id %d", tagID)).WithCode(http.StatusNotFound)
+	} else if tag.GameID != p.CurrentGameID {
+		return nil, api.HandleErrorString(fmt.Sprintf("tag %d is not allowed to request for the game %d", tag.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	return tag, nil
 }
 
 // GET /suggestions
@@ -703,7 +1592,9 @@ func (api *APIServer) handlePutGameSettings(w http.ResponseWriter, r *http.Reque
 	}
 
 	currentGame, err := api.storage.UpdateGameSettings(&gameSettingsUpdate)
-	if err != nil {
+	if errors.Is(err, data.ErrUnknownSheetTemplate) {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	} else if err != nil {
 		return api.HandleError(err)
 	}
 
@@ -711,6 +1602,301 @@ func (api *APIServer) handlePutGameSettings(w http.ResponseWriter, r *http.Reque
 	return api.Respond(r, w, http.StatusOK, currentGameInfo)
 }
 
+// PUT /game/session
+func (api *APIServer) handlePutGameSession(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	if p.CurrentGame.GMID != p.ID {
+		return api.HandleErrorString("only GM may change sessions").WithCode(http.StatusForbidden)
+	}
+
+	var sessionUpdate reqData.GameSessionUpdate
+	err := ReadJsonBody(r, &sessionUpdate)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	session, err := api.storage.UpdateGameSession(&sessionUpdate, p.CurrentGame)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	} else if session == nil {
+		return api.HandleErrorString(fmt.Sprintf("no session with number %d", sessionUpdate.Number)).WithCode(http.StatusNotFound)
+	}
+
+	sessionInfo := respData.SessionToSessionInfoArray([]data.Session{*session})[0]
+	return api.Respond(r, w, http.StatusOK, sessionInfo)
+}
+
+// GET /game/calendar
+func (api *APIServer) handleGetGameCalendar(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	calendar, err := api.storage.GetGameCalendar(p.CurrentGameID)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, respData.CalendarToGameCalendar(calendar))
+}
+
+// PUT /game/calendar
+func (api *APIServer) handlePutGameCalendar(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	if p.CurrentGame.GMID != p.ID {
+		return api.HandleErrorString("only GM may change calendar").WithCode(http.StatusForbidden)
+	}
+
+	var calendarUpdate reqData.GameCalendarUpdate
+	err := ReadJsonBody(r, &calendarUpdate)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	calendar, err := api.storage.UpdateGameCalendar(&calendarUpdate, p.CurrentGame)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	return api.Respond(r, w, http.StatusOK, respData.CalendarToGameCalendar(calendar))
+}
+
+// GET /game/fields
+func (api *APIServer) handleGetGameFields(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	fields, err := api.storage.GetGameCustomFields(p.CurrentGameID, r.URL.Query().Get("entity"))
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	gameFields := respData.GameCustomFields{
+		Fields:      respData.CustomFieldToCustomFieldInfoArray(fields),
+		CurrentGame: *respData.GameToGameInfo(p.CurrentGame),
+	}
+
+	return api.Respond(r, w, http.StatusOK, gameFields)
+}
+
+// POST /game/field
+func (api *APIServer) handleCreateGameField(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	if p.CurrentGame.GMID != p.ID {
+		return api.HandleErrorString("only GM may define custom fields").WithCode(http.StatusForbidden)
+	}
+
+	var fieldCreate reqData.CustomFieldCreate
+	err := ReadJsonBody(r, &fieldCreate)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	field, err := api.storage.CreateCustomField(&fieldCreate, p.CurrentGame)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	return api.Respond(r, w, http.StatusCreated, respData.CustomFieldToCustomFieldInfo(field))
+}
+
+// PUT /game/field
+func (api *APIServer) handleUpdateGameField(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var fieldUpdate reqData.CustomFieldUpdate
+	err := ReadJsonBody(r, &fieldUpdate)
+	if err != nil {
+		return api.HandleError(err)
+	}
+	if apiErr := api.readIfMatch(r, &fieldUpdate.Version); apiErr != nil {
+		return apiErr
+	}
+
+	field, apiErr := api.getGMCustomField(fieldUpdate.ID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	field, err = api.storage.UpdateCustomField(&fieldUpdate, field)
+	if errors.Is(err, data.ErrVersionConflict) {
+		current, apiErr := api.getGMCustomField(fieldUpdate.ID, p)
+		if apiErr != nil {
+			return apiErr
+		}
+		return api.respondVersionConflict(w, r, current.Version, respData.CustomFieldToCustomFieldInfo(current))
+	} else if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	setETag(w, field.Version)
+	return api.Respond(r, w, http.StatusOK, respData.CustomFieldToCustomFieldInfo(field))
+}
+
+// DELETE /game/field/{id}
+func (api *APIServer) handleDeleteGameField(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	fieldID := getPathValueInt(r, "id")
+	if fieldID < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: field id is invalid"))
+	}
+
+	field, apiErr := api.getGMCustomField(fieldID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	err := api.storage.DeleteCustomField(field)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, nil)
+}
+
+func (api *APIServer) getGMCustomField(fieldID int, p *data.Player) (*data.CustomField, *APIError) {
+	if p.CurrentGame.GMID != p.ID {
+		return nil, api.HandleErrorString("only GM may define custom fields").WithCode(http.StatusForbidden)
+	}
+
+	field, err := api.storage.GetCustomFieldByID(fieldID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	} else if field == nil || field.Deleted != nil {
+		return nil, api.HandleErrorString(fmt.Sprintf("no custom field with id %d", fieldID)).WithCode(http.StatusNotFound)
+	} else if field.GameID != p.CurrentGameID {
+		return nil, api.HandleErrorString(fmt.Sprintf("custom field %d is not allowed to request for the game %d", field.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	return field, nil
+}
+
+func (api *APIServer) respondEntityFullInfo(w http.ResponseWriter, r *http.Request, p *data.Player, status int, entityType string, entityID int, fullInfo respData.EntityFullInfo) *APIError {
+	fields, err := api.storage.GetEntityFields(entityType, entityID, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+	fullInfo.SetFields(fields)
+
+	tags, err := api.storage.GetEntityTags(entityType, entityID)
+	if err != nil {
+		return api.HandleError(err)
+	}
+	fullInfo.SetTags(tags)
+
+	return api.Respond(r, w, status, fullInfo)
+}
+
+// getFilteredIDs applies ?field.<key>= and ?tag= filters, filtered is false when none passed
+func (api *APIServer) getFilteredIDs(r *http.Request, p *data.Player, entityType string) (ids []int, filtered bool, apiErr *APIError) {
+	if filters := getFieldFilters(r); len(filters) > 0 {
+		fieldIDs, err := api.storage.FilterEntitiesByFields(p.CurrentGameID, entityType, filters)
+		if err != nil {
+			return nil, false, api.HandleError(err).WithCode(http.StatusBadRequest)
+		}
+		ids, filtered = fieldIDs, true
+	}
+
+	if tags := r.URL.Query()["tag"]; len(tags) > 0 {
+		tagIDs, err := api.storage.FilterEntitiesByTags(p.CurrentGameID, entityType, tags)
+		if err != nil {
+			return nil, false, api.HandleError(err)
+		}
+		if filtered {
+			tagIDs = filterByIDs(tagIDs, ids, func(id int) int { return id })
+		}
+		ids, filtered = tagIDs, true
+	}
+
+	return ids, filtered, nil
+}
+
+// GET /timeline
+func (api *APIServer) handleGetTimeline(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	calendar, err := api.storage.GetGameCalendar(p.CurrentGameID)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	entries, err := api.storage.GetTimeline(p.CurrentGame, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	timeline := respData.FormTimeline(p.CurrentGame, calendar, entries)
+	return api.Respond(r, w, http.StatusOK, timeline)
+}
+
+// POST /timeline/event
+func (api *APIServer) handleCreateTimelineEvent(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var eventCreate reqData.TimelineEventCreate
+	err := ReadJsonBody(r, &eventCreate)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	event, err := api.storage.CreateTimelineEvent(&eventCreate, p)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	return api.Respond(r, w, http.StatusCreated, respData.TimelineEventToTimelineEventInfo(event))
+}
+
+// PUT /timeline/event
+func (api *APIServer) handleUpdateTimelineEvent(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var eventUpdate reqData.TimelineEventUpdate
+	err := ReadJsonBody(r, &eventUpdate)
+	if err != nil {
+		return api.HandleError(err)
+	}
+	if apiErr := api.readIfMatch(r, &eventUpdate.Version); apiErr != nil {
+		return apiErr
+	}
+
+	event, apiErr := api.getEditableTimelineEvent(eventUpdate.ID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	event, err = api.storage.UpdateTimelineEvent(&eventUpdate, event, p)
+	if errors.Is(err, data.ErrVersionConflict) {
+		current, apiErr := api.getEditableTimelineEvent(eventUpdate.ID, p)
+		if apiErr != nil {
+			return apiErr
+		}
+		return api.respondVersionConflict(w, r, current.Version, respData.TimelineEventToTimelineEventInfo(current))
+	} else if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	setETag(w, event.Version)
+	return api.Respond(r, w, http.StatusOK, respData.TimelineEventToTimelineEventInfo(event))
+}
+
+// DELETE /timeline/event/{id}
+func (api *APIServer) handleDeleteTimelineEvent(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	eventID := getPathValueInt(r, "id")
+	if eventID < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: timeline event id is invalid"))
+	}
+
+	event, apiErr := api.getEditableTimelineEvent(eventID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	err := api.storage.DeleteTimelineEvent(event)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, nil)
+}
+
+func (api *APIServer) getEditableTimelineEvent(eventID int, p *data.Player) (*data.TimelineEvent, *APIError) {
+	event, err := api.storage.GetTimelineEventByID(eventID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	} else if event == nil || event.Deleted != nil || (event.HiddenBy != 0 && event.HiddenBy != p.ID) {
+		// Events hidden by another player stay unknown even for the GM
+		return nil, api.HandleErrorString(fmt.Sprintf("no timeline event with id %d", eventID)).WithCode(http.StatusNotFound)
+	} else if event.GameID != p.CurrentGameID {
+		return nil, api.HandleErrorString(fmt.Sprintf("timeline event %d is not allowed to request for the game %d", event.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	} else if event.CreatedByID != p.ID && p.CurrentGame.GMID != p.ID {
+		return nil, api.HandleErrorString(fmt.Sprintf("timeline event %d is not allowed to edit for the player %d", event.ID, p.ID)).WithCode(http.StatusForbidden)
+	}
+
+	return event, nil
+}
+
 // GET /image/{type}/{id}
 func (api *APIServer) handleGetImage(w http.ResponseWriter, r *http.Request) *APIError {
 	// ++ add permissions by player ++ //
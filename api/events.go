@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"personae-fasti/data"
+)
+
+const (
+	// Events kept per game to resume a stream
+	eventHistorySize   = 500
+	eventClientBuffer  = 64
+	eventPingInterval  = 25 * time.Second
+	eventRetryInterval = 3000
+)
+
+type streamEvent struct {
+	ID    int64
+	Event data.Event
+}
+
+type eventClient struct {
+	events chan streamEvent
+}
+
+// eventHub keeps the recent events of the games and passes new ones to
+// the connected clients. Event IDs start from the server start time, so
+// the IDs given before a restart are older than any new one
+type eventHub struct {
+	mu      sync.Mutex
+	startID int64
+	lastID  int64
+	history map[int][]streamEvent
+	dropped map[int]int64
+	clients map[int]map[*eventClient]struct{}
+}
+
+func newEventHub(s *data.Storage) *eventHub {
+	startID := time.Now().UnixMilli() * 1000
+	hub := &eventHub{
+		startID: startID,
+		lastID:  startID,
+		history: map[int][]streamEvent{},
+		dropped: map[int]int64{},
+		clients: map[int]map[*eventClient]struct{}{},
+	}
+	s.Subscribe(hub.publish)
+
+	return hub
+}
+
+func (h *eventHub) publish(event data.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	streamEvent := streamEvent{ID: h.lastID, Event: event}
+
+	history := append(h.history[event.GameID], streamEvent)
+	if len(history) > eventHistorySize {
+		h.dropped[event.GameID] = history[len(history)-eventHistorySize-1].ID
+		history = history[len(history)-eventHistorySize:]
+	}
+	h.history[event.GameID] = history
+
+	for client := range h.clients[event.GameID] {
+		select {
+		case client.events <- streamEvent:
+		default:
+			// A slow client reconnects and resumes from its last event
+			delete(h.clients[event.GameID], client)
+			close(client.events)
+		}
+	}
+}
+
+// subscribe returns the client and the events after lastID. The events
+// cannot be resumed if lastID is from before the restart or some events
+// after it are already dropped from the history
+func (h *eventHub) subscribe(gameID int, lastID int64) (*eventClient, []streamEvent, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client := &eventClient{
+		events: make(chan streamEvent, eventClientBuffer),
+	}
+	if h.clients[gameID] == nil {
+		h.clients[gameID] = map[*eventClient]struct{}{}
+	}
+	h.clients[gameID][client] = struct{}{}
+
+	if lastID == 0 {
+		return client, nil, true
+	}
+
+	if lastID < h.startID || lastID > h.lastID || lastID < h.dropped[gameID] {
+		return client, nil, false
+	}
+
+	missed := []streamEvent{}
+	for _, event := range h.history[gameID] {
+		if event.ID > lastID {
+			missed = append(missed, event)
+		}
+	}
+
+	return client, missed, true
+}
+
+func (h *eventHub) unsubscribe(gameID int, client *eventClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[gameID][client]; ok {
+		delete(h.clients[gameID], client)
+		close(client.events)
+	}
+}
+
+// GET /events
+func (api *APIServer) handleEvents(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return api.HandleErrorString("event stream is not supported")
+	} else if p.CurrentGameID == 0 {
+		return api.HandleErrorString("no current game to stream events of").WithCode(http.StatusBadRequest)
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventID")
+	}
+	var lastID int64
+	if lastEventID != "" {
+		var err error
+		lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			return api.HandleErrorString(fmt.Sprintf("last event id %q is invalid", lastEventID)).WithCode(http.StatusBadRequest)
+		}
+	}
+
+	client, missed, resumed := api.events.subscribe(p.CurrentGameID, lastID)
+	defer api.events.unsubscribe(p.CurrentGameID, client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetryInterval)
+	if !resumed {
+		// Too many events were missed, the client reloads everything
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range missed {
+		writeStreamEvent(w, event, p.ID)
+	}
+	flusher.Flush()
+
+	ping := time.NewTicker(eventPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case event, ok := <-client.events:
+			if !ok {
+				return nil
+			}
+			writeStreamEvent(w, event, p.ID)
+			flusher.Flush()
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, event streamEvent, playerID int) {
+	if !event.Event.VisibleTo(playerID) {
+		return
+	}
+
+	eventData, err := json.Marshal(event.Event)
+	if err != nil {
+		log.Printf("failed to encode event %d: %v", event.ID, err)
+		return
+	}
+
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Event.Type, eventData)
+}
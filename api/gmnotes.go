@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/api/models/respData"
+	"personae-fasti/data"
+)
+
+// pageGMNotes returns the notes of the entity for the page, nil for the
+// players so the page has no notes at all
+func (api *APIServer) pageGMNotes(p *data.Player, entityType string, entityID int) ([]respData.GMNoteInfo, *APIError) {
+	if !p.IsGM() {
+		return nil, nil
+	}
+
+	notes, err := api.storage.GetEntityGMNotes(entityType, entityID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+
+	return respData.GMNoteToGMNoteInfoArray(notes), nil
+}
+
+func (api *APIServer) getGMNote(noteID int, p *data.Player) (*data.GMNote, *APIError) {
+	if !p.IsGM() {
+		return nil, api.HandleErrorString("only GM may manage notes").WithCode(http.StatusForbidden)
+	}
+
+	note, err := api.storage.GetGMNoteByID(noteID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	} else if note == nil {
+		return nil, api.HandleErrorString(fmt.Sprintf("no note with id %d", noteID)).WithCode(http.StatusNotFound)
+	} else if note.GameID != p.CurrentGameID {
+		return nil, api.HandleErrorString(fmt.Sprintf("note %d is not allowed to request for the game %d", note.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	return note, nil
+}
+
+// POST /gmnote
+func (api *APIServer) handleCreateGMNote(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	if !p.IsGM() {
+		return api.HandleErrorString("only GM may manage notes").WithCode(http.StatusForbidden)
+	}
+
+	var noteCreate reqData.GMNoteCreate
+	err := ReadJsonBody(r, &noteCreate)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	note, err := api.storage.CreateGMNote(p.CurrentGame, noteCreate.EntityType, noteCreate.EntityID, noteCreate.Text)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	return api.Respond(r, w, http.StatusCreated, respData.GMNoteToGMNoteInfo(note))
+}
+
+// PUT /gmnote
+func (api *APIServer) handleUpdateGMNote(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var noteUpdate reqData.GMNoteUpdate
+	err := ReadJsonBody(r, &noteUpdate)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+	if apiErr := api.readIfMatch(r, &noteUpdate.Version); apiErr != nil {
+		return apiErr
+	}
+
+	note, apiErr := api.getGMNote(noteUpdate.ID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	note, err = api.storage.UpdateGMNote(note, noteUpdate.Text, noteUpdate.Version)
+	if errors.Is(err, data.ErrVersionConflict) {
+		current, apiErr := api.getGMNote(noteUpdate.ID, p)
+		if apiErr != nil {
+			return apiErr
+		}
+		return api.respondVersionConflict(w, r, current.Version, respData.GMNoteToGMNoteInfo(current))
+	} else if err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	setETag(w, note.Version)
+	return api.Respond(r, w, http.StatusOK, respData.GMNoteToGMNoteInfo(note))
+}
+
+// DELETE /gmnote/{id}
+func (api *APIServer) handleDeleteGMNote(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	noteID := getPathValueInt(r, "id")
+	if noteID < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: note id is invalid"))
+	}
+
+	note, apiErr := api.getGMNote(noteID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	err := api.storage.DeleteGMNote(note)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, nil)
+}
@@ -4,9 +4,14 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"time"
 )
 
+// The last action of the player is saved not more often than this
+const lastActionPeriod = time.Minute
+
 func (api *APIServer) HTTPWrapper(f APIFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if APIErr := f(w, r); APIErr != nil {
@@ -15,6 +20,19 @@ func (api *APIServer) HTTPWrapper(f APIFunc) http.HandlerFunc {
 	}
 }
 
+// StreamWrapper is PlayerWrapper for EventSource clients, which cannot
+// set headers and pass the access key in the query
+func (api *APIServer) StreamWrapper(f APIFuncAuth) APIFunc {
+	playerFunc := api.PlayerWrapper(f)
+	return func(w http.ResponseWriter, r *http.Request) *APIError {
+		if accesskey := r.URL.Query().Get("accesskey"); accesskey != "" && r.Header.Get("AccessKey") == "" {
+			r.Header.Set("AccessKey", accesskey)
+		}
+
+		return playerFunc(w, r)
+	}
+}
+
 func (api *APIServer) PlayerWrapper(f APIFuncAuth) APIFunc {
 	return func(w http.ResponseWriter, r *http.Request) *APIError {
 		accesskey := r.Header.Get("AccessKey")
@@ -27,6 +45,12 @@ func (api *APIServer) PlayerWrapper(f APIFuncAuth) APIFunc {
 			}
 		}
 
+		if player.LastAction == nil || time.Since(*player.LastAction) > lastActionPeriod {
+			if err := api.storage.UpdateLastAction(player); err != nil {
+				log.Printf("failed to update the last action of the player %d: %v", player.ID, err)
+			}
+		}
+
 		if APIErr := f(w, r, player); APIErr != nil {
 			return api.Respond(r, w, APIErr.Code, APIErr)
 		}
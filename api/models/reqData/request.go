@@ -1,11 +1,17 @@
 package reqData
 
+import "time"
+
 type RecordInsert struct {
 	Text     string `json:"text"`
 	Hidden   bool   `json:"hidden"`
 	PlayerID int    `json:"-"`
 	GameID   int    `json:"-"`
 	QuestID  int    `json:"questID"`
+
+	WorldStart *WorldDate `json:"worldStart"`
+	WorldEnd   *WorldDate `json:"worldEnd"`
+	Tags       []string   `json:"tags"`
 }
 
 type RecordUpdate struct {
@@ -13,6 +19,18 @@ type RecordUpdate struct {
 	Text    string `json:"text"`
 	Hidden  bool   `json:"hidden"`
 	QuestID int    `json:"questID"`
+	// Version the update is based on, zero overwrites any
+	Version int `json:"version"`
+
+	WorldStart *WorldDate `json:"worldStart"`
+	WorldEnd   *WorldDate `json:"worldEnd"`
+	Tags       []string   `json:"tags"`
+}
+
+type WorldDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
 }
 
 type CharCreate struct {
@@ -20,6 +38,9 @@ type CharCreate struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Hidden      bool   `json:"hidden"`
+
+	Fields map[string]any `json:"fields"`
+	Tags   []string       `json:"tags"`
 }
 
 type CharUpdate struct {
@@ -28,6 +49,10 @@ type CharUpdate struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Hidden      bool   `json:"hidden"`
+	Version     int    `json:"version"`
+
+	Fields map[string]any `json:"fields"`
+	Tags   []string       `json:"tags"`
 }
 
 type NPCCreate struct {
@@ -35,6 +60,9 @@ type NPCCreate struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Hidden      bool   `json:"hidden"`
+
+	Fields map[string]any `json:"fields"`
+	Tags   []string       `json:"tags"`
 }
 
 type NPCUpdate struct {
@@ -43,6 +71,10 @@ type NPCUpdate struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Hidden      bool   `json:"hidden"`
+	Version     int    `json:"version"`
+
+	Fields map[string]any `json:"fields"`
+	Tags   []string       `json:"tags"`
 }
 
 type LocationCreate struct {
@@ -51,6 +83,9 @@ type LocationCreate struct {
 	Description string `json:"description"`
 	ParentID    int    `json:"pid"`
 	Hidden      bool   `json:"hidden"`
+
+	Fields map[string]any `json:"fields"`
+	Tags   []string       `json:"tags"`
 }
 
 type LocationUpdate struct {
@@ -60,21 +95,94 @@ type LocationUpdate struct {
 	Description string `json:"description"`
 	ParentID    int    `json:"pid"`
 	Hidden      bool   `json:"hidden"`
+	Version     int    `json:"version"`
+
+	Fields map[string]any `json:"fields"`
+	Tags   []string       `json:"tags"`
+}
+
+type ItemCreate struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	OwnerType   int    `json:"ownerType"`
+	OwnerID     int    `json:"ownerID"`
+	Hidden      bool   `json:"hidden"`
+}
+
+type ItemUpdate struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	Hidden      bool   `json:"hidden"`
+	Version     int    `json:"version"`
+}
+
+type ItemTransfer struct {
+	ItemID    int `json:"itemID"`
+	OwnerType int `json:"ownerType"`
+	OwnerID   int `json:"ownerID"`
+}
+
+type FactionCreate struct {
+	Name        string                `json:"name"`
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	Members     []FactionMemberUpdate `json:"members"`
+	Hidden      bool                  `json:"hidden"`
+}
+
+type FactionUpdate struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Members are replaced only when present in the request
+	Members []FactionMemberUpdate `json:"members"`
+	Hidden  bool                  `json:"hidden"`
+	Version int                   `json:"version"`
+}
+
+type FactionMemberUpdate struct {
+	CharID int    `json:"charID"`
+	NPCID  int    `json:"npcID"`
+	Rank   string `json:"rank"`
+}
+
+type FactionStandingChange struct {
+	FactionID int    `json:"factionID"`
+	CharID    int    `json:"charID"`
+	Delta     int    `json:"delta"`
+	Reason    string `json:"reason"`
 }
 
 type QuestCreateData struct {
-	Quest QuestCreate  `json:"quest"`
-	Tasks []TaskCreate `json:"tasks"`
+	Quest   QuestCreate    `json:"quest"`
+	Tasks   []TaskCreate   `json:"tasks"`
+	Rewards []RewardCreate `json:"rewards"`
 }
 
 type QuestUpdateData struct {
 	Quest QuestUpdate  `json:"quest"`
 	Tasks []TaskUpdate `json:"tasks"`
+	// Rewards are replaced only when present in the request
+	Rewards []RewardCreate `json:"rewards"`
+}
+
+type QuestComplete struct {
+	Successful bool `json:"successful"`
 }
 
 type QuestTasksPatch struct {
 	QuestID int         `json:"questID"`
 	Tasks   []TaskPatch `json:"tasks"`
+
+	// Record asks to store a record describing the progress made
+	Record       bool `json:"record"`
+	RecordHidden bool `json:"recordHidden"`
 }
 
 type QuestCreate struct {
@@ -88,7 +196,8 @@ type QuestCreate struct {
 
 	Successful bool `json:"successful"`
 
-	Hidden bool `json:"hidden"`
+	Hidden bool     `json:"hidden"`
+	Tags   []string `json:"tags"`
 }
 
 type QuestUpdate struct {
@@ -103,9 +212,11 @@ type QuestUpdate struct {
 
 	Successful bool `json:"successful"`
 
-	Hidden bool `json:"hidden"`
+	Hidden  bool `json:"hidden"`
+	Version int  `json:"version"`
 
-	Finished bool `json:"finished"`
+	Finished bool     `json:"finished"`
+	Tags     []string `json:"tags"`
 }
 
 type TaskCreate struct {
@@ -128,6 +239,17 @@ type TaskUpdate struct {
 	Hidden bool `json:"hidden"`
 }
 
+type RewardCreate struct {
+	Type        int    `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Amount      int    `json:"amount"`
+	CharIDs     []int  `json:"charIDs"`
+
+	HiddenUntilFinished bool `json:"hiddenUntilFinished"`
+	Hidden              bool `json:"hidden"`
+}
+
 type TaskPatch struct {
 	ID      int `json:"id"`
 	Current int `json:"current"`
@@ -140,4 +262,207 @@ type GameChange struct {
 type GameSettingsUpdate struct {
 	GameID              int  `json:"gameID"`
 	AllowAllEditRecords bool `json:"allowAllEditRecords"`
+	// The template is kept if it is not passed
+	SheetTemplate *string `json:"sheetTemplate"`
+}
+
+type GameCalendarUpdate struct {
+	Months     []CalendarMonth `json:"months"`
+	WeekLength int             `json:"weekLength"`
+	Weekdays   []string        `json:"weekdays"`
+	Eras       []CalendarEra   `json:"eras"`
+}
+
+type CalendarMonth struct {
+	Name string `json:"name"`
+	Days int    `json:"days"`
+}
+
+type CalendarEra struct {
+	Name      string `json:"name"`
+	StartYear int    `json:"startYear"`
+}
+
+type GameSessionUpdate struct {
+	Number     int        `json:"number"`
+	Name       string     `json:"name"`
+	WorldStart *WorldDate `json:"worldStart"`
+	WorldEnd   *WorldDate `json:"worldEnd"`
+}
+
+type TimelineEventCreate struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	WorldStart  *WorldDate `json:"worldStart"`
+	WorldEnd    *WorldDate `json:"worldEnd"`
+	Hidden      bool       `json:"hidden"`
+}
+
+type TimelineEventUpdate struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	WorldStart  *WorldDate `json:"worldStart"`
+	WorldEnd    *WorldDate `json:"worldEnd"`
+	Hidden      bool       `json:"hidden"`
+	Version     int        `json:"version"`
+}
+
+type CustomFieldCreate struct {
+	EntityType string   `json:"entityType"`
+	Key        string   `json:"key"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Options    []string `json:"options"`
+	RefType    string   `json:"refType"`
+	Required   bool     `json:"required"`
+	Order      int      `json:"order"`
+}
+
+type CustomFieldUpdate struct {
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	Options  []string `json:"options"`
+	Required bool     `json:"required"`
+	Order    int      `json:"order"`
+	Version  int      `json:"version"`
+}
+
+type TagRename struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type TagMerge struct {
+	SourceIDs []int `json:"sourceIDs"`
+	TargetID  int   `json:"targetID"`
+}
+
+type WebhookCreate struct {
+	URL    string   `json:"url"`
+	Format string   `json:"format"`
+	Events []string `json:"events"`
+}
+
+type WebhookUpdate struct {
+	ID     int      `json:"id"`
+	URL    string   `json:"url"`
+	Format string   `json:"format"`
+	Events []string `json:"events"`
+	Active bool     `json:"active"`
+}
+
+type RollCreate struct {
+	Expression string `json:"expression"`
+	CharID     int    `json:"charID"`
+	Reason     string `json:"reason"`
+	GMOnly     bool   `json:"gmOnly"`
+}
+
+type EncounterCreate struct {
+	Name string `json:"name"`
+}
+
+type CombatantCreate struct {
+	CharID int    `json:"charID"`
+	NPCID  int    `json:"npcID"`
+	Name   string `json:"name"`
+
+	Initiative int  `json:"initiative"`
+	HP         int  `json:"hp"`
+	MaxHP      int  `json:"maxHP"`
+	Hidden     bool `json:"hidden"`
+}
+
+type CombatantUpdate struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+
+	Initiative int  `json:"initiative"`
+	HP         int  `json:"hp"`
+	MaxHP      int  `json:"maxHP"`
+	Hidden     bool `json:"hidden"`
+}
+
+type EncounterDamage struct {
+	CombatantIDs []int `json:"combatantIDs"`
+	// Negative amount heals
+	Amount int `json:"amount"`
+}
+
+type EncounterCondition struct {
+	CombatantID int    `json:"combatantID"`
+	Condition   string `json:"condition"`
+	Remove      bool   `json:"remove"`
+}
+
+type CommentCreate struct {
+	// Zero for the comment to the record itself
+	ParentID int    `json:"parentID"`
+	Text     string `json:"text"`
+	Hidden   bool   `json:"hidden"`
+}
+
+type CommentUpdate struct {
+	ID      int    `json:"id"`
+	Text    string `json:"text"`
+	Hidden  bool   `json:"hidden"`
+	Version int    `json:"version"`
+}
+
+type ReactionSet struct {
+	Emoji  string `json:"emoji"`
+	Remove bool   `json:"remove"`
+}
+
+type NotificationsRead struct {
+	// All notifications are read if there are no IDs
+	IDs []int `json:"ids"`
+}
+
+type CharTransfer struct {
+	// The GM's own ID makes the char a ward
+	PlayerID int `json:"playerID"`
+	Version  int `json:"version"`
+}
+
+type CharStatusUpdate struct {
+	Status string `json:"status"`
+	// Now and the current session if empty
+	Time          *time.Time `json:"time"`
+	SessionNumber *int       `json:"sessionNumber"`
+	Version       int        `json:"version"`
+}
+
+type CharSheetUpdate struct {
+	Version    int            `json:"version"`
+	Level      int            `json:"level"`
+	HP         int            `json:"hp"`
+	MaxHP      int            `json:"maxHP"`
+	Class      string         `json:"class"`
+	Background string         `json:"background"`
+	Abilities  map[string]int `json:"abilities"`
+	Sections   []SheetSection `json:"sections"`
+}
+
+type SheetSection struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+type GMNoteCreate struct {
+	EntityType string `json:"entityType"`
+	EntityID   int    `json:"entityID"`
+	Text       string `json:"text"`
+}
+
+type GMNoteUpdate struct {
+	ID      int    `json:"id"`
+	Text    string `json:"text"`
+	Version int    `json:"version"`
+}
+
+type DigestSeen struct {
+	// Until of the digest the player has seen, now if empty
+	Until *time.Time `json:"until"`
 }
@@ -1,6 +1,11 @@
 package respData
 
-import "personae-fasti/data"
+import (
+	"maps"
+	"slices"
+
+	"personae-fasti/data"
+)
 
 func GameToGameInfo(game *data.Game) *GameInfo {
 	return &GameInfo{
@@ -18,6 +23,7 @@ func GameToGameFullInfo(game *data.Game) *GameFullInfo {
 
 		Settings: &GameSettings{
 			AllowAllEditRecords: game.Settings.AllowAllEditRecords,
+			SheetTemplate:       game.Settings.SheetTemplate,
 		},
 		Sessions: SessionToSessionInfoArray(game.Sessions),
 	}
@@ -27,9 +33,11 @@ func SessionToSessionInfoArray(sessions []data.Session) []SessionInfo {
 	sessionInfoArray := []SessionInfo{}
 	for _, session := range sessions {
 		sessionInfoArray = append(sessionInfoArray, SessionInfo{
-			Number:  session.Number,
-			Name:    session.Name,
-			EndTime: session.EndTime,
+			Number:     session.Number,
+			Name:       session.Name,
+			EndTime:    session.EndTime,
+			WorldStart: session.WorldStart,
+			WorldEnd:   session.WorldEnd,
 		})
 	}
 
@@ -52,12 +60,15 @@ func CharToCharInfoArray(chars []data.Char) []CharInfo {
 	charInfoArray := []CharInfo{}
 	for _, char := range chars {
 		charInfoArray = append(charInfoArray, CharInfo{
-			ID:       char.ID,
-			Name:     char.Name,
-			Title:    char.Title,
-			PlayerID: char.PlayerID,
-			GameID:   char.GameID,
-			HiddenBy: char.HiddenBy,
+			ID:          char.ID,
+			Name:        char.Name,
+			Title:       char.Title,
+			PlayerID:    char.PlayerID,
+			GameID:      char.GameID,
+			HiddenBy:    char.HiddenBy,
+			Status:      charStatus(&char),
+			LeftTime:    char.LeftTime,
+			LeftSession: leftSession(&char),
 		})
 	}
 
@@ -73,7 +84,29 @@ func CharToCharFullInfo(char *data.Char) *CharFullInfo {
 		PlayerID:    char.PlayerID,
 		GameID:      char.GameID,
 		HiddenBy:    char.HiddenBy,
+		Version:     char.Version,
+		Status:      charStatus(char),
+		LeftTime:    char.LeftTime,
+		LeftSession: leftSession(char),
+	}
+}
+
+// charStatus is active for the chars created before the statuses
+func charStatus(char *data.Char) string {
+	if char.Status == "" {
+		return string(data.CharActive)
+	}
+
+	return string(char.Status)
+}
+
+func leftSession(char *data.Char) *int {
+	if char.LeftSession == 0 {
+		return nil
 	}
+	sessionNumber := char.LeftSession
+
+	return &sessionNumber
 }
 
 func NPCToNPCInfoArray(npcs []data.NPC) []NPCInfo {
@@ -99,6 +132,7 @@ func NPCToNPCFullInfo(npc *data.NPC) *NPCFullInfo {
 		Description: npc.Description,
 		GameID:      npc.GameID,
 		HiddenBy:    npc.HiddenBy,
+		Version:     npc.Version,
 	}
 }
 
@@ -136,7 +170,262 @@ func LocationToLocationFullInfo(location *data.Location) *LocationFullInfo {
 		ParentID:    location.ParentID,
 		GameID:      location.GameID,
 		HiddenBy:    location.HiddenBy,
+		Version:     location.Version,
+	}
+}
+
+func ItemToItemInfoArray(items []data.Item) []ItemInfo {
+	itemInfoArray := []ItemInfo{}
+	for _, item := range items {
+		itemInfoArray = append(itemInfoArray, ItemInfo{
+			ID:        item.ID,
+			Name:      item.Name,
+			Title:     item.Title,
+			Quantity:  item.Quantity,
+			OwnerType: int(item.OwnerType),
+			OwnerID:   item.OwnerID,
+			GameID:    item.GameID,
+			HiddenBy:  item.HiddenBy,
+		})
+	}
+
+	return itemInfoArray
+}
+
+func ItemToItemFullInfo(item *data.Item) *ItemFullInfo {
+	return &ItemFullInfo{
+		ID:          item.ID,
+		Name:        item.Name,
+		Title:       item.Title,
+		Description: item.Description,
+		Quantity:    item.Quantity,
+		OwnerType:   int(item.OwnerType),
+		OwnerID:     item.OwnerID,
+		GameID:      item.GameID,
+		HiddenBy:    item.HiddenBy,
+		Version:     item.Version,
+	}
+}
+
+func ItemTransferToItemTransferInfoArray(transfers []data.ItemTransfer) []ItemTransferInfo {
+	transferInfoArray := []ItemTransferInfo{}
+	for _, transfer := range transfers {
+		transferInfo := ItemTransferInfo{
+			ID:       transfer.ID,
+			FromType: int(transfer.FromType),
+			FromID:   transfer.FromID,
+			ToType:   int(transfer.ToType),
+			ToID:     transfer.ToID,
+			Quantity: transfer.Quantity,
+			Created:  transfer.Created,
+		}
+		if transfer.Player != nil {
+			transferInfo.Player = &PlayerInfo{
+				ID:       transfer.Player.ID,
+				Username: transfer.Player.Username,
+			}
+		}
+		if transfer.Session != nil {
+			sessionNumber := transfer.Session.Number
+			transferInfo.SessionNumber = &sessionNumber
+		}
+		transferInfoArray = append(transferInfoArray, transferInfo)
+	}
+
+	return transferInfoArray
+}
+
+// RollToRollInfoArray leaves out the chars hidden from the player
+func RollToRollInfoArray(rolls []data.Roll, playerID int) []RollInfo {
+	rollInfoArray := []RollInfo{}
+	for _, roll := range rolls {
+		rollInfoArray = append(rollInfoArray, *RollToRollInfo(&roll, playerID))
+	}
+
+	return rollInfoArray
+}
+
+func RollToRollInfo(roll *data.Roll, playerID int) *RollInfo {
+	rollInfo := RollInfo{
+		ID:         roll.ID,
+		Expression: roll.Expression,
+		Reason:     roll.Reason,
+		Total:      roll.Total,
+		Result:     roll.Result,
+		GMOnly:     roll.GMOnly,
+		RecordID:   roll.RecordID,
+		Created:    roll.Created,
+	}
+	if roll.Result != nil {
+		rollInfo.Detail = roll.Result.Detail()
+	}
+	if roll.Player != nil {
+		rollInfo.Player = &PlayerInfo{
+			ID:       roll.Player.ID,
+			Username: roll.Player.Username,
+		}
+	}
+	if roll.Char != nil && (roll.Char.HiddenBy == 0 || roll.Char.HiddenBy == playerID) {
+		rollInfo.Char = &CharInfo{
+			ID:       roll.Char.ID,
+			Name:     roll.Char.Name,
+			Title:    roll.Char.Title,
+			PlayerID: roll.Char.PlayerID,
+			GameID:   roll.Char.GameID,
+			HiddenBy: roll.Char.HiddenBy,
+		}
+	}
+	if roll.Session != nil {
+		sessionNumber := roll.Session.Number
+		rollInfo.SessionNumber = &sessionNumber
+	}
+
+	return &rollInfo
+}
+
+func EncounterToEncounterInfoArray(encounters []data.Encounter, gm bool) []EncounterInfo {
+	encounterInfoArray := []EncounterInfo{}
+	for _, encounter := range encounters {
+		encounterInfoArray = append(encounterInfoArray, *EncounterToEncounterInfo(&encounter, gm))
+	}
+
+	return encounterInfoArray
+}
+
+// EncounterToEncounterInfo leaves out hidden combatants for players
+func EncounterToEncounterInfo(encounter *data.Encounter, gm bool) *EncounterInfo {
+	encounterInfo := EncounterInfo{
+		ID:         encounter.ID,
+		Name:       encounter.Name,
+		Round:      encounter.Round,
+		TurnID:     encounter.TurnID,
+		RecordID:   encounter.RecordID,
+		Combatants: []CombatantInfo{},
+		Created:    encounter.Created,
+		Ended:      encounter.Ended,
+	}
+	if encounter.Session != nil {
+		sessionNumber := encounter.Session.Number
+		encounterInfo.SessionNumber = &sessionNumber
+	}
+
+	for _, combatant := range encounter.Combatants {
+		if combatant.Hidden && !gm {
+			if combatant.ID == encounter.TurnID {
+				encounterInfo.TurnID = 0
+			}
+			continue
+		}
+
+		conditions := combatant.Conditions
+		if conditions == nil {
+			conditions = []string{}
+		}
+		encounterInfo.Combatants = append(encounterInfo.Combatants, CombatantInfo{
+			ID:         combatant.ID,
+			CharID:     combatant.CharID,
+			NPCID:      combatant.NPCID,
+			Name:       combatant.Name,
+			Initiative: combatant.Initiative,
+			HP:         combatant.HP,
+			MaxHP:      combatant.MaxHP,
+			Conditions: conditions,
+			Hidden:     combatant.Hidden,
+			Defeated:   combatant.Defeated(),
+		})
+	}
+
+	return &encounterInfo
+}
+
+func FactionToFactionInfoArray(factions []data.Faction) []FactionInfo {
+	factionInfoArray := []FactionInfo{}
+	for _, faction := range factions {
+		factionInfoArray = append(factionInfoArray, FactionInfo{
+			ID:       faction.ID,
+			Name:     faction.Name,
+			Title:    faction.Title,
+			GameID:   faction.GameID,
+			HiddenBy: faction.HiddenBy,
+		})
+	}
+
+	return factionInfoArray
+}
+
+func FactionToFactionFullInfo(faction *data.Faction) *FactionFullInfo {
+	return &FactionFullInfo{
+		ID:          faction.ID,
+		Name:        faction.Name,
+		Title:       faction.Title,
+		Description: faction.Description,
+		GameID:      faction.GameID,
+		HiddenBy:    faction.HiddenBy,
+		Version:     faction.Version,
+	}
+}
+
+func FactionMemberToFactionMemberInfoArray(members []data.FactionMember) []FactionMemberInfo {
+	memberInfoArray := []FactionMemberInfo{}
+	for _, member := range members {
+		memberInfo := FactionMemberInfo{
+			ID:     member.ID,
+			CharID: member.CharID,
+			NPCID:  member.NPCID,
+			Rank:   member.Rank,
+		}
+		if member.Char != nil {
+			memberInfo.Name = member.Char.Name
+		} else if member.NPC != nil {
+			memberInfo.Name = member.NPC.Name
+		}
+		memberInfoArray = append(memberInfoArray, memberInfo)
 	}
+
+	return memberInfoArray
+}
+
+func FactionStandingToFactionStandingInfoArray(standings []data.FactionStanding) []FactionStandingInfo {
+	standingInfoArray := []FactionStandingInfo{}
+	for _, standing := range standings {
+		standingInfo := FactionStandingInfo{
+			CharID:   standing.CharID,
+			Standing: standing.Standing,
+		}
+		if standing.Char != nil {
+			standingInfo.CharName = standing.Char.Name
+		}
+		standingInfoArray = append(standingInfoArray, standingInfo)
+	}
+
+	return standingInfoArray
+}
+
+func FactionStandingChangeToInfoArray(changes []data.FactionStandingChange) []FactionStandingChangeInfo {
+	changeInfoArray := []FactionStandingChangeInfo{}
+	for _, change := range changes {
+		changeInfo := FactionStandingChangeInfo{
+			ID:       change.ID,
+			CharID:   change.CharID,
+			Delta:    change.Delta,
+			Standing: change.Standing,
+			Reason:   change.Reason,
+			Created:  change.Created,
+		}
+		if change.Player != nil {
+			changeInfo.Player = &PlayerInfo{
+				ID:       change.Player.ID,
+				Username: change.Player.Username,
+			}
+		}
+		if change.Session != nil {
+			sessionNumber := change.Session.Number
+			changeInfo.SessionNumber = &sessionNumber
+		}
+		changeInfoArray = append(changeInfoArray, changeInfo)
+	}
+
+	return changeInfoArray
 }
 
 func QuestToQuestInfoArray(quests []data.Quest) []QuestInfo {
@@ -176,30 +465,399 @@ func QuestToQuestFullInfo(quest *data.Quest) *QuestFullInfo {
 		GameID:      quest.GameID,
 		Successful:  quest.Successful,
 		HiddenBy:    quest.HiddenBy,
+		Version:     quest.Version,
 		Finished:    finishedQuest,
 	}
 }
 
+func TaskToTaskFullInfo(task *data.QuestTask) *QuestTaskFullInfo {
+	return &QuestTaskFullInfo{
+		ID:          task.ID,
+		QuestID:     task.QuestID,
+		Name:        task.Name,
+		Description: task.Description,
+		GameID:      task.GameID,
+		Type:        int(task.Type),
+		Capacity:    task.Capacity,
+		Current:     task.Current,
+		HiddenBy:    task.HiddenBy,
+		Finished:    task.Finished != nil,
+	}
+}
+
 func TaskToTaskFullInfoArray(tasks []data.QuestTask) []QuestTaskFullInfo {
 	taskInfoArray := []QuestTaskFullInfo{}
 	for _, task := range tasks {
-		finishedTask := false
-		if task.Finished != nil {
-			finishedTask = true
-		}
-		taskInfoArray = append(taskInfoArray, QuestTaskFullInfo{
-			ID:          task.ID,
-			QuestID:     task.QuestID,
-			Name:        task.Name,
-			Description: task.Description,
-			GameID:      task.GameID,
-			Type:        int(task.Type),
-			Capacity:    task.Capacity,
-			Current:     task.Current,
-			HiddenBy:    task.HiddenBy,
-			Finished:    finishedTask,
-		})
+		taskInfoArray = append(taskInfoArray, *TaskToTaskFullInfo(&task))
 	}
 
 	return taskInfoArray
 }
+
+func TaskProgressToTaskProgressInfoArray(progress []data.QuestTaskProgress) []QuestTaskProgressInfo {
+	progressInfoArray := []QuestTaskProgressInfo{}
+	for _, event := range progress {
+		progressInfo := QuestTaskProgressInfo{
+			ID:       event.ID,
+			TaskID:   event.TaskID,
+			Previous: event.Previous,
+			Current:  event.Current,
+			Finished: event.Finished,
+			RecordID: event.RecordID,
+			Created:  event.Created,
+		}
+		if event.Player != nil {
+			progressInfo.Player = &PlayerInfo{
+				ID:       event.Player.ID,
+				Username: event.Player.Username,
+			}
+		}
+		if event.Session != nil {
+			sessionNumber := event.Session.Number
+			progressInfo.SessionNumber = &sessionNumber
+		}
+		progressInfoArray = append(progressInfoArray, progressInfo)
+	}
+
+	return progressInfoArray
+}
+
+func RewardToRewardInfoArray(rewards []data.QuestReward) []QuestRewardInfo {
+	rewardInfoArray := []QuestRewardInfo{}
+	for _, reward := range rewards {
+		charIDs := []int{}
+		for _, char := range reward.Chars {
+			charIDs = append(charIDs, char.ID)
+		}
+		rewardInfoArray = append(rewardInfoArray, QuestRewardInfo{
+			ID:                  reward.ID,
+			QuestID:             reward.QuestID,
+			Type:                int(reward.Type),
+			Name:                reward.Name,
+			Description:         reward.Description,
+			Amount:              reward.Amount,
+			CharIDs:             charIDs,
+			HiddenUntilFinished: reward.HiddenUntilFinished,
+			HiddenBy:            reward.HiddenBy,
+		})
+	}
+
+	return rewardInfoArray
+}
+
+func FormCharRewardSummary(char *data.Char, grants []data.QuestRewardGrant) *CharRewardSummary {
+	summary := CharRewardSummary{
+		Char:   CharToCharInfoArray([]data.Char{*char})[0],
+		Items:  []string{},
+		Boons:  []string{},
+		Grants: []RewardGrantInfo{},
+	}
+
+	for _, grant := range grants {
+		switch grant.Type {
+		case data.Experience:
+			summary.Experience += grant.Amount
+		case data.Gold:
+			summary.Gold += grant.Amount
+		case data.ItemReward:
+			summary.Items = append(summary.Items, grant.Name)
+		case data.Boon:
+			summary.Boons = append(summary.Boons, grant.Name)
+		}
+
+		grantInfo := RewardGrantInfo{
+			ID:       grant.ID,
+			QuestID:  grant.QuestID,
+			RewardID: grant.RewardID,
+			Type:     int(grant.Type),
+			Name:     grant.Name,
+			Amount:   grant.Amount,
+			Created:  grant.Created,
+		}
+		if grant.Quest != nil {
+			grantInfo.Quest = grant.Quest.Name
+		}
+		summary.Grants = append(summary.Grants, grantInfo)
+	}
+
+	return &summary
+}
+
+func FormQuestRewardSummary(quest *data.Quest, grants []data.QuestRewardGrant) *QuestRewardSummary {
+	var charOrder []int
+	charGrants := map[int][]data.QuestRewardGrant{}
+	chars := map[int]*data.Char{}
+	for _, grant := range grants {
+		if _, ok := charGrants[grant.CharID]; !ok {
+			charOrder = append(charOrder, grant.CharID)
+			chars[grant.CharID] = grant.Char
+		}
+		charGrants[grant.CharID] = append(charGrants[grant.CharID], grant)
+	}
+
+	summary := QuestRewardSummary{
+		Quest:      *QuestToQuestFullInfo(quest),
+		Successful: quest.Successful,
+		Chars:      []CharRewardSummary{},
+	}
+	for _, charID := range charOrder {
+		char := chars[charID]
+		if char == nil {
+			char = &data.Char{ID: charID}
+		}
+		summary.Chars = append(summary.Chars, *FormCharRewardSummary(char, charGrants[charID]))
+	}
+
+	return &summary
+}
+
+func CalendarToGameCalendar(calendar *data.GameCalendar) *GameCalendar {
+	return &GameCalendar{
+		Months:     calendar.Months,
+		WeekLength: calendar.WeekLength,
+		Weekdays:   calendar.Weekdays,
+		Eras:       calendar.Eras,
+	}
+}
+
+func FormTimeline(game *data.Game, calendar *data.GameCalendar, entries []data.TimelineEntry) *Timeline {
+	timeline := Timeline{
+		Calendar:    *CalendarToGameCalendar(calendar),
+		Entries:     []TimelineEntryInfo{},
+		CurrentGame: *GameToGameInfo(game),
+	}
+
+	for _, entry := range entries {
+		entryInfo := TimelineEntryInfo{
+			Type:          entry.Type,
+			ID:            entry.ID,
+			Name:          entry.Name,
+			Text:          entry.Text,
+			WorldStart:    entry.WorldStart,
+			WorldEnd:      entry.WorldEnd,
+			Derived:       entry.Derived,
+			SessionNumber: entry.SessionNumber,
+			Time:          entry.Time,
+		}
+		if entry.WorldStart != nil {
+			entryInfo.WorldDate = calendar.Format(entry.WorldStart)
+			entryInfo.Weekday = calendar.Weekday(entry.WorldStart)
+			if entry.WorldEnd != nil && entry.WorldEnd.Compare(entry.WorldStart) != 0 {
+				entryInfo.WorldDate += " – " + calendar.Format(entry.WorldEnd)
+			}
+		}
+		timeline.Entries = append(timeline.Entries, entryInfo)
+	}
+
+	return &timeline
+}
+
+func TimelineEventToTimelineEventInfo(event *data.TimelineEvent) *TimelineEventInfo {
+	return &TimelineEventInfo{
+		ID:          event.ID,
+		Name:        event.Name,
+		Description: event.Description,
+		WorldStart:  event.WorldStart,
+		WorldEnd:    event.WorldEnd,
+		GameID:      event.GameID,
+		HiddenBy:    event.HiddenBy,
+		Version:     event.Version,
+	}
+}
+
+// EntityFullInfo is a full info of an entity with custom fields and tags
+type EntityFullInfo interface {
+	SetFields(fields map[string]any)
+	SetTags(tags []string)
+}
+
+func (c *CharFullInfo) SetFields(fields map[string]any)     { c.Fields = fields }
+func (n *NPCFullInfo) SetFields(fields map[string]any)      { n.Fields = fields }
+func (l *LocationFullInfo) SetFields(fields map[string]any) { l.Fields = fields }
+
+func (c *CharFullInfo) SetTags(tags []string)     { c.Tags = tags }
+func (n *NPCFullInfo) SetTags(tags []string)      { n.Tags = tags }
+func (l *LocationFullInfo) SetTags(tags []string) { l.Tags = tags }
+
+func TagUsageToTagInfoArray(tags []data.TagUsage) []TagInfo {
+	tagInfoArray := []TagInfo{}
+	for _, tag := range tags {
+		tagInfoArray = append(tagInfoArray, TagInfo{
+			ID:    tag.ID,
+			Name:  tag.Name,
+			Count: tag.Count,
+		})
+	}
+
+	return tagInfoArray
+}
+
+func CustomFieldToCustomFieldInfoArray(fields []data.CustomField) []CustomFieldInfo {
+	fieldInfoArray := []CustomFieldInfo{}
+	for _, field := range fields {
+		fieldInfoArray = append(fieldInfoArray, *CustomFieldToCustomFieldInfo(&field))
+	}
+
+	return fieldInfoArray
+}
+
+func CustomFieldToCustomFieldInfo(field *data.CustomField) *CustomFieldInfo {
+	return &CustomFieldInfo{
+		ID:         field.ID,
+		EntityType: field.EntityType,
+		Key:        field.Key,
+		Name:       field.Name,
+		Type:       string(field.Type),
+		Options:    field.Options,
+		RefType:    field.RefType,
+		Required:   field.Required,
+		Order:      field.Order,
+		Version:    field.Version,
+	}
+}
+
+func CommentToCommentInfoArray(comments []data.Comment) []CommentInfo {
+	commentInfoArray := []CommentInfo{}
+	for _, comment := range comments {
+		commentInfoArray = append(commentInfoArray, *CommentToCommentInfo(&comment))
+	}
+
+	return commentInfoArray
+}
+
+func CommentToCommentInfo(comment *data.Comment) *CommentInfo {
+	commentInfo := CommentInfo{
+		ID:       comment.ID,
+		RecordID: comment.RecordID,
+		ParentID: comment.ParentID,
+		Text:     comment.Text,
+		HiddenBy: comment.HiddenBy,
+		Version:  comment.Version,
+		Created:  comment.Created,
+		Updated:  comment.Updated,
+	}
+	if comment.Player != nil {
+		commentInfo.Player = &PlayerInfo{
+			ID:       comment.Player.ID,
+			Username: comment.Player.Username,
+		}
+	}
+
+	return &commentInfo
+}
+
+func NotificationToNotificationInfoArray(notifications []data.Notification) []NotificationInfo {
+	notificationInfoArray := []NotificationInfo{}
+	for _, notification := range notifications {
+		notificationInfo := NotificationInfo{
+			ID:         notification.ID,
+			GameID:     notification.GameID,
+			Type:       notification.Type,
+			EntityType: notification.EntityType,
+			EntityID:   notification.EntityID,
+			Text:       notification.Text,
+			Read:       notification.Read,
+			Created:    notification.Created,
+		}
+		if notification.Actor != nil {
+			notificationInfo.Actor = &PlayerInfo{
+				ID:       notification.Actor.ID,
+				Username: notification.Actor.Username,
+			}
+		}
+		notificationInfoArray = append(notificationInfoArray, notificationInfo)
+	}
+
+	return notificationInfoArray
+}
+
+func GMNoteToGMNoteInfoArray(notes []data.GMNote) []GMNoteInfo {
+	gmNoteInfoArray := []GMNoteInfo{}
+	for _, note := range notes {
+		gmNoteInfoArray = append(gmNoteInfoArray, *GMNoteToGMNoteInfo(&note))
+	}
+
+	return gmNoteInfoArray
+}
+
+func GMNoteToGMNoteInfo(note *data.GMNote) *GMNoteInfo {
+	return &GMNoteInfo{
+		ID:         note.ID,
+		EntityType: note.EntityType,
+		EntityID:   note.EntityID,
+		Text:       note.Text,
+		Version:    note.Version,
+		Created:    note.Created,
+		Updated:    note.Updated,
+	}
+}
+
+// CharSheetToCharSheetInfo orders the abilities as the template does, the
+// ones of a free template by name
+func CharSheetToCharSheetInfo(sheet *data.CharSheet, levelUps []data.CharLevelUp) *CharSheetInfo {
+	template := data.GetSheetTemplate(sheet.Template)
+	if template == nil {
+		template = data.GetSheetTemplate(data.DefaultSheetTemplate)
+	}
+
+	names := slices.Clone(template.Abilities)
+	if len(names) == 0 {
+		names = slices.Sorted(maps.Keys(sheet.Abilities))
+	}
+	abilities := []SheetAbility{}
+	for _, name := range names {
+		score, ok := sheet.Abilities[name]
+		if !ok {
+			continue
+		}
+		ability := SheetAbility{Name: name, Score: score}
+		if template.Modifiers {
+			modifier := data.Modifier(score)
+			ability.Modifier = &modifier
+		}
+		abilities = append(abilities, ability)
+	}
+
+	sections := sheet.Sections
+	if sections == nil {
+		sections = []data.SheetSection{}
+	}
+
+	return &CharSheetInfo{
+		Template:   *template,
+		Level:      sheet.Level,
+		HP:         sheet.HP,
+		MaxHP:      sheet.MaxHP,
+		Class:      sheet.Class,
+		Background: sheet.Background,
+		Abilities:  abilities,
+		Sections:   sections,
+		LevelUps:   CharLevelUpToCharLevelUpInfoArray(levelUps),
+		Updated:    sheet.Updated,
+	}
+}
+
+func CharLevelUpToCharLevelUpInfoArray(levelUps []data.CharLevelUp) []CharLevelUpInfo {
+	levelUpInfoArray := []CharLevelUpInfo{}
+	for _, levelUp := range levelUps {
+		levelUpInfo := CharLevelUpInfo{
+			ID:            levelUp.ID,
+			PreviousLevel: levelUp.PreviousLevel,
+			Level:         levelUp.Level,
+			Created:       levelUp.Created,
+		}
+		if levelUp.Player != nil {
+			levelUpInfo.Player = &PlayerInfo{
+				ID:       levelUp.Player.ID,
+				Username: levelUp.Player.Username,
+			}
+		}
+		if levelUp.Session != nil {
+			sessionNumber := levelUp.Session.Number
+			levelUpInfo.SessionNumber = &sessionNumber
+		}
+		levelUpInfoArray = append(levelUpInfoArray, levelUpInfo)
+	}
+
+	return levelUpInfoArray
+}
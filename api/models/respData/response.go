@@ -2,6 +2,7 @@ package respData
 
 import (
 	"personae-fasti/data"
+	"personae-fasti/dice"
 	"time"
 )
 
@@ -9,6 +10,19 @@ type LoginInfo struct {
 	AccessKey   string       `json:"accesskey"`
 	Player      PlayerInfo   `json:"player"`
 	CurrentGame GameFullInfo `json:"currentGame"`
+	Lang        string       `json:"lang"`
+}
+
+func FormLoginInfo(player *data.Player) *LoginInfo {
+	return &LoginInfo{
+		AccessKey: player.AccessKey,
+		Player: PlayerInfo{
+			ID:       player.ID,
+			Username: player.Username,
+		},
+		CurrentGame: *GameToGameFullInfo(player.CurrentGame),
+		Lang:        player.Lang(),
+	}
 }
 
 type PlayerInfo struct {
@@ -56,13 +70,59 @@ type GameRecords struct {
 }
 
 type GameSettings struct {
-	AllowAllEditRecords bool `json:"allowAllEditRecords"`
+	AllowAllEditRecords bool   `json:"allowAllEditRecords"`
+	SheetTemplate       string `json:"sheetTemplate"`
 }
 
 type SessionInfo struct {
 	Number  int        `json:"number"`
 	Name    string     `json:"name"`
 	EndTime *time.Time `json:"endTime"`
+
+	WorldStart *data.WorldDate `json:"worldStart"`
+	WorldEnd   *data.WorldDate `json:"worldEnd"`
+}
+
+type GameCalendar struct {
+	Months     []data.CalendarMonth `json:"months"`
+	WeekLength int                  `json:"weekLength"`
+	Weekdays   []string             `json:"weekdays"`
+	Eras       []data.CalendarEra   `json:"eras"`
+}
+
+type Timeline struct {
+	Calendar    GameCalendar        `json:"calendar"`
+	Entries     []TimelineEntryInfo `json:"entries"`
+	CurrentGame GameInfo            `json:"currentGame"`
+}
+
+type TimelineEntryInfo struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+	Name string `json:"name,omitempty"`
+	Text string `json:"text,omitempty"`
+
+	WorldStart *data.WorldDate `json:"worldStart"`
+	WorldEnd   *data.WorldDate `json:"worldEnd"`
+	WorldDate  string          `json:"worldDate"`
+	Weekday    string          `json:"weekday,omitempty"`
+	Derived    bool            `json:"derived"`
+
+	SessionNumber *int       `json:"sessionNumber"`
+	Time          *time.Time `json:"time"`
+}
+
+type TimelineEventInfo struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	WorldStart *data.WorldDate `json:"worldStart"`
+	WorldEnd   *data.WorldDate `json:"worldEnd"`
+
+	GameID   int `json:"gameID"`
+	HiddenBy int `json:"hiddenBy"`
+	Version  int `json:"version"`
 }
 
 func FormGameRecords(p *data.Player, rs []data.Record, ps []data.Player, ss []data.Session) *GameRecords {
@@ -88,17 +148,25 @@ type CharInfo struct {
 	PlayerID int `json:"playerID"`
 	GameID   int `json:"gameID"`
 	HiddenBy int `json:"hiddenBy"`
+
+	Status      string     `json:"status"`
+	LeftTime    *time.Time `json:"leftTime"`
+	LeftSession *int       `json:"leftSession"`
 }
 
 type GameChars struct {
 	Chars       []CharInfo   `json:"chars"`
+	FormerChars []CharInfo   `json:"formerChars"`
 	Players     []PlayerInfo `json:"players"`
 	CurrentGame GameInfo     `json:"currentGame"`
 }
 
 type CharPage struct {
-	Char    CharFullInfo  `json:"char"`
-	Records []data.Record `json:"records"`
+	Char    CharFullInfo   `json:"char"`
+	Sheet   *CharSheetInfo `json:"sheet"`
+	Records []data.Record  `json:"records"`
+	// Only for the GM
+	GMNotes []GMNoteInfo `json:"gmNotes,omitempty"`
 }
 
 type CharFullInfo struct {
@@ -110,6 +178,14 @@ type CharFullInfo struct {
 	PlayerID int `json:"playerID"`
 	GameID   int `json:"gameID"`
 	HiddenBy int `json:"hiddenBy"`
+	Version  int `json:"version"`
+
+	Status      string     `json:"status"`
+	LeftTime    *time.Time `json:"leftTime"`
+	LeftSession *int       `json:"leftSession"`
+
+	Fields map[string]any `json:"fields"`
+	Tags   []string       `json:"tags"`
 }
 
 type NPCInfo struct {
@@ -129,6 +205,8 @@ type GameNPCs struct {
 type NPCPage struct {
 	NPC     NPCFullInfo   `json:"npc"`
 	Records []data.Record `json:"records"`
+	// Only for the GM
+	GMNotes []GMNoteInfo `json:"gmNotes,omitempty"`
 }
 
 type NPCFullInfo struct {
@@ -139,6 +217,10 @@ type NPCFullInfo struct {
 
 	GameID   int `json:"gameID"`
 	HiddenBy int `json:"hiddenBy"`
+	Version  int `json:"version"`
+
+	Fields map[string]any `json:"fields"`
+	Tags   []string       `json:"tags"`
 }
 
 type LocationInfo struct {
@@ -160,6 +242,8 @@ type LocationPage struct {
 	Records  []data.Record    `json:"records"`
 	Parent   *LocationInfo    `json:"parent"`
 	Includes []LocationInfo   `json:"includes"`
+	// Only for the GM
+	GMNotes []GMNoteInfo `json:"gmNotes,omitempty"`
 }
 
 type LocationFullInfo struct {
@@ -172,6 +256,124 @@ type LocationFullInfo struct {
 
 	GameID   int `json:"gameID"`
 	HiddenBy int `json:"hiddenBy"`
+	Version  int `json:"version"`
+
+	Fields map[string]any `json:"fields"`
+	Tags   []string       `json:"tags"`
+}
+
+type ItemInfo struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Title    string `json:"title"`
+	Quantity int    `json:"quantity"`
+
+	OwnerType int `json:"ownerType"`
+	OwnerID   int `json:"ownerID"`
+
+	GameID   int `json:"gameID"`
+	HiddenBy int `json:"hiddenBy"`
+}
+
+type GameItems struct {
+	Items       []ItemInfo `json:"items"`
+	CurrentGame GameInfo   `json:"currentGame"`
+}
+
+type ItemPage struct {
+	Item      ItemFullInfo       `json:"item"`
+	Transfers []ItemTransferInfo `json:"transfers"`
+	Records   []data.Record      `json:"records"`
+}
+
+type ItemFullInfo struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+
+	OwnerType int `json:"ownerType"`
+	OwnerID   int `json:"ownerID"`
+
+	GameID   int `json:"gameID"`
+	HiddenBy int `json:"hiddenBy"`
+	Version  int `json:"version"`
+}
+
+type ItemTransferInfo struct {
+	ID int `json:"id"`
+
+	FromType int `json:"fromType"`
+	FromID   int `json:"fromID"`
+	ToType   int `json:"toType"`
+	ToID     int `json:"toID"`
+	Quantity int `json:"quantity"`
+
+	Player        *PlayerInfo `json:"player"`
+	SessionNumber *int        `json:"sessionNumber"`
+
+	Created *time.Time `json:"created"`
+}
+
+type FactionInfo struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Title string `json:"title"`
+
+	GameID   int `json:"gameID"`
+	HiddenBy int `json:"hiddenBy"`
+}
+
+type GameFactions struct {
+	Factions    []FactionInfo `json:"factions"`
+	CurrentGame GameInfo      `json:"currentGame"`
+}
+
+type FactionPage struct {
+	Faction     FactionFullInfo             `json:"faction"`
+	Members     []FactionMemberInfo         `json:"members"`
+	Standings   []FactionStandingInfo       `json:"standings"`
+	StandingLog []FactionStandingChangeInfo `json:"standingLog"`
+	Records     []data.Record               `json:"records"`
+}
+
+type FactionFullInfo struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+
+	GameID   int `json:"gameID"`
+	HiddenBy int `json:"hiddenBy"`
+	Version  int `json:"version"`
+}
+
+type FactionMemberInfo struct {
+	ID     int    `json:"id"`
+	CharID int    `json:"charID,omitempty"`
+	NPCID  int    `json:"npcID,omitempty"`
+	Name   string `json:"name"`
+	Rank   string `json:"rank"`
+}
+
+type FactionStandingInfo struct {
+	CharID   int    `json:"charID"`
+	CharName string `json:"charName"`
+	Standing int    `json:"standing"`
+}
+
+type FactionStandingChangeInfo struct {
+	ID       int    `json:"id"`
+	CharID   int    `json:"charID"`
+	Delta    int    `json:"delta"`
+	Standing int    `json:"standing"`
+	Reason   string `json:"reason"`
+
+	Player        *PlayerInfo `json:"player"`
+	SessionNumber *int        `json:"sessionNumber"`
+
+	Created *time.Time `json:"created"`
 }
 
 type QuestInfo struct {
@@ -194,7 +396,10 @@ type GameQuests struct {
 type QuestPage struct {
 	Quest   QuestFullInfo       `json:"quest"`
 	Tasks   []QuestTaskFullInfo `json:"tasks"`
+	Rewards []QuestRewardInfo   `json:"rewards"`
 	Records []data.Record       `json:"records"`
+	// Only for the GM
+	GMNotes []GMNoteInfo `json:"gmNotes,omitempty"`
 }
 
 type QuestFullInfo struct {
@@ -207,10 +412,12 @@ type QuestFullInfo struct {
 	ChildID  int `json:"childID"`
 	HeadID   int `json:"headID"`
 
-	GameID     int  `json:"gameID"`
-	HiddenBy   int  `json:"hiddenBy"`
-	Successful bool `json:"successful"`
-	Finished   bool `json:"finished"`
+	GameID     int      `json:"gameID"`
+	HiddenBy   int      `json:"hiddenBy"`
+	Version    int      `json:"version"`
+	Successful bool     `json:"successful"`
+	Finished   bool     `json:"finished"`
+	Tags       []string `json:"tags"`
 }
 
 type QuestTaskFullInfo struct {
@@ -229,6 +436,241 @@ type QuestTaskFullInfo struct {
 	HiddenBy int `json:"hiddenBy"`
 }
 
+type QuestRewardInfo struct {
+	ID          int    `json:"id"`
+	QuestID     int    `json:"questID"`
+	Type        int    `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Amount      int    `json:"amount"`
+	CharIDs     []int  `json:"charIDs"`
+
+	HiddenUntilFinished bool `json:"hiddenUntilFinished"`
+	HiddenBy            int  `json:"hiddenBy"`
+}
+
+type QuestRewardSummary struct {
+	Quest      QuestFullInfo       `json:"quest"`
+	Successful bool                `json:"successful"`
+	Chars      []CharRewardSummary `json:"chars"`
+}
+
+type CharRewardSummary struct {
+	Char       CharInfo          `json:"char"`
+	Experience int               `json:"experience"`
+	Gold       int               `json:"gold"`
+	Items      []string          `json:"items"`
+	Boons      []string          `json:"boons"`
+	Grants     []RewardGrantInfo `json:"grants"`
+}
+
+type RewardGrantInfo struct {
+	ID       int        `json:"id"`
+	QuestID  int        `json:"questID"`
+	Quest    string     `json:"quest,omitempty"`
+	RewardID int        `json:"rewardID"`
+	Type     int        `json:"type"`
+	Name     string     `json:"name"`
+	Amount   int        `json:"amount"`
+	Created  *time.Time `json:"created"`
+}
+
+type QuestTaskTimeline struct {
+	Task     QuestTaskFullInfo       `json:"task"`
+	Progress []QuestTaskProgressInfo `json:"progress"`
+}
+
+type QuestTaskProgressInfo struct {
+	ID     int `json:"id"`
+	TaskID int `json:"taskID"`
+
+	Previous int  `json:"previous"`
+	Current  int  `json:"current"`
+	Finished bool `json:"finished"`
+
+	Player        *PlayerInfo `json:"player"`
+	SessionNumber *int        `json:"sessionNumber"`
+	RecordID      int         `json:"recordID"`
+
+	Created *time.Time `json:"created"`
+}
+
+type CustomFieldInfo struct {
+	ID         int      `json:"id"`
+	EntityType string   `json:"entityType"`
+	Key        string   `json:"key"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Options    []string `json:"options"`
+	RefType    string   `json:"refType"`
+	Required   bool     `json:"required"`
+	Order      int      `json:"order"`
+	Version    int      `json:"version"`
+}
+
+type GameCustomFields struct {
+	Fields      []CustomFieldInfo `json:"fields"`
+	CurrentGame GameInfo          `json:"currentGame"`
+}
+
+type TagInfo struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type GameTags struct {
+	Tags        []TagInfo `json:"tags"`
+	CurrentGame GameInfo  `json:"currentGame"`
+}
+
 type SuggestionData struct {
 	Suggestions []data.Suggestion `json:"entities"`
 }
+
+type TelegramLink struct {
+	Code    string     `json:"code"`
+	Expires *time.Time `json:"expires"`
+}
+
+// VersionConflict is returned on a stale update with the current state of
+// the entity for the client to merge
+type VersionConflict struct {
+	Error   string `json:"error"`
+	Current any    `json:"current"`
+}
+
+type RollInfo struct {
+	ID         int          `json:"id"`
+	Expression string       `json:"expression"`
+	Reason     string       `json:"reason"`
+	Total      int          `json:"total"`
+	Detail     string       `json:"detail"`
+	Result     *dice.Result `json:"result"`
+	GMOnly     bool         `json:"gmOnly"`
+
+	Player        *PlayerInfo `json:"player"`
+	Char          *CharInfo   `json:"char"`
+	RecordID      int         `json:"recordID,omitempty"`
+	SessionNumber *int        `json:"sessionNumber"`
+
+	Created *time.Time `json:"created"`
+}
+
+type EncounterInfo struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	SessionNumber *int   `json:"sessionNumber"`
+	Round         int    `json:"round"`
+	TurnID        int    `json:"turnID"`
+	RecordID      int    `json:"recordID,omitempty"`
+
+	Combatants   []CombatantInfo `json:"combatants"`
+	LastChangeID int64           `json:"lastChangeID"`
+
+	Created *time.Time `json:"created"`
+	Ended   *time.Time `json:"ended"`
+}
+
+type CombatantInfo struct {
+	ID         int      `json:"id"`
+	CharID     int      `json:"charID,omitempty"`
+	NPCID      int      `json:"npcID,omitempty"`
+	Name       string   `json:"name"`
+	Initiative int      `json:"initiative"`
+	HP         int      `json:"hp"`
+	MaxHP      int      `json:"maxHP"`
+	Conditions []string `json:"conditions"`
+	Hidden     bool     `json:"hidden"`
+	Defeated   bool     `json:"defeated"`
+}
+
+// EncounterChanges has the encounter only if there are new changes
+type EncounterChanges struct {
+	Encounter    *EncounterInfo         `json:"encounter"`
+	Changes      []data.EncounterChange `json:"changes"`
+	LastChangeID int64                  `json:"lastChangeID"`
+}
+
+type CommentInfo struct {
+	ID       int         `json:"id"`
+	RecordID int         `json:"recordID"`
+	ParentID int         `json:"parentID,omitempty"`
+	Text     string      `json:"text"`
+	HiddenBy int         `json:"hiddenBy"`
+	Version  int         `json:"version"`
+	Player   *PlayerInfo `json:"player"`
+
+	Created *time.Time `json:"created"`
+	Updated *time.Time `json:"updated"`
+}
+
+type RecordComments struct {
+	Comments  []CommentInfo        `json:"comments"`
+	Reactions []data.ReactionCount `json:"reactions"`
+}
+
+type NotificationInfo struct {
+	ID         int         `json:"id"`
+	GameID     int         `json:"gameID"`
+	Type       string      `json:"type"`
+	Actor      *PlayerInfo `json:"actor"`
+	EntityType string      `json:"entityType,omitempty"`
+	EntityID   int         `json:"entityID,omitempty"`
+	Text       string      `json:"text"`
+
+	Read    *time.Time `json:"read"`
+	Created *time.Time `json:"created"`
+}
+
+type Notifications struct {
+	Notifications []NotificationInfo `json:"notifications"`
+	Unread        int                `json:"unread"`
+}
+
+type GMNoteInfo struct {
+	ID         int    `json:"id"`
+	EntityType string `json:"entityType"`
+	EntityID   int    `json:"entityID"`
+	Text       string `json:"text"`
+	Version    int    `json:"version"`
+
+	Created *time.Time `json:"created"`
+	Updated *time.Time `json:"updated"`
+}
+
+type CharSheetInfo struct {
+	Template   data.SheetTemplate  `json:"template"`
+	Level      int                 `json:"level"`
+	HP         int                 `json:"hp"`
+	MaxHP      int                 `json:"maxHP"`
+	Class      string              `json:"class"`
+	Background string              `json:"background"`
+	Abilities  []SheetAbility      `json:"abilities"`
+	Sections   []data.SheetSection `json:"sections"`
+	LevelUps   []CharLevelUpInfo   `json:"levelUps"`
+
+	Updated *time.Time `json:"updated"`
+}
+
+type SheetAbility struct {
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+	Modifier *int   `json:"modifier,omitempty"`
+}
+
+type CharLevelUpInfo struct {
+	ID            int `json:"id"`
+	PreviousLevel int `json:"previousLevel"`
+	Level         int `json:"level"`
+
+	Player        *PlayerInfo `json:"player"`
+	SessionNumber *int        `json:"sessionNumber"`
+
+	Created *time.Time `json:"created"`
+}
+
+type SheetTemplates struct {
+	Templates []data.SheetTemplate `json:"templates"`
+	Current   string               `json:"current"`
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/api/models/respData"
+	"personae-fasti/data"
+)
+
+const (
+	defaultNotificationsLimit = 50
+	maxNotificationsLimit     = 200
+)
+
+// GET /notifications
+func (api *APIServer) handleGetNotifications(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	limit := defaultNotificationsLimit
+	if limitValue := r.URL.Query().Get("limit"); limitValue != "" {
+		number, err := strconv.Atoi(limitValue)
+		if err != nil || number < 1 {
+			return api.HandleErrorString(fmt.Sprintf("limit %q is invalid", limitValue)).WithCode(http.StatusBadRequest)
+		}
+		limit = min(number, maxNotificationsLimit)
+	}
+
+	notifications, err := api.storage.GetPlayerNotifications(p, unreadOnly, limit)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	unread, err := api.storage.CountUnreadNotifications(p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, respData.Notifications{
+		Notifications: respData.NotificationToNotificationInfoArray(notifications),
+		Unread:        unread,
+	})
+}
+
+// POST /notifications/read
+func (api *APIServer) handleReadNotifications(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var notificationsRead reqData.NotificationsRead
+	err := ReadJsonBody(r, &notificationsRead)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	err = api.storage.MarkNotificationsRead(p, notificationsRead.IDs)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, nil)
+}
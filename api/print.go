@@ -0,0 +1,139 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"personae-fasti/api/render"
+	"personae-fasti/data"
+	"strconv"
+)
+
+// GET /print/{type}/{id}
+func (api *APIServer) handlePrint(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	printType := r.PathValue("type")
+	id := getPathValueInt(r, "id")
+	if id < 0 {
+		return api.HandleError(fmt.Errorf("error parsing id: %s id is invalid", printType)).WithCode(http.StatusBadRequest)
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "pdf"
+	} else if format != "pdf" && format != "html" {
+		return api.HandleErrorString(fmt.Sprintf("unknown print format %s", format)).WithCode(http.StatusBadRequest)
+	}
+
+	viewer, apiErr := api.getPrintViewer(r, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var doc *render.Document
+	switch printType {
+	case data.CharEntity:
+		page, apiErr := api.getCharPage(id, viewer)
+		if apiErr != nil {
+			return apiErr
+		}
+		doc = render.CharDocument(page)
+	case data.NPCEntity:
+		page, apiErr := api.getNPCPage(id, viewer)
+		if apiErr != nil {
+			return apiErr
+		}
+		doc = render.NPCDocument(page)
+	case data.LocationEntity:
+		page, apiErr := api.getLocationPage(id, viewer)
+		if apiErr != nil {
+			return apiErr
+		}
+		doc = render.LocationDocument(page, viewer.ID)
+	case data.QuestEntity:
+		page, apiErr := api.getQuestPage(id, viewer)
+		if apiErr != nil {
+			return apiErr
+		} else if page.Quest.HiddenBy != 0 && page.Quest.HiddenBy != viewer.ID {
+			return api.HandleErrorString(fmt.Sprintf("quest %d is not allowed to request for the player %d", id, viewer.ID)).WithCode(http.StatusForbidden)
+		}
+		doc = render.QuestDocument(page, viewer.ID)
+	case "session":
+		session, records, err := api.storage.GetSessionRecordsForPlayer(p.CurrentGame, viewer, id)
+		if err != nil {
+			return api.HandleError(err)
+		} else if session == nil {
+			return api.HandleErrorString(fmt.Sprintf("no session with number %d", id)).WithCode(http.StatusNotFound)
+		}
+		calendar, err := api.storage.GetGameCalendar(p.CurrentGameID)
+		if err != nil {
+			return api.HandleError(err)
+		}
+		doc = render.SessionDocument(p.CurrentGame.Name, session, records, calendar)
+	default:
+		return api.HandleErrorString(fmt.Sprintf("cannot print %s", printType)).WithCode(http.StatusBadRequest)
+	}
+
+	if printType != "session" {
+		// The document is still printed if the file server fails
+		image, found, err := api.getFileServerImage(printType, id)
+		if err != nil {
+			log.Printf("failed to get image for print: %v", err)
+		} else if found {
+			doc.Image = render.ParseImage([]byte(image))
+		}
+	}
+
+	var body []byte
+	var err error
+	if format == "html" {
+		body, err = render.HTML(doc)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	} else {
+		body, err = render.PDF(doc)
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s_%d.pdf\"", printType, id))
+	}
+	if err != nil {
+		w.Header().Del("Content-Disposition")
+		return api.HandleError(err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(body)
+	if err != nil {
+		log.Printf("failed to write printed document: %v", err)
+	}
+	return nil
+}
+
+// getPrintViewer lets GM print the document as one of the players sees it
+func (api *APIServer) getPrintViewer(r *http.Request, p *data.Player) (*data.Player, *APIError) {
+	playerParam := r.URL.Query().Get("player")
+	if playerParam == "" {
+		return p, nil
+	}
+
+	if p.CurrentGame.GMID != p.ID {
+		return nil, api.HandleErrorString("only GM may print for another player").WithCode(http.StatusForbidden)
+	}
+
+	playerID, err := strconv.Atoi(playerParam)
+	if err != nil {
+		return nil, api.HandleErrorString(fmt.Sprintf("player id %q is invalid", playerParam)).WithCode(http.StatusBadRequest)
+	}
+
+	players, err := api.storage.GetCurrentGamePlayers(p.CurrentGame)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+	for _, player := range players {
+		if player.ID == playerID {
+			viewer := *p
+			viewer.ID = player.ID
+			viewer.Username = player.Username
+			return &viewer, nil
+		}
+	}
+
+	return nil, api.HandleErrorString(fmt.Sprintf("player %d does not play the game %d", playerID, p.CurrentGameID)).WithCode(http.StatusNotFound)
+}
@@ -0,0 +1,87 @@
+// Package render turns game pages into printable HTML and PDF documents
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// Document is a printable page, both HTML and PDF are rendered of it
+type Document struct {
+	Title    string
+	Subtitle string
+	Image    *Image
+	Details  []Detail
+	Sections []Section
+}
+
+type Detail struct {
+	Label string
+	Value string
+}
+
+// Section holds paragraphs and then list items under a heading
+type Section struct {
+	Heading    string
+	Paragraphs []string
+	Items      []string
+}
+
+type Image struct {
+	Data []byte
+	MIME string
+}
+
+// DataURI embeds the image into HTML
+func (i *Image) DataURI() string {
+	return "data:" + i.MIME + ";base64," + base64.StdEncoding.EncodeToString(i.Data)
+}
+
+// ParseImage accepts a file server response: raw image bytes,
+// a data URI or base64 encoded image. Unknown content gives nil
+func ParseImage(body []byte) *Image {
+	body = bytes.TrimSpace(body)
+	body = bytes.Trim(body, `"`)
+
+	if rest, ok := bytes.CutPrefix(body, []byte("data:")); ok {
+		_, encoded, found := bytes.Cut(rest, []byte(";base64,"))
+		if !found {
+			return nil
+		}
+		body = encoded
+	}
+
+	if image := detectImage(body); image != nil {
+		return image
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(string(body))
+	if err != nil {
+		return nil
+	}
+
+	return detectImage(decoded)
+}
+
+func detectImage(data []byte) *Image {
+	mime := http.DetectContentType(data)
+	if !strings.HasPrefix(mime, "image/") {
+		return nil
+	}
+
+	return &Image{Data: data, MIME: mime}
+}
+
+// paragraphs splits text by blank lines
+func paragraphs(text string) []string {
+	result := []string{}
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			result = append(result, paragraph)
+		}
+	}
+
+	return result
+}
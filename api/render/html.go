@@ -0,0 +1,53 @@
+package render
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+)
+
+var htmlTemplate = template.Must(template.New("document").Funcs(template.FuncMap{
+	"lines": func(text string) []string { return strings.Split(text, "\n") },
+	"image": func(i *Image) template.URL { return template.URL(i.DataURI()) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+	body { font-family: Georgia, serif; max-width: 48em; margin: 2em auto; color: #222; }
+	h1 { margin-bottom: 0; }
+	.subtitle { font-style: italic; color: #555; margin-top: 0.2em; }
+	.image { float: right; max-width: 14em; max-height: 14em; margin: 0 0 1em 1em; }
+	dl { display: grid; grid-template-columns: max-content auto; gap: 0.2em 1em; }
+	dt { font-weight: bold; }
+	dd { margin: 0; }
+	h2 { clear: both; border-bottom: 1px solid #999; }
+	@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+{{if .Image}}<img class="image" src="{{image .Image}}" alt="">{{end}}
+<h1>{{.Title}}</h1>
+{{if .Subtitle}}<p class="subtitle">{{.Subtitle}}</p>{{end}}
+{{if .Details}}<dl>{{range .Details}}<dt>{{.Label}}</dt><dd>{{.Value}}</dd>{{end}}</dl>{{end}}
+{{range .Sections}}
+<section>
+{{if .Heading}}<h2>{{.Heading}}</h2>{{end}}
+{{range .Paragraphs}}<p>{{range $i, $line := lines .}}{{if $i}}<br>{{end}}{{$line}}{{end}}</p>
+{{end}}{{if .Items}}<ul>{{range .Items}}<li>{{.}}</li>{{end}}</ul>{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
+
+func HTML(doc *Document) ([]byte, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,188 @@
+package render
+
+import (
+	"fmt"
+	"personae-fasti/api/models/respData"
+	"personae-fasti/data"
+	"slices"
+	"strings"
+)
+
+// Pages are already filtered for the player, the checks below skip
+// only what the page types keep for the web client
+
+func visible(hiddenBy, playerID int) bool {
+	return hiddenBy == 0 || hiddenBy == playerID
+}
+
+func CharDocument(page *respData.CharPage) *Document {
+	doc := &Document{
+		Title:    page.Char.Name,
+		Subtitle: page.Char.Title,
+		Details:  entityDetails(page.Char.Fields, page.Char.Tags),
+	}
+	doc.addDescription(page.Char.Description)
+	doc.addRecords(page.Records)
+
+	return doc
+}
+
+func NPCDocument(page *respData.NPCPage) *Document {
+	doc := &Document{
+		Title:    page.NPC.Name,
+		Subtitle: page.NPC.Title,
+		Details:  entityDetails(page.NPC.Fields, page.NPC.Tags),
+	}
+	doc.addDescription(page.NPC.Description)
+	doc.addRecords(page.Records)
+
+	return doc
+}
+
+func LocationDocument(page *respData.LocationPage, playerID int) *Document {
+	doc := &Document{
+		Title:    page.Location.Name,
+		Subtitle: page.Location.Title,
+	}
+	if page.Parent != nil && visible(page.Parent.HiddenBy, playerID) {
+		doc.Details = append(doc.Details, Detail{Label: "Part of", Value: page.Parent.Name})
+	}
+	doc.Details = append(doc.Details, entityDetails(page.Location.Fields, page.Location.Tags)...)
+	doc.addDescription(page.Location.Description)
+
+	includes := Section{Heading: "Locations"}
+	for _, location := range page.Includes {
+		if visible(location.HiddenBy, playerID) {
+			includes.Items = append(includes.Items, joinNotEmpty(" — ", location.Name, location.Title))
+		}
+	}
+	if len(includes.Items) > 0 {
+		doc.Sections = append(doc.Sections, includes)
+	}
+	doc.addRecords(page.Records)
+
+	return doc
+}
+
+func QuestDocument(page *respData.QuestPage, playerID int) *Document {
+	doc := &Document{
+		Title:    page.Quest.Name,
+		Subtitle: page.Quest.Title,
+	}
+
+	status := "Active"
+	if page.Quest.Finished {
+		status = "Failed"
+		if page.Quest.Successful {
+			status = "Completed"
+		}
+	}
+	doc.Details = append(doc.Details, Detail{Label: "Status", Value: status})
+	doc.Details = append(doc.Details, entityDetails(nil, page.Quest.Tags)...)
+	doc.addDescription(page.Quest.Description)
+
+	tasks := Section{Heading: "Tasks"}
+	for _, task := range page.Tasks {
+		if !visible(task.HiddenBy, playerID) {
+			continue
+		}
+		check := "[ ]"
+		if task.Finished {
+			check = "[x]"
+		}
+		item := check + " " + task.Name
+		if task.Type == int(data.Decimal) {
+			item += fmt.Sprintf(" (%d/%d)", task.Current, task.Capacity)
+		}
+		if task.Description != "" {
+			item += ": " + data.StripMentions(task.Description)
+		}
+		tasks.Items = append(tasks.Items, item)
+	}
+	if len(tasks.Items) > 0 {
+		doc.Sections = append(doc.Sections, tasks)
+	}
+
+	rewards := Section{Heading: "Rewards"}
+	for _, reward := range page.Rewards {
+		item := reward.Name
+		if reward.Amount != 0 {
+			item += fmt.Sprintf(" x%d", reward.Amount)
+		}
+		if reward.Description != "" {
+			item += ": " + reward.Description
+		}
+		rewards.Items = append(rewards.Items, item)
+	}
+	if len(rewards.Items) > 0 {
+		doc.Sections = append(doc.Sections, rewards)
+	}
+	doc.addRecords(page.Records)
+
+	return doc
+}
+
+// SessionDocument is a handout of the records written during the session
+func SessionDocument(gameName string, session *data.Session, records []data.Record, calendar *data.GameCalendar) *Document {
+	doc := &Document{
+		Title:    fmt.Sprintf("Session %d", session.Number),
+		Subtitle: joinNotEmpty(" — ", session.Name, gameName),
+	}
+	if date := joinNotEmpty(" — ", calendar.Format(session.WorldStart), calendar.Format(session.WorldEnd)); date != "" {
+		doc.Details = append(doc.Details, Detail{Label: "In-world dates", Value: date})
+	}
+	if session.EndTime != nil {
+		doc.Details = append(doc.Details, Detail{Label: "Ended", Value: session.EndTime.Format("2006-01-02 15:04")})
+	}
+	doc.addRecords(records)
+
+	return doc
+}
+
+func (doc *Document) addDescription(description string) {
+	if description = data.StripMentions(description); strings.TrimSpace(description) != "" {
+		doc.Sections = append(doc.Sections, Section{Paragraphs: paragraphs(description)})
+	}
+}
+
+func (doc *Document) addRecords(records []data.Record) {
+	if len(records) == 0 {
+		return
+	}
+
+	sorted := slices.Clone(records)
+	slices.SortFunc(sorted, func(a, b data.Record) int { return a.Created.Compare(*b.Created) })
+
+	section := Section{Heading: "Records"}
+	for _, record := range sorted {
+		text := strings.TrimSpace(data.StripMentions(record.Text))
+		if record.Created != nil {
+			text = record.Created.Format("2006-01-02") + " — " + text
+		}
+		section.Paragraphs = append(section.Paragraphs, text)
+	}
+	doc.Sections = append(doc.Sections, section)
+}
+
+func entityDetails(fields map[string]any, tags []string) []Detail {
+	details := []Detail{}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		details = append(details, Detail{Label: key, Value: fmt.Sprint(fields[key])})
+	}
+
+	if len(tags) > 0 {
+		details = append(details, Detail{Label: "Tags", Value: "#" + strings.Join(tags, " #")})
+	}
+
+	return details
+}
+
+func joinNotEmpty(sep string, values ...string) string {
+	return strings.Join(slices.DeleteFunc(values, func(value string) bool { return value == "" }), sep)
+}
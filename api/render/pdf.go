@@ -0,0 +1,429 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"strings"
+	"unicode/utf16"
+)
+
+// The writer uses standard Helvetica fonts, so nothing has to be embedded.
+// Their encoding is WinAnsi with Cyrillic letters at the Windows-1251 codes,
+// other characters are printed as "?"
+
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	pageMargin   = 56.0
+	contentWidth = pageWidth - 2*pageMargin
+
+	imageMaxSize = 160.0
+	imageGap     = 14.0
+)
+
+const (
+	regularFont = "F1"
+	boldFont    = "F2"
+)
+
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // ' ' - '/'
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // '0' - '?'
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // '@' - 'O'
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // 'P' - '_'
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // '`' - 'o'
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // 'p' - '~'
+}
+
+// winAnsiRunes are the characters of WinAnsi codes 128-159 used in texts
+var winAnsiRunes = map[rune]byte{
+	'€': 128, '…': 133, '‘': 145, '’': 146, '“': 147, '”': 148, '•': 149, '–': 150, '—': 151, '™': 153,
+}
+
+func charWidth(code byte, font string) float64 {
+	var width int
+	switch {
+	case code >= 32 && code < 127:
+		width = helveticaWidths[code-32]
+	case code >= 192 && code < 224, code == 168:
+		width = 680
+	case code >= 224, code == 184:
+		width = 540
+	default:
+		width = 556
+	}
+
+	if font == boldFont {
+		return float64(width) * 1.07
+	}
+	return float64(width)
+}
+
+func textWidth(text []byte, font string, size float64) float64 {
+	width := 0.0
+	for _, code := range text {
+		width += charWidth(code, font)
+	}
+
+	return width * size / 1000
+}
+
+// encodePDFText converts UTF-8 text to the font encoding
+func encodePDFText(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			encoded = append(encoded, ' ')
+		case r >= 32 && r < 127:
+			encoded = append(encoded, byte(r))
+		case r >= 'А' && r <= 'я':
+			encoded = append(encoded, byte(192+r-'А'))
+		case r == 'Ё':
+			encoded = append(encoded, 168)
+		case r == 'ё':
+			encoded = append(encoded, 184)
+		case r == '№':
+			encoded = append(encoded, 'N', 'o')
+		case r >= 0xA0 && r < 0xC0 && r != 0xA8 && r != 0xB8:
+			encoded = append(encoded, byte(r))
+		default:
+			if code, ok := winAnsiRunes[r]; ok {
+				encoded = append(encoded, code)
+			} else {
+				encoded = append(encoded, '?')
+			}
+		}
+	}
+
+	return encoded
+}
+
+// wrapText splits text into lines, the first line may be narrower
+func wrapText(text []byte, font string, size, firstWidth, width float64) [][]byte {
+	lines := [][]byte{}
+	line := []byte{}
+	maxWidth := firstWidth
+
+	for _, word := range bytes.Fields(text) {
+		candidate := word
+		if len(line) > 0 {
+			candidate = append(append(append([]byte{}, line...), ' '), word...)
+		}
+		if textWidth(candidate, font, size) <= maxWidth {
+			line = candidate
+			continue
+		}
+
+		if len(line) > 0 {
+			lines = append(lines, line)
+			maxWidth = width
+		}
+		// Words longer than a line are cut by characters
+		line = []byte{}
+		for _, code := range word {
+			if len(line) > 0 && textWidth(append(line, code), font, size) > maxWidth {
+				lines = append(lines, line)
+				maxWidth = width
+				line = []byte{}
+			}
+			line = append(line, code)
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func escapePDFString(text []byte) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for _, code := range text {
+		if code == '(' || code == ')' || code == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(code)
+	}
+	sb.WriteByte(')')
+
+	return sb.String()
+}
+
+type pdfImage struct {
+	width      int
+	height     int
+	colorSpace string
+	decode     string
+	filter     string
+	data       []byte
+}
+
+// newPDFImage keeps JPEG as it is, other formats are converted to RGB
+func newPDFImage(i *Image) (*pdfImage, error) {
+	if i.MIME == "image/jpeg" {
+		config, err := jpeg.DecodeConfig(bytes.NewReader(i.Data))
+		if err != nil {
+			return nil, err
+		}
+
+		img := &pdfImage{width: config.Width, height: config.Height, colorSpace: "/DeviceRGB", filter: "/DCTDecode", data: i.Data}
+		switch config.ColorModel {
+		case color.GrayModel:
+			img.colorSpace = "/DeviceGray"
+		case color.CMYKModel:
+			img.colorSpace, img.decode = "/DeviceCMYK", "/Decode [1 0 1 0 1 0 1 0]"
+		}
+		return img, nil
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(i.Data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := decoded.Bounds()
+	rgb := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := decoded.At(x, y).RGBA()
+			// Transparent pixels are put on white paper
+			white := 0xffff - a
+			rgb = append(rgb, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(rgb); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return &pdfImage{width: bounds.Dx(), height: bounds.Dy(), colorSpace: "/DeviceRGB", filter: "/FlateDecode", data: compressed.Bytes()}, nil
+}
+
+type pdfLayout struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+
+	// the image takes the right side of the first page until reserveBottom
+	reserveWidth  float64
+	reserveBottom float64
+}
+
+func (l *pdfLayout) newPage() {
+	l.page = &bytes.Buffer{}
+	l.pages = append(l.pages, l.page)
+	l.y = pageHeight - pageMargin
+	l.reserveWidth = 0
+}
+
+func (l *pdfLayout) width() float64 {
+	if l.reserveWidth > 0 && l.y > l.reserveBottom {
+		return contentWidth - l.reserveWidth
+	}
+
+	return contentWidth
+}
+
+// ensure moves to the next page if the height does not fit
+func (l *pdfLayout) ensure(height float64) {
+	if l.y-height < pageMargin {
+		l.newPage()
+	}
+}
+
+// text writes wrapped lines, an optional bold label starts the first line
+func (l *pdfLayout) text(label, text string, font string, size, indent float64) {
+	leading := size * 1.35
+	encodedLabel := encodePDFText(label)
+	labelWidth := textWidth(encodedLabel, boldFont, size)
+
+	lines := wrapText(encodePDFText(text), font, size, l.width()-indent-labelWidth, l.width()-indent)
+	for i, line := range lines {
+		l.ensure(leading)
+		l.y -= leading
+
+		fmt.Fprintf(l.page, "BT %.2f %.2f Td ", pageMargin+indent, l.y)
+		if i == 0 && len(encodedLabel) > 0 {
+			fmt.Fprintf(l.page, "/%s %.1f Tf %s Tj ", boldFont, size, escapePDFString(encodedLabel))
+		}
+		fmt.Fprintf(l.page, "/%s %.1f Tf %s Tj ET\n", font, size, escapePDFString(line))
+	}
+}
+
+func (l *pdfLayout) space(height float64) {
+	l.y -= height
+}
+
+// PDF renders the document into a single PDF file
+func PDF(doc *Document) ([]byte, error) {
+	var img *pdfImage
+	if doc.Image != nil {
+		// Broken images are skipped rather than failing the whole document
+		img, _ = newPDFImage(doc.Image)
+	}
+
+	layout := &pdfLayout{}
+	layout.newPage()
+
+	if img != nil && img.width > 0 && img.height > 0 {
+		scale := min(imageMaxSize/float64(img.width), imageMaxSize/float64(img.height))
+		width, height := float64(img.width)*scale, float64(img.height)*scale
+		x, y := pageWidth-pageMargin-width, pageHeight-pageMargin-height
+		fmt.Fprintf(layout.page, "q %.2f 0 0 %.2f %.2f %.2f cm /Im1 Do Q\n", width, height, x, y)
+		layout.reserveWidth, layout.reserveBottom = width+imageGap, y
+	}
+
+	layout.text("", doc.Title, boldFont, 20, 0)
+	if doc.Subtitle != "" {
+		layout.space(2)
+		layout.text("", doc.Subtitle, regularFont, 13, 0)
+	}
+	if len(doc.Details) > 0 {
+		layout.space(8)
+		for _, detail := range doc.Details {
+			layout.text(detail.Label+": ", detail.Value, regularFont, 11, 0)
+		}
+	}
+
+	for _, section := range doc.Sections {
+		if section.Heading != "" {
+			layout.space(12)
+			layout.ensure(40)
+			layout.text("", section.Heading, boldFont, 14, 0)
+			layout.space(2)
+		}
+		for _, paragraph := range section.Paragraphs {
+			layout.space(5)
+			for _, line := range strings.Split(paragraph, "\n") {
+				layout.text("", line, regularFont, 11, 0)
+			}
+		}
+		if len(section.Items) > 0 {
+			layout.space(5)
+		}
+		for _, item := range section.Items {
+			layout.text("• ", item, regularFont, 11, 8)
+		}
+	}
+
+	return writePDF(doc.Title, layout.pages, img)
+}
+
+func writePDF(title string, pages []*bytes.Buffer, img *pdfImage) ([]byte, error) {
+	var buf bytes.Buffer
+	offsets := []int{}
+
+	// Fixed objects: 1 catalog, 2 pages, 3-4 fonts, 5 encoding, 6 info, 7 image
+	const (
+		catalogObj  = 1
+		pagesObj    = 2
+		regularObj  = 3
+		boldObj     = 4
+		encodingObj = 5
+		infoObj     = 6
+		imageObj    = 7
+	)
+	firstPageObj := imageObj + 1
+
+	object := func(body string, stream []byte) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\n", len(offsets), body)
+		if stream != nil {
+			buf.WriteString("stream\n")
+			buf.Write(stream)
+			buf.WriteString("\nendstream\n")
+		}
+		buf.WriteString("endobj\n")
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	object(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj), nil)
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObj+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)), nil)
+
+	object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding %d 0 R >>", encodingObj), nil)
+	object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding %d 0 R >>", encodingObj), nil)
+	object("<< /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences "+cyrillicDifferences()+" >>", nil)
+	object(fmt.Sprintf("<< /Title %s /Producer (personae-fasti) >>", pdfTextString(title)), nil)
+
+	resources := fmt.Sprintf("/Font << /%s %d 0 R /%s %d 0 R >>", regularFont, regularObj, boldFont, boldObj)
+	if img != nil {
+		object(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter %s %s /Length %d >>",
+			img.width, img.height, img.colorSpace, img.filter, img.decode, len(img.data)), img.data)
+		resources += fmt.Sprintf(" /XObject << /Im1 %d 0 R >>", imageObj)
+	} else {
+		// Keeps object numbers of the pages fixed
+		object("null", nil)
+	}
+
+	for i, page := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] /Resources << %s >> /Contents %d 0 R >>",
+			pagesObj, pageWidth, pageHeight, resources, firstPageObj+2*i+1), nil)
+
+		var content bytes.Buffer
+		zw := zlib.NewWriter(&content)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		object(fmt.Sprintf("<< /Filter /FlateDecode /Length %d >>", content.Len()), content.Bytes())
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, catalogObj, infoObj, xref)
+
+	return buf.Bytes(), nil
+}
+
+// cyrillicDifferences places Cyrillic glyphs at the Windows-1251 codes
+func cyrillicDifferences() string {
+	names := []string{"168 /afii10023", "184 /afii10071", "192"}
+	for glyph := 10017; glyph <= 10049; glyph++ {
+		if glyph != 10023 {
+			names = append(names, fmt.Sprintf("/afii%d", glyph))
+		}
+	}
+	for glyph := 10065; glyph <= 10097; glyph++ {
+		if glyph != 10071 {
+			names = append(names, fmt.Sprintf("/afii%d", glyph))
+		}
+	}
+
+	return "[" + strings.Join(names, " ") + "]"
+}
+
+// pdfTextString encodes document metadata as UTF-16 with BOM
+func pdfTextString(text string) string {
+	var sb strings.Builder
+	sb.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&sb, "%04X", unit)
+	}
+	sb.WriteString(">")
+
+	return sb.String()
+}
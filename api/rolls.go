@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/api/models/respData"
+	"personae-fasti/data"
+	"personae-fasti/dice"
+)
+
+const (
+	defaultRollsLimit = 50
+	maxRollsLimit     = 200
+)
+
+// POST /roll
+func (api *APIServer) handleRoll(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var rollCreate reqData.RollCreate
+	err := ReadJsonBody(r, &rollCreate)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	var char *data.Char
+	if rollCreate.CharID != 0 {
+		char, err = api.storage.GetCharByID(rollCreate.CharID)
+		if err != nil {
+			return api.HandleError(err)
+		} else if char == nil {
+			return api.HandleErrorString(fmt.Sprintf("no character with id %d", rollCreate.CharID)).WithCode(http.StatusNotFound)
+		} else if char.GameID != p.CurrentGameID {
+			return api.HandleErrorString(fmt.Sprintf("char %d is not allowed to request for the game %d", char.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+		} else if char.PlayerID != p.ID && p.CurrentGame.GMID != p.ID {
+			return api.HandleErrorString(fmt.Sprintf("char %d is not allowed to roll for the player %d", char.ID, p.ID)).WithCode(http.StatusForbidden)
+		}
+	}
+
+	result, err := dice.Roll(rollCreate.Expression)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	roll, err := api.storage.CreateRoll(&rollCreate, result, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+	roll.Char = char
+
+	return api.Respond(r, w, http.StatusCreated, respData.RollToRollInfo(roll, p.ID))
+}
+
+// GET /rolls
+func (api *APIServer) handleGetRolls(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	sessionNumber := 0
+	if session := r.URL.Query().Get("session"); session != "" {
+		number, err := strconv.Atoi(session)
+		if err != nil || number < 1 {
+			return api.HandleErrorString(fmt.Sprintf("session number %q is invalid", session)).WithCode(http.StatusBadRequest)
+		}
+		sessionNumber = number
+	}
+
+	limit := defaultRollsLimit
+	if limitValue := r.URL.Query().Get("limit"); limitValue != "" {
+		number, err := strconv.Atoi(limitValue)
+		if err != nil || number < 1 {
+			return api.HandleErrorString(fmt.Sprintf("limit %q is invalid", limitValue)).WithCode(http.StatusBadRequest)
+		}
+		limit = min(number, maxRollsLimit)
+	}
+
+	rolls, err := api.storage.GetGameRolls(p.CurrentGame, p, sessionNumber, limit)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, respData.RollToRollInfoArray(rolls, p.ID))
+}
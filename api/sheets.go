@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/api/models/respData"
+	"personae-fasti/data"
+)
+
+// charSheet returns nil if the char has no sheet
+func (api *APIServer) charSheet(charID int) (*respData.CharSheetInfo, *APIError) {
+	sheet, err := api.storage.GetCharSheet(charID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	} else if sheet == nil {
+		return nil, nil
+	}
+
+	levelUps, err := api.storage.GetCharLevelUps(charID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	}
+
+	return respData.CharSheetToCharSheetInfo(sheet, levelUps), nil
+}
+
+// GET /sheet/templates
+func (api *APIServer) handleGetSheetTemplates(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	template, err := api.storage.GameSheetTemplate(p.CurrentGame)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, respData.SheetTemplates{
+		Templates: data.GetSheetTemplates(),
+		Current:   template.Key,
+	})
+}
+
+// PUT /char/{id}/sheet
+func (api *APIServer) handlePutCharSheet(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var sheetUpdate reqData.CharSheetUpdate
+	err := ReadJsonBody(r, &sheetUpdate)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+	if apiErr := api.readIfMatch(r, &sheetUpdate.Version); apiErr != nil {
+		return apiErr
+	}
+
+	char, apiErr := api.getChar(r, p)
+	if apiErr != nil {
+		return apiErr
+	} else if char.PlayerID != p.ID && !p.IsGM() {
+		return api.HandleErrorString("only the owner of the char or GM may change the sheet").WithCode(http.StatusForbidden)
+	}
+
+	template, err := api.storage.GameSheetTemplate(p.CurrentGame)
+	if err != nil {
+		return api.HandleError(err)
+	}
+	if err := template.Validate(&sheetUpdate); err != nil {
+		return api.HandleError(err).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	_, err = api.storage.SaveCharSheet(char, &sheetUpdate, template, p)
+
+	return api.respondCharChange(w, r, p, char.ID, err)
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"personae-fasti/api/models/respData"
+	"personae-fasti/data"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Login widget data older than this is rejected
+const telegramAuthMaxAge = 24 * time.Hour
+
+// POST /login/telegram
+func (api *APIServer) handleTelegramLogin(w http.ResponseWriter, r *http.Request) *APIError {
+	if api.telegram.Token == "" {
+		return api.HandleErrorString("telegram login is not configured").WithCode(http.StatusNotImplemented)
+	}
+
+	// Numbers are kept as they were sent, the hash is calculated over the text
+	var body map[string]any
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil {
+		return api.HandleError(fmt.Errorf("error parsing telegram login data: %v", err)).WithCode(http.StatusBadRequest)
+	}
+
+	fields := make(map[string]string, len(body))
+	for key, value := range body {
+		if value != nil {
+			fields[key] = fmt.Sprint(value)
+		}
+	}
+
+	telegramID, err := verifyTelegramLogin(fields, api.telegram.Token, time.Now())
+	if err != nil {
+		return api.HandleError(fmt.Errorf("login failed: %v", err)).WithCode(http.StatusUnauthorized)
+	}
+
+	player, err := api.storage.GetPlayerByTelegramID(telegramID)
+	if err != nil {
+		return api.HandleError(err)
+	} else if player == nil {
+		return api.HandleErrorString(fmt.Sprintf("login failed: telegram account %d is not linked to a player", telegramID)).WithCode(http.StatusUnauthorized)
+	}
+
+	// The widget has no language, only the username is refreshed
+	if player.Telegram != nil && player.Telegram.Username != fields["username"] {
+		player.Telegram.Username = fields["username"]
+		if err := api.storage.UpdateTelegram(player.Telegram); err != nil {
+			log.Printf("failed to update telegram account %d: %v", telegramID, err)
+		}
+	}
+
+	return api.Respond(r, w, http.StatusOK, respData.FormLoginInfo(player))
+}
+
+// verifyTelegramLogin checks the login widget hash as described at
+// https://core.telegram.org/widgets/login#checking-authorization
+func verifyTelegramLogin(fields map[string]string, token string, now time.Time) (int64, error) {
+	hash, ok := fields["hash"]
+	if !ok {
+		return 0, errors.New("no telegram hash")
+	}
+
+	lines := make([]string, 0, len(fields))
+	for key, value := range fields {
+		if key != "hash" {
+			lines = append(lines, key+"="+value)
+		}
+	}
+	slices.Sort(lines)
+
+	secret := sha256.Sum256([]byte(token))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(lines, "\n")))
+
+	expected, err := hex.DecodeString(hash)
+	if err != nil || !hmac.Equal(mac.Sum(nil), expected) {
+		return 0, errors.New("telegram hash is invalid")
+	}
+
+	authDate, err := strconv.ParseInt(fields["auth_date"], 10, 64)
+	if err != nil {
+		return 0, errors.New("telegram auth date is invalid")
+	} else if now.Sub(time.Unix(authDate, 0)) > telegramAuthMaxAge {
+		return 0, errors.New("telegram login data is expired")
+	}
+
+	telegramID, err := strconv.ParseInt(fields["id"], 10, 64)
+	if err != nil {
+		return 0, errors.New("telegram id is invalid")
+	}
+
+	return telegramID, nil
+}
+
+// POST /telegram/link
+func (api *APIServer) handleCreateTelegramLink(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	link, err := api.storage.CreateTelegramLink(p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusCreated, &respData.TelegramLink{
+		Code:    link.Code,
+		Expires: link.Expires,
+	})
+}
+
+// DELETE /telegram/link
+func (api *APIServer) handleDeleteTelegramLink(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	if err := api.storage.UnlinkTelegram(p); err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, nil)
+}
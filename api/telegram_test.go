@@ -0,0 +1,76 @@
+package api
+
+import (
+	"maps"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTelegramToken = "123456:test-bot-token"
+
+// The hash is calculated by the algorithm of the Telegram docs outside Go
+func testTelegramFields() map[string]string {
+	return map[string]string{
+		"id":         "42",
+		"first_name": "Alice",
+		"username":   "alice_tg",
+		"auth_date":  "1760000000",
+		"hash":       "2d5bca1a28497d20ff9169d29f4999e63354c571712a0858e15ab96abfa8f6d9",
+	}
+}
+
+func TestVerifyTelegramLogin(t *testing.T) {
+	authDate := time.Unix(1760000000, 0)
+
+	tests := []struct {
+		name   string
+		change map[string]string
+		token  string
+		now    time.Time
+		err    string
+	}{
+		{"valid", nil, testTelegramToken, authDate.Add(time.Hour), ""},
+		{"valid at the max age", nil, testTelegramToken, authDate.Add(telegramAuthMaxAge), ""},
+		{"tampered id", map[string]string{"id": "43"}, testTelegramToken, authDate, "hash is invalid"},
+		{"tampered username", map[string]string{"username": "mallory"}, testTelegramToken, authDate, "hash is invalid"},
+		{"added field", map[string]string{"last_name": "Smith"}, testTelegramToken, authDate, "hash is invalid"},
+		{"tampered auth date", map[string]string{"auth_date": "1760090000"}, testTelegramToken, authDate, "hash is invalid"},
+		{"other bot token", nil, "654321:other-token", authDate, "hash is invalid"},
+		{"hash is not hex", map[string]string{"hash": "not-a-hash"}, testTelegramToken, authDate, "hash is invalid"},
+		{"expired", nil, testTelegramToken, authDate.Add(telegramAuthMaxAge + time.Second), "expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := testTelegramFields()
+			maps.Copy(fields, tt.change)
+
+			telegramID, err := verifyTelegramLogin(fields, tt.token, tt.now)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("login failed: %v", err)
+				} else if telegramID != 42 {
+					t.Errorf("telegram id is %d, want 42", telegramID)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("login succeeded with the telegram id %d", telegramID)
+			} else if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error is %q, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVerifyTelegramLoginWithoutHash(t *testing.T) {
+	fields := testTelegramFields()
+	delete(fields, "hash")
+
+	_, err := verifyTelegramLogin(fields, testTelegramToken, time.Unix(1760000000, 0))
+	if err == nil || !strings.Contains(err.Error(), "no telegram hash") {
+		t.Errorf("error is %v, want no telegram hash", err)
+	}
+}
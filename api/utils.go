@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 )
 
 func ReadBody(r *http.Request) []byte {
@@ -33,3 +35,21 @@ func getPathValueInt(r *http.Request, param string) int {
 
 	return wrongValue
 }
+
+// getFieldFilters collects custom field filters passed as ?field.<key>=<value>
+func getFieldFilters(r *http.Request) map[string]string {
+	filters := map[string]string{}
+	for key, values := range r.URL.Query() {
+		if fieldKey, ok := strings.CutPrefix(key, "field."); ok && len(values) > 0 {
+			filters[fieldKey] = values[0]
+		}
+	}
+
+	return filters
+}
+
+func filterByIDs[T any](entities []T, ids []int, getID func(T) int) []T {
+	return slices.DeleteFunc(entities, func(entity T) bool {
+		return !slices.Contains(ids, getID(entity))
+	})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"personae-fasti/api/models/respData"
+	"personae-fasti/data"
+)
+
+// readIfMatch replaces the version of the update with the If-Match header
+// if it is passed. The header holds the ETag of the GET response. An
+// update with no version is blind and is refused with 428 if the config
+// requires versions, If-Match: * asks for the blind write explicitly
+func (api *APIServer) readIfMatch(r *http.Request, version *int) *APIError {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "*" {
+		return nil
+	} else if ifMatch == "" {
+		if *version == 0 && api.requireVersions {
+			return api.HandleErrorString("update must be based on a version: pass If-Match or version").WithCode(http.StatusPreconditionRequired)
+		}
+		return nil
+	}
+
+	value, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`))
+	if err != nil || value <= 0 {
+		return api.HandleErrorString(fmt.Sprintf("If-Match %s is not a version", ifMatch)).WithCode(http.StatusBadRequest)
+	}
+	*version = value
+
+	return nil
+}
+
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+func (api *APIServer) respondVersionConflict(w http.ResponseWriter, r *http.Request, version int, current any) *APIError {
+	setETag(w, version)
+	return api.Respond(r, w, http.StatusConflict, &respData.VersionConflict{
+		Error:   data.ErrVersionConflict.Error(),
+		Current: current,
+	})
+}
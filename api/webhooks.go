@@ -0,0 +1,141 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/data"
+)
+
+const webhookDeliveriesLimit = 50
+
+// GET /game/webhooks
+func (api *APIServer) handleGetWebhooks(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	if p.CurrentGame.GMID != p.ID {
+		return api.HandleErrorString("only GM may manage webhooks").WithCode(http.StatusForbidden)
+	}
+
+	webhooks, err := api.storage.GetGameWebhooks(p.CurrentGameID)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, webhooks)
+}
+
+// POST /game/webhook
+func (api *APIServer) handleCreateWebhook(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	if p.CurrentGame.GMID != p.ID {
+		return api.HandleErrorString("only GM may manage webhooks").WithCode(http.StatusForbidden)
+	}
+
+	var webhookCreate reqData.WebhookCreate
+	err := ReadJsonBody(r, &webhookCreate)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	webhook, err := api.storage.CreateWebhook(&webhookCreate, p)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	return api.Respond(r, w, http.StatusCreated, webhook)
+}
+
+// PUT /game/webhook
+func (api *APIServer) handleUpdateWebhook(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	var webhookUpdate reqData.WebhookUpdate
+	err := ReadJsonBody(r, &webhookUpdate)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	webhook, apiErr := api.getWebhook(webhookUpdate.ID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	webhook, err = api.storage.UpdateWebhook(&webhookUpdate, webhook)
+	if err != nil {
+		return api.HandleError(err).WithCode(http.StatusBadRequest)
+	}
+
+	return api.Respond(r, w, http.StatusOK, webhook)
+}
+
+// DELETE /game/webhook/{id}
+func (api *APIServer) handleDeleteWebhook(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	webhookID := getPathValueInt(r, "id")
+	if webhookID < 0 {
+		return api.HandleErrorString("error parsing id: webhook id is invalid").WithCode(http.StatusBadRequest)
+	}
+
+	webhook, apiErr := api.getWebhook(webhookID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if err := api.storage.DeleteWebhook(webhook); err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, nil)
+}
+
+// GET /game/webhook/{id}/deliveries
+func (api *APIServer) handleGetWebhookDeliveries(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	webhookID := getPathValueInt(r, "id")
+	if webhookID < 0 {
+		return api.HandleErrorString("error parsing id: webhook id is invalid").WithCode(http.StatusBadRequest)
+	}
+
+	webhook, apiErr := api.getWebhook(webhookID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	deliveries, err := api.storage.GetWebhookDeliveries(webhook, webhookDeliveriesLimit)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, deliveries)
+}
+
+// POST /game/webhook/{id}/test
+func (api *APIServer) handleTestWebhook(w http.ResponseWriter, r *http.Request, p *data.Player) *APIError {
+	webhookID := getPathValueInt(r, "id")
+	if webhookID < 0 {
+		return api.HandleErrorString("error parsing id: webhook id is invalid").WithCode(http.StatusBadRequest)
+	}
+
+	webhook, apiErr := api.getWebhook(webhookID, p)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	delivery, err := api.webhooks.Test(webhook, p)
+	if err != nil {
+		return api.HandleError(err)
+	}
+
+	return api.Respond(r, w, http.StatusOK, delivery)
+}
+
+func (api *APIServer) getWebhook(webhookID int, p *data.Player) (*data.Webhook, *APIError) {
+	if p.CurrentGame.GMID != p.ID {
+		return nil, api.HandleErrorString("only GM may manage webhooks").WithCode(http.StatusForbidden)
+	}
+
+	webhook, err := api.storage.GetWebhookByID(webhookID)
+	if err != nil {
+		return nil, api.HandleError(err)
+	} else if webhook == nil {
+		return nil, api.HandleErrorString(fmt.Sprintf("no webhook with id %d", webhookID)).WithCode(http.StatusNotFound)
+	} else if webhook.GameID != p.CurrentGameID {
+		return nil, api.HandleErrorString(fmt.Sprintf("webhook %d is not allowed to request for the game %d", webhook.ID, p.CurrentGameID)).WithCode(http.StatusUnprocessableEntity)
+	}
+
+	return webhook, nil
+}
@@ -0,0 +1,563 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ArchiveVersion is bumped on every incompatible change of GameArchive.
+// The format is described in docs/export.md
+const ArchiveVersion = 1
+
+// GameArchive is a portable copy of a game. IDs are local to the archive,
+// players are referenced by username
+type GameArchive struct {
+	Version  int         `json:"version"`
+	Exported time.Time   `json:"exported"`
+	Game     ArchiveGame `json:"game"`
+
+	Players   []ArchivePlayer    `json:"players"`
+	Sessions  []ArchiveSession   `json:"sessions"`
+	Chars     []ArchiveEntity    `json:"chars"`
+	NPCs      []ArchiveEntity    `json:"npcs"`
+	Locations []ArchiveEntity    `json:"locations"`
+	Items     []ArchiveItem      `json:"items"`
+	Factions  []ArchiveFaction   `json:"factions"`
+	Quests    []ArchiveQuest     `json:"quests"`
+	Records   []ArchiveRecord    `json:"records"`
+	Mentions  []ArchiveMention   `json:"mentions"`
+	Events    []ArchiveEvent     `json:"events"`
+	Fields    []ArchiveField     `json:"fields"`
+	Values    []ArchiveValue     `json:"values"`
+	Tags      []ArchiveEntityTag `json:"tags"`
+
+	// Images are file server contents by "<type>_<id>" key
+	Images map[string]string `json:"images"`
+}
+
+type ArchiveGame struct {
+	ID       int             `json:"id"`
+	Name     string          `json:"name"`
+	GM       string          `json:"gm"`
+	Settings ArchiveSettings `json:"settings"`
+	Calendar *GameCalendar   `json:"calendar"`
+	Created  *time.Time      `json:"created"`
+}
+
+type ArchiveSettings struct {
+	AllowAllEditRecords bool   `json:"allowAllEditRecords"`
+	SheetTemplate       string `json:"sheetTemplate"`
+}
+
+type ArchivePlayer struct {
+	Username string `json:"username"`
+}
+
+type ArchiveSession struct {
+	ID         int        `json:"id"`
+	Number     int        `json:"number"`
+	Name       string     `json:"name"`
+	StartTime  *time.Time `json:"startTime,omitempty"`
+	EndTime    *time.Time `json:"endTime"`
+	WorldStart *WorldDate `json:"worldStart"`
+	WorldEnd   *WorldDate `json:"worldEnd"`
+}
+
+// ArchiveEntity is a char, an NPC or a location
+type ArchiveEntity struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Player      string     `json:"player,omitempty"`
+	CreatedBy   string     `json:"createdBy,omitempty"`
+	ParentID    int        `json:"parentID,omitempty"`
+	HiddenBy    string     `json:"hiddenBy,omitempty"`
+	Status      CharStatus `json:"status,omitempty"`
+	LeftTime    *time.Time `json:"leftTime,omitempty"`
+	LeftSession int        `json:"leftSession,omitempty"`
+	Created     *time.Time `json:"created"`
+}
+
+type ArchiveItem struct {
+	ArchiveEntity
+	Quantity  int           `json:"quantity"`
+	OwnerType ItemOwnerType `json:"ownerType"`
+	OwnerID   int           `json:"ownerID"`
+}
+
+type ArchiveFaction struct {
+	ArchiveEntity
+	Members []ArchiveFactionMember `json:"members"`
+}
+
+type ArchiveFactionMember struct {
+	CharID int    `json:"charID,omitempty"`
+	NPCID  int    `json:"npcID,omitempty"`
+	Rank   string `json:"rank"`
+}
+
+type ArchiveQuest struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+
+	ParentID int `json:"parentID,omitempty"`
+	ChildID  int `json:"childID,omitempty"`
+	HeadID   int `json:"headID,omitempty"`
+
+	Successful bool       `json:"successful"`
+	HiddenBy   string     `json:"hiddenBy,omitempty"`
+	Created    *time.Time `json:"created"`
+	Finished   *time.Time `json:"finished"`
+
+	Tasks   []ArchiveTask   `json:"tasks"`
+	Rewards []ArchiveReward `json:"rewards"`
+}
+
+type ArchiveTask struct {
+	ID          int           `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Type        QuestTaskType `json:"type"`
+	Capacity    int           `json:"capacity"`
+	Current     int           `json:"current"`
+	HiddenBy    string        `json:"hiddenBy,omitempty"`
+	Finished    *time.Time    `json:"finished"`
+}
+
+type ArchiveReward struct {
+	Type                QuestRewardType `json:"type"`
+	Name                string          `json:"name"`
+	Description         string          `json:"description"`
+	Amount              int             `json:"amount"`
+	CharIDs             []int           `json:"charIDs"`
+	HiddenUntilFinished bool            `json:"hiddenUntilFinished"`
+	HiddenBy            string          `json:"hiddenBy,omitempty"`
+}
+
+type ArchiveRecord struct {
+	ID         int        `json:"id"`
+	Text       string     `json:"text"`
+	Player     string     `json:"player"`
+	QuestID    int        `json:"questID,omitempty"`
+	HiddenBy   string     `json:"hiddenBy,omitempty"`
+	WorldStart *WorldDate `json:"worldStart"`
+	WorldEnd   *WorldDate `json:"worldEnd"`
+	Created    *time.Time `json:"created"`
+	Updated    *time.Time `json:"updated"`
+}
+
+// ArchiveMention is a row of a records_* join table
+type ArchiveMention struct {
+	RecordID int    `json:"recordID"`
+	Type     string `json:"type"`
+	ID       int    `json:"id"`
+}
+
+type ArchiveEvent struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	WorldStart  *WorldDate `json:"worldStart"`
+	WorldEnd    *WorldDate `json:"worldEnd"`
+	CreatedBy   string     `json:"createdBy,omitempty"`
+	HiddenBy    string     `json:"hiddenBy,omitempty"`
+}
+
+type ArchiveField struct {
+	ID         int             `json:"id"`
+	EntityType string          `json:"entityType"`
+	Key        string          `json:"key"`
+	Name       string          `json:"name"`
+	Type       CustomFieldType `json:"type"`
+	Options    []string        `json:"options"`
+	RefType    string          `json:"refType,omitempty"`
+	Required   bool            `json:"required"`
+	Order      int             `json:"order"`
+}
+
+type ArchiveValue struct {
+	FieldID  int    `json:"fieldID"`
+	EntityID int    `json:"entityID"`
+	Value    string `json:"value"`
+}
+
+type ArchiveEntityTag struct {
+	Tag        string `json:"tag"`
+	EntityType string `json:"entityType"`
+	EntityID   int    `json:"entityID"`
+}
+
+// ExportGame collects every living row of the game, images are left to the caller
+func (s *Storage) ExportGame(gameID int) (*GameArchive, error) {
+	ctx := context.Background()
+
+	game := Game{ID: gameID}
+	err := s.db.NewSelect().Model(&game).WherePK().
+		Relation("GM").
+		Relation("Settings").
+		Relation("Players").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load game: %w", err)
+	}
+
+	usernames := map[int]string{}
+	if game.GM != nil {
+		usernames[game.GM.ID] = game.GM.Username
+	}
+
+	archive := GameArchive{
+		Version:  ArchiveVersion,
+		Exported: time.Now().UTC(),
+		Players:  []ArchivePlayer{},
+		Images:   map[string]string{},
+	}
+
+	for _, player := range game.Players {
+		usernames[player.ID] = player.Username
+		archive.Players = append(archive.Players, ArchivePlayer{Username: player.Username})
+	}
+	username := func(playerID int) string { return usernames[playerID] }
+
+	calendar, err := s.GetGameCalendar(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	archive.Game = ArchiveGame{
+		ID:       game.ID,
+		Name:     game.Name,
+		GM:       username(game.GMID),
+		Calendar: calendar,
+		Created:  game.Created,
+	}
+	if game.Settings != nil {
+		archive.Game.Settings = ArchiveSettings{
+			AllowAllEditRecords: game.Settings.AllowAllEditRecords,
+			SheetTemplate:       game.Settings.SheetTemplate,
+		}
+	}
+
+	var sessions []Session
+	if err := selectGameRows(ctx, s.db, &sessions, gameID, false, "number ASC"); err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		archive.Sessions = append(archive.Sessions, ArchiveSession{
+			ID:         session.ID,
+			Number:     session.Number,
+			Name:       session.Name,
+			StartTime:  session.StartTime,
+			EndTime:    session.EndTime,
+			WorldStart: session.WorldStart,
+			WorldEnd:   session.WorldEnd,
+		})
+	}
+
+	var chars []Char
+	if err := selectGameRows(ctx, s.db, &chars, gameID, true, "id ASC"); err != nil {
+		return nil, err
+	}
+	for _, char := range chars {
+		archive.Chars = append(archive.Chars, ArchiveEntity{
+			ID:          char.ID,
+			Name:        char.Name,
+			Title:       char.Title,
+			Description: char.Description,
+			Player:      username(char.PlayerID),
+			HiddenBy:    username(char.HiddenBy),
+			Status:      char.Status,
+			LeftTime:    char.LeftTime,
+			LeftSession: char.LeftSession,
+			Created:     char.Created,
+		})
+	}
+
+	var npcs []NPC
+	if err := selectGameRows(ctx, s.db, &npcs, gameID, true, "id ASC"); err != nil {
+		return nil, err
+	}
+	for _, npc := range npcs {
+		archive.NPCs = append(archive.NPCs, ArchiveEntity{
+			ID:          npc.ID,
+			Name:        npc.Name,
+			Title:       npc.Title,
+			Description: npc.Description,
+			CreatedBy:   username(npc.CreatedByID),
+			HiddenBy:    username(npc.HiddenBy),
+			Created:     npc.Created,
+		})
+	}
+
+	var locations []Location
+	if err := selectGameRows(ctx, s.db, &locations, gameID, true, "id ASC"); err != nil {
+		return nil, err
+	}
+	for _, location := range locations {
+		archive.Locations = append(archive.Locations, ArchiveEntity{
+			ID:          location.ID,
+			Name:        location.Name,
+			Title:       location.Title,
+			Description: location.Description,
+			CreatedBy:   username(location.CreatedByID),
+			ParentID:    location.ParentID,
+			HiddenBy:    username(location.HiddenBy),
+			Created:     location.Created,
+		})
+	}
+
+	var items []Item
+	if err := selectGameRows(ctx, s.db, &items, gameID, true, "id ASC"); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		archive.Items = append(archive.Items, ArchiveItem{
+			ArchiveEntity: ArchiveEntity{
+				ID:          item.ID,
+				Name:        item.Name,
+				Title:       item.Title,
+				Description: item.Description,
+				CreatedBy:   username(item.CreatedByID),
+				HiddenBy:    username(item.HiddenBy),
+				Created:     item.Created,
+			},
+			Quantity:  item.Quantity,
+			OwnerType: item.OwnerType,
+			OwnerID:   item.OwnerID,
+		})
+	}
+
+	var factions []Faction
+	err = s.db.NewSelect().Model(&factions).Relation("Members").
+		Where("faction.game_id = ? AND faction.deleted IS NULL", gameID).
+		Order("faction.id ASC").Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to load factions: %w", err)
+	}
+	for _, faction := range factions {
+		archiveFaction := ArchiveFaction{
+			ArchiveEntity: ArchiveEntity{
+				ID:          faction.ID,
+				Name:        faction.Name,
+				Title:       faction.Title,
+				Description: faction.Description,
+				CreatedBy:   username(faction.CreatedByID),
+				HiddenBy:    username(faction.HiddenBy),
+				Created:     faction.Created,
+			},
+			Members: []ArchiveFactionMember{},
+		}
+		for _, member := range faction.Members {
+			archiveFaction.Members = append(archiveFaction.Members, ArchiveFactionMember{
+				CharID: member.CharID,
+				NPCID:  member.NPCID,
+				Rank:   member.Rank,
+			})
+		}
+		archive.Factions = append(archive.Factions, archiveFaction)
+	}
+
+	var quests []Quest
+	err = s.db.NewSelect().Model(&quests).
+		Relation("Tasks").
+		Relation("Rewards.Chars").
+		Where("quest.game_id = ? AND quest.deleted IS NULL", gameID).
+		Order("quest.id ASC").Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to load quests: %w", err)
+	}
+	for _, quest := range quests {
+		archiveQuest := ArchiveQuest{
+			ID:          quest.ID,
+			Name:        quest.Name,
+			Title:       quest.Title,
+			Description: quest.Description,
+			ParentID:    quest.ParentID,
+			ChildID:     quest.ChildID,
+			HeadID:      quest.HeadID,
+			Successful:  quest.Successful,
+			HiddenBy:    username(quest.HiddenBy),
+			Created:     quest.Created,
+			Finished:    quest.Finished,
+			Tasks:       []ArchiveTask{},
+			Rewards:     []ArchiveReward{},
+		}
+		for _, task := range quest.Tasks {
+			archiveQuest.Tasks = append(archiveQuest.Tasks, ArchiveTask{
+				ID:          task.ID,
+				Name:        task.Name,
+				Description: task.Description,
+				Type:        task.Type,
+				Capacity:    task.Capacity,
+				Current:     task.Current,
+				HiddenBy:    username(task.HiddenBy),
+				Finished:    task.Finished,
+			})
+		}
+		for _, reward := range quest.Rewards {
+			charIDs := []int{}
+			for _, char := range reward.Chars {
+				charIDs = append(charIDs, char.ID)
+			}
+			archiveQuest.Rewards = append(archiveQuest.Rewards, ArchiveReward{
+				Type:                reward.Type,
+				Name:                reward.Name,
+				Description:         reward.Description,
+				Amount:              reward.Amount,
+				CharIDs:             charIDs,
+				HiddenUntilFinished: reward.HiddenUntilFinished,
+				HiddenBy:            username(reward.HiddenBy),
+			})
+		}
+		archive.Quests = append(archive.Quests, archiveQuest)
+	}
+
+	var records []Record
+	if err := selectGameRows(ctx, s.db, &records, gameID, true, "id ASC"); err != nil {
+		return nil, err
+	}
+	recordIDs := []int{}
+	for _, record := range records {
+		recordIDs = append(recordIDs, record.ID)
+		archive.Records = append(archive.Records, ArchiveRecord{
+			ID:         record.ID,
+			Text:       record.Text,
+			Player:     username(record.PlayerID),
+			QuestID:    record.QuestID,
+			HiddenBy:   username(record.HiddenBy),
+			WorldStart: record.WorldStart,
+			WorldEnd:   record.WorldEnd,
+			Created:    record.Created,
+			Updated:    record.Updated,
+		})
+	}
+
+	archive.Mentions, err = s.exportMentions(ctx, recordIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []TimelineEvent
+	if err := selectGameRows(ctx, s.db, &events, gameID, true, "id ASC"); err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		archive.Events = append(archive.Events, ArchiveEvent{
+			ID:          event.ID,
+			Name:        event.Name,
+			Description: event.Description,
+			WorldStart:  event.WorldStart,
+			WorldEnd:    event.WorldEnd,
+			CreatedBy:   username(event.CreatedByID),
+			HiddenBy:    username(event.HiddenBy),
+		})
+	}
+
+	fields, err := s.GetGameCustomFields(gameID, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		archive.Fields = append(archive.Fields, ArchiveField{
+			ID:         field.ID,
+			EntityType: field.EntityType,
+			Key:        field.Key,
+			Name:       field.Name,
+			Type:       field.Type,
+			Options:    field.Options,
+			RefType:    field.RefType,
+			Required:   field.Required,
+			Order:      field.Order,
+		})
+	}
+
+	var values []CustomFieldValue
+	err = s.db.NewSelect().Model(&values).
+		Join("JOIN custom_field AS field ON field.id = custom_field_value.field_id").
+		Where("custom_field_value.game_id = ? AND field.deleted IS NULL", gameID).
+		Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to load field values: %w", err)
+	}
+	for _, value := range values {
+		archive.Values = append(archive.Values, ArchiveValue{
+			FieldID:  value.FieldID,
+			EntityID: value.EntityID,
+			Value:    value.Value,
+		})
+	}
+
+	var entityTags []EntityTag
+	err = s.db.NewSelect().Model(&entityTags).Relation("Tag").Where("entity_tag.game_id = ?", gameID).Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to load tags: %w", err)
+	}
+	for _, entityTag := range entityTags {
+		archive.Tags = append(archive.Tags, ArchiveEntityTag{
+			Tag:        entityTag.Tag.Name,
+			EntityType: entityTag.EntityType,
+			EntityID:   entityTag.EntityID,
+		})
+	}
+
+	return &archive, nil
+}
+
+// selectGameRows loads rows of a game table, optionally skipping soft deleted ones
+func selectGameRows(ctx context.Context, db bun.IDB, rows any, gameID int, skipDeleted bool, order string) error {
+	q := db.NewSelect().Model(rows).Where("game_id = ?", gameID).Order(order)
+	if skipDeleted {
+		q = q.Where("deleted IS NULL")
+	}
+
+	err := q.Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to export game rows: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) exportMentions(ctx context.Context, recordIDs []int) ([]ArchiveMention, error) {
+	mentions := []ArchiveMention{}
+	if len(recordIDs) == 0 {
+		return mentions, nil
+	}
+
+	joinTables := []struct {
+		mentionType string
+		table       string
+		column      string
+	}{
+		{CharEntity, "records_chars", "char_id"},
+		{NPCEntity, "records_npcs", "npc_id"},
+		{LocationEntity, "records_locations", "location_id"},
+		{ItemEntity, "records_items", "item_id"},
+		{FactionEntity, "records_factions", "faction_id"},
+	}
+
+	for _, joinTable := range joinTables {
+		var rows []ArchiveMention
+		err := s.db.NewSelect().
+			TableExpr(joinTable.table).
+			ColumnExpr("record_id AS record_id").
+			ColumnExpr("? AS type", joinTable.mentionType).
+			ColumnExpr("? AS id", bun.Ident(joinTable.column)).
+			Where("record_id IN (?)", bun.In(recordIDs)).
+			OrderExpr("record_id ASC").
+			Scan(ctx, &rows)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to export %s mentions: %w", joinTable.mentionType, err)
+		}
+		mentions = append(mentions, rows...)
+	}
+
+	return mentions, nil
+}
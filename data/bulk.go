@@ -0,0 +1,627 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"personae-fasti/api/models/reqData"
+	gu "personae-fasti/gewi-utils"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+var (
+	ErrBulkImportFile = errors.New("cannot import the file")
+	ErrBulkImportRows = errors.New("some rows cannot be imported")
+)
+
+const maxBulkRows = 1000
+
+const (
+	CSVFormat      = "csv"
+	MarkdownFormat = "md"
+)
+
+var bulkColumns = map[string][]string{
+	NPCEntity:      {"name", "title", "description", "hidden", "tags"},
+	LocationEntity: {"name", "title", "description", "hidden", "tags", "parent"},
+	RecordEntity:   {"text", "hidden", "tags", "quest"},
+}
+
+type BulkRow struct {
+	Row    int      `json:"row"`
+	Name   string   `json:"name,omitempty"`
+	ID     int      `json:"id,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// BulkReport lists every parsed row, nothing is created if any row has errors
+type BulkReport struct {
+	EntityType string    `json:"entityType"`
+	Created    int       `json:"created"`
+	Failed     int       `json:"failed"`
+	Rows       []BulkRow `json:"rows"`
+}
+
+// bulkEntry is a parsed row of CSV or an entry of Markdown outline
+type bulkEntry struct {
+	row         int
+	name        string
+	title       string
+	description string
+	text        string
+	hidden      *bool
+	parent      string
+	parentEntry *bulkEntry
+	tags        []string
+	fields      map[string]string
+	quest       string
+
+	// resolved on validation
+	hiddenBy    int
+	parentID    int
+	questID     int
+	fieldValues map[int]*string
+	errors      []string
+	id          int
+	// inserted entity to publish after the commit
+	npc      *NPC
+	location *Location
+	record   *Record
+}
+
+func (e *bulkEntry) fail(format string, args ...any) {
+	e.errors = append(e.errors, fmt.Sprintf(format, args...))
+}
+
+// set applies a column or a Markdown metadata value to the entry
+func (e *bulkEntry) set(entityType, key, value string) bool {
+	if fieldKey, ok := strings.CutPrefix(key, "field."); ok && entityType != RecordEntity {
+		if value != "" {
+			e.fields[fieldKey] = value
+		}
+		return true
+	}
+	if !slices.Contains(bulkColumns[entityType], key) {
+		return false
+	}
+
+	switch key {
+	case "name":
+		e.name = value
+	case "title":
+		e.title = value
+	case "description":
+		e.description = value
+	case "text":
+		e.text = value
+	case "parent":
+		e.parent = value
+	case "quest":
+		e.quest = value
+	case "tags":
+		e.tags = strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '#' })
+	case "hidden":
+		if value == "" {
+			break
+		}
+		hidden, err := strconv.ParseBool(value)
+		if err != nil {
+			e.fail("hidden must be true or false, got %q", value)
+		} else {
+			e.hidden = &hidden
+		}
+	}
+
+	return true
+}
+
+func newBulkEntry(row int) *bulkEntry {
+	return &bulkEntry{row: row, fields: map[string]string{}}
+}
+
+func parseBulkCSV(body []byte, entityType string) ([]*bulkEntry, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CSV header: %w", err)
+	}
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		if !newBulkEntry(0).set(entityType, header[i], "") {
+			return nil, fmt.Errorf("unknown %s column %q", entityType, column)
+		}
+	}
+
+	entries := []*bulkEntry{}
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("cannot read CSV: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		entry := newBulkEntry(line)
+		if len(values) != len(header) {
+			entry.fail("row has %d columns, header has %d", len(values), len(header))
+		}
+		for i, value := range values {
+			if i < len(header) {
+				entry.set(entityType, header[i], strings.TrimSpace(value))
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+var (
+	bulkHeadingRegexp  = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*$`)
+	bulkMetadataRegexp = regexp.MustCompile(`^([A-Za-z][\w.]*):\s*(.*)$`)
+)
+
+// parseBulkMarkdown reads entities from headings and records from blocks
+// separated by "---". Metadata lines "key: value" may follow a heading or
+// start a record block, the rest is the description or the record text
+func parseBulkMarkdown(body []byte, entityType string) ([]*bulkEntry, error) {
+	entries := []*bulkEntry{}
+	var entry *bulkEntry
+	var content []string
+	metadata := false
+
+	type level struct {
+		depth int
+		entry *bulkEntry
+	}
+	stack := []level{}
+
+	flush := func() {
+		if entry == nil {
+			return
+		}
+		text := strings.TrimSpace(strings.Join(content, "\n"))
+		if entityType == RecordEntity {
+			if text != "" {
+				entry.text = text
+			}
+			if entry.text != "" || len(entry.errors) > 0 {
+				entries = append(entries, entry)
+			}
+		} else {
+			if text != "" {
+				entry.description = text
+			}
+			entries = append(entries, entry)
+		}
+		entry, content = nil, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if entityType == RecordEntity {
+			if strings.TrimSpace(line) == "---" {
+				flush()
+				continue
+			}
+			if entry == nil {
+				if strings.TrimSpace(line) == "" {
+					continue
+				}
+				entry, metadata = newBulkEntry(lineNumber), true
+			}
+		} else if match := bulkHeadingRegexp.FindStringSubmatch(line); match != nil {
+			flush()
+			entry, metadata = newBulkEntry(lineNumber), true
+			entry.name = match[2]
+
+			depth := len(match[1])
+			for len(stack) > 0 && stack[len(stack)-1].depth >= depth {
+				stack = stack[:len(stack)-1]
+			}
+			if entityType == LocationEntity && len(stack) > 0 {
+				entry.parentEntry = stack[len(stack)-1].entry
+			}
+			stack = append(stack, level{depth: depth, entry: entry})
+			continue
+		} else if entry == nil {
+			// Text before the first heading is a preface of the outline
+			continue
+		}
+
+		if metadata {
+			if match := bulkMetadataRegexp.FindStringSubmatch(line); match != nil && entry.set(entityType, strings.ToLower(match[1]), strings.TrimSpace(match[2])) {
+				continue
+			}
+			if strings.TrimSpace(line) == "" && len(content) == 0 {
+				continue
+			}
+			metadata = false
+		}
+		content = append(content, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read Markdown: %w", err)
+	}
+	flush()
+
+	return entries, nil
+}
+
+// fieldValueFromText converts imported text to the JSON value ValidateEntityFields expects
+func (f *CustomField) fieldValueFromText(text string) (any, error) {
+	switch f.Type {
+	case NumberField:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("field %q expects a number, got %q", f.Key, text)
+		}
+		return number, nil
+	case BooleanField:
+		flag, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("field %q expects true or false, got %q", f.Key, text)
+		}
+		return flag, nil
+	case ReferenceField:
+		id, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("field %q expects %s id, got %q", f.Key, f.RefType, text)
+		}
+		return float64(id), nil
+	}
+
+	return text, nil
+}
+
+// BulkImport creates NPCs, locations or records of CSV or Markdown in one transaction.
+// Rows without visibility inherit it from the parent location or take the default one
+func (s *Storage) BulkImport(entityType, format string, body []byte, defaultHidden bool, player *Player) (*BulkReport, error) {
+	if _, ok := bulkColumns[entityType]; !ok {
+		return nil, fmt.Errorf("%w: bulk import is not supported for %q", ErrBulkImportFile, entityType)
+	}
+
+	var entries []*bulkEntry
+	var err error
+	switch format {
+	case CSVFormat:
+		entries, err = parseBulkCSV(body, entityType)
+	case MarkdownFormat:
+		entries, err = parseBulkMarkdown(body, entityType)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrBulkImportFile, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBulkImportFile, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: nothing to import", ErrBulkImportFile)
+	} else if len(entries) > maxBulkRows {
+		return nil, fmt.Errorf("%w: more than %d rows", ErrBulkImportFile, maxBulkRows)
+	}
+
+	err = s.validateBulkEntries(entityType, entries, defaultHidden, player)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BulkReport{EntityType: entityType, Rows: []BulkRow{}}
+	for _, entry := range entries {
+		if len(entry.errors) > 0 {
+			report.Failed++
+		}
+	}
+
+	if report.Failed == 0 {
+		// Rolls of the records are logged in the current session
+		var session *Session
+		if entityType == RecordEntity {
+			session, err = s.currentSession(player.CurrentGame)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = s.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+			return s.insertBulkEntries(ctx, tx, entityType, entries, player, session)
+		})
+		if err != nil {
+			return nil, err
+		}
+		report.Created = len(entries)
+
+		for _, entry := range entries {
+			switch {
+			case entry.npc != nil:
+				s.publishEntity(EntityCreatedEvent, entry.npc.GameID, player, entry.npc.HiddenBy, &EntityEventData{
+					EntityType: NPCEntity, ID: entry.npc.ID, Name: entry.npc.Name, Title: entry.npc.Title, Description: entry.npc.Description,
+				})
+			case entry.location != nil:
+				s.publishEntity(EntityCreatedEvent, entry.location.GameID, player, entry.location.HiddenBy, &EntityEventData{
+					EntityType: LocationEntity, ID: entry.location.ID, Name: entry.location.Name, Title: entry.location.Title, Description: entry.location.Description,
+				})
+			case entry.record != nil:
+				s.publishRecordCreated(entry.record, player)
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		name := entry.name
+		if entityType == RecordEntity {
+			name = strings.SplitN(entry.text, "\n", 2)[0]
+		}
+		report.Rows = append(report.Rows, BulkRow{Row: entry.row, Name: name, ID: entry.id, Errors: entry.errors})
+	}
+
+	if report.Failed > 0 {
+		return report, ErrBulkImportRows
+	}
+
+	return report, nil
+}
+
+func (s *Storage) validateBulkEntries(entityType string, entries []*bulkEntry, defaultHidden bool, player *Player) error {
+	gameID := player.CurrentGameID
+
+	fields, err := s.GetGameCustomFields(gameID, entityType)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entityType == RecordEntity {
+			if entry.text == "" {
+				entry.fail("record text cannot be empty")
+			}
+		} else if entry.name == "" {
+			entry.fail("name cannot be empty")
+		}
+
+		values := map[string]any{}
+		for key, text := range entry.fields {
+			i := slices.IndexFunc(fields, func(f CustomField) bool { return f.Key == key })
+			if i < 0 {
+				entry.fail("unknown %s field %q", entityType, key)
+				continue
+			}
+			value, err := fields[i].fieldValueFromText(text)
+			if err != nil {
+				entry.fail("%v", err)
+				continue
+			}
+			values[key] = value
+		}
+		if len(entry.errors) == 0 && entityType != RecordEntity {
+			entry.fieldValues, err = s.ValidateEntityFields(gameID, entityType, values, true, player)
+			if err != nil {
+				entry.fail("%v", err)
+			}
+		}
+	}
+
+	switch entityType {
+	case LocationEntity:
+		err = s.resolveBulkParents(entries, player)
+	case RecordEntity:
+		err = s.resolveBulkQuests(entries, player)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		hidden := defaultHidden
+		for e := entry; e != nil; e = e.parentEntry {
+			if e.hidden != nil {
+				hidden = *e.hidden
+				break
+			}
+		}
+		entry.hiddenBy = gu.TernaryInt(hidden, player.ID, 0)
+	}
+
+	return nil
+}
+
+// resolveBulkParents links locations to the entries of the same file by name,
+// otherwise to the existing locations by name or id
+func (s *Storage) resolveBulkParents(entries []*bulkEntry, player *Player) error {
+	existing, err := s.GetCurrentGameLocations(player.CurrentGame)
+	if err != nil {
+		return err
+	}
+	existing, err = s.GetAllowedLocations(existing, player.ID)
+	if err != nil {
+		return err
+	}
+
+	byName := map[string][]*bulkEntry{}
+	for _, entry := range entries {
+		key := strings.ToLower(entry.name)
+		byName[key] = append(byName[key], entry)
+	}
+
+	for _, entry := range entries {
+		if entry.parent == "" {
+			continue
+		}
+		entry.parentEntry = nil
+
+		if candidates := byName[strings.ToLower(entry.parent)]; len(candidates) == 1 {
+			entry.parentEntry = candidates[0]
+			continue
+		} else if len(candidates) > 1 {
+			entry.fail("parent %q is ambiguous in the file", entry.parent)
+			continue
+		}
+
+		matches := []int{}
+		parentID, idErr := strconv.Atoi(entry.parent)
+		for _, location := range existing {
+			if location.Deleted != nil {
+				continue
+			}
+			if (idErr == nil && location.ID == parentID) || strings.EqualFold(location.Name, entry.parent) {
+				matches = append(matches, location.ID)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			entry.fail("parent location %q is not found", entry.parent)
+		case 1:
+			entry.parentID = matches[0]
+		default:
+			entry.fail("parent %q matches %d locations, use the location id", entry.parent, len(matches))
+		}
+	}
+
+	for _, entry := range entries {
+		seen := map[*bulkEntry]bool{}
+		for e := entry; e != nil; e = e.parentEntry {
+			if seen[e] {
+				entry.fail("location is nested into itself")
+				entry.parentEntry = nil
+				break
+			}
+			seen[e] = true
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) resolveBulkQuests(entries []*bulkEntry, player *Player) error {
+	quests, err := s.GetCurrentGameQuests(player.CurrentGame)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.quest == "" {
+			continue
+		}
+
+		questID, idErr := strconv.Atoi(entry.quest)
+		i := slices.IndexFunc(quests, func(q Quest) bool {
+			visible := q.HiddenBy == 0 || q.HiddenBy == player.ID
+			return visible && ((idErr == nil && q.ID == questID) || strings.EqualFold(q.Name, entry.quest))
+		})
+		if i < 0 {
+			entry.fail("quest %q is not found", entry.quest)
+			continue
+		}
+		entry.questID = quests[i].ID
+	}
+
+	return nil
+}
+
+func (s *Storage) insertBulkEntries(ctx context.Context, tx bun.Tx, entityType string, entries []*bulkEntry, player *Player, session *Session) error {
+	gameID := player.CurrentGameID
+
+	for _, entry := range entries {
+		var err error
+		switch entityType {
+		case NPCEntity:
+			npc := NPC{
+				Name:        entry.name,
+				Title:       entry.title,
+				Description: entry.description,
+				HiddenBy:    entry.hiddenBy,
+				CreatedByID: player.ID,
+				GameID:      gameID,
+			}
+			_, err = tx.NewInsert().Model(&npc).
+				Column("name", "title", "description", "hidden_by", "created_by_id", "game_id").
+				Returning("*").Exec(ctx)
+			entry.id = npc.ID
+			entry.npc = &npc
+		case LocationEntity:
+			location := Location{
+				Name:        entry.name,
+				Title:       entry.title,
+				Description: entry.description,
+				ParentID:    entry.parentID,
+				HiddenBy:    entry.hiddenBy,
+				CreatedByID: player.ID,
+				GameID:      gameID,
+			}
+			_, err = tx.NewInsert().Model(&location).
+				Column("name", "title", "description", "pid", "hidden_by", "created_by_id", "game_id").
+				Returning("*").Exec(ctx)
+			entry.id = location.ID
+			entry.location = &location
+		case RecordEntity:
+			// Records go the same way as the created ones with their rolls,
+			// mentions and tags
+			entry.record, err = s.insertRecordTx(ctx, tx, &reqData.RecordInsert{
+				Text:    entry.text,
+				Hidden:  entry.hiddenBy != 0,
+				QuestID: entry.questID,
+				Tags:    entry.tags,
+			}, player, session)
+			if entry.record != nil {
+				entry.id = entry.record.ID
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import row %d: %w", entry.row, err)
+		}
+
+		for fieldID, value := range entry.fieldValues {
+			if value == nil {
+				continue
+			}
+			fieldValue := CustomFieldValue{FieldID: fieldID, EntityID: entry.id, EntityType: entityType, GameID: gameID, Value: *value}
+			if _, err = tx.NewInsert().Model(&fieldValue).Exec(ctx); err != nil {
+				return fmt.Errorf("failed to set fields of row %d: %w", entry.row, err)
+			}
+		}
+
+		// Records are tagged on the insert
+		if entityType == RecordEntity {
+			continue
+		}
+		if err = s.SetEntityTags(ctx, tx, gameID, entityType, entry.id, entry.tags); err != nil {
+			return fmt.Errorf("failed to tag row %d: %w", entry.row, err)
+		}
+	}
+
+	// Parents from the same file get their IDs only after the insert
+	if entityType == LocationEntity {
+		for _, entry := range entries {
+			if entry.parentEntry == nil {
+				continue
+			}
+			_, err := tx.NewUpdate().Model((*Location)(nil)).
+				Set("pid = ?", entry.parentEntry.id).
+				Where("id = ?", entry.id).
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to set parent of row %d: %w", entry.row, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package data
+
+import (
+	"cmp"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type GameCalendar struct {
+	bun.BaseModel `bun:"table:game_calendar"`
+
+	GameID int   `bun:"game_id,pk"`
+	Game   *Game `bun:"rel:belongs-to,join:game_id=id"`
+
+	Months     []CalendarMonth `bun:"months,type:jsonb" json:"months"`
+	WeekLength int             `bun:"week_length,default:7" json:"weekLength"`
+	Weekdays   []string        `bun:"weekdays,type:jsonb" json:"weekdays"`
+	Eras       []CalendarEra   `bun:"eras,type:jsonb" json:"eras"`
+}
+
+type CalendarMonth struct {
+	Name string `json:"name"`
+	Days int    `json:"days"`
+}
+
+// CalendarEra starts at StartYear and lasts until the next era
+type CalendarEra struct {
+	Name      string `json:"name"`
+	StartYear int    `json:"startYear"`
+}
+
+// WorldDate is an in-world date, Month and Day are counted from 1
+type WorldDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
+type TimelineEvent struct {
+	bun.BaseModel `bun:"table:timeline_event"`
+
+	ID          int    `bun:"id,pk,autoincrement"`
+	Name        string `bun:"name,notnull"`
+	Description string `bun:"description"`
+
+	WorldStart *WorldDate `bun:"world_start,type:jsonb"`
+	WorldEnd   *WorldDate `bun:"world_end,type:jsonb"`
+
+	GameID      int     `bun:"game_id,notnull"`
+	Game        *Game   `bun:"rel:belongs-to,join:game_id=id"`
+	CreatedByID int     `bun:"created_by_id"`
+	CreatedBy   *Player `bun:"rel:belongs-to,join:created_by_id=id"`
+	HiddenBy    int     `bun:"hidden_by,default:0"`
+	Version     int     `bun:"version,notnull,default:1"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp"`
+	Deleted *time.Time `bun:"deleted,default:null"`
+}
+
+// DefaultCalendar is used until GM sets up a calendar of the game
+func DefaultCalendar(gameID int) *GameCalendar {
+	calendar := &GameCalendar{
+		GameID:     gameID,
+		WeekLength: 7,
+		Weekdays:   []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"},
+		Eras:       []CalendarEra{},
+	}
+	for month := time.January; month <= time.December; month++ {
+		days := time.Date(2001, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		calendar.Months = append(calendar.Months, CalendarMonth{Name: month.String(), Days: days})
+	}
+
+	return calendar
+}
+
+func (c *GameCalendar) Validate() error {
+	if len(c.Months) == 0 {
+		return fmt.Errorf("calendar must have at least one month")
+	}
+	for _, month := range c.Months {
+		if month.Name == "" || month.Days <= 0 {
+			return fmt.Errorf("calendar month must have a name and a positive days count")
+		}
+	}
+	if c.WeekLength <= 0 {
+		return fmt.Errorf("calendar week length must be positive")
+	}
+	if len(c.Weekdays) != 0 && len(c.Weekdays) != c.WeekLength {
+		return fmt.Errorf("calendar must have %d weekday names or none", c.WeekLength)
+	}
+	for i := 1; i < len(c.Eras); i++ {
+		if c.Eras[i].StartYear <= c.Eras[i-1].StartYear {
+			return fmt.Errorf("calendar eras must be ordered by start year")
+		}
+	}
+
+	return nil
+}
+
+func (c *GameCalendar) ValidateDate(d *WorldDate) error {
+	if d == nil {
+		return nil
+	}
+	if d.Month < 1 || d.Month > len(c.Months) {
+		return fmt.Errorf("month %d is out of calendar range 1-%d", d.Month, len(c.Months))
+	}
+	if d.Day < 1 || d.Day > c.Months[d.Month-1].Days {
+		return fmt.Errorf("day %d is out of %s range 1-%d", d.Day, c.Months[d.Month-1].Name, c.Months[d.Month-1].Days)
+	}
+
+	return nil
+}
+
+func (c *GameCalendar) ValidateRange(start, end *WorldDate) error {
+	if start == nil && end != nil {
+		return fmt.Errorf("date range cannot have an end without a start")
+	}
+	if err := c.ValidateDate(start); err != nil {
+		return err
+	}
+	if err := c.ValidateDate(end); err != nil {
+		return err
+	}
+	if end != nil && end.Compare(start) < 0 {
+		return fmt.Errorf("date range end is before its start")
+	}
+
+	return nil
+}
+
+// Era returns name of the era the year belongs to
+func (c *GameCalendar) Era(year int) string {
+	era := ""
+	for _, e := range c.Eras {
+		if e.StartYear <= year {
+			era = e.Name
+		}
+	}
+
+	return era
+}
+
+// Weekday counts days from the first day of year 0
+func (c *GameCalendar) Weekday(d *WorldDate) string {
+	if len(c.Weekdays) == 0 || c.WeekLength == 0 {
+		return ""
+	}
+
+	yearLength := 0
+	for _, month := range c.Months {
+		yearLength += month.Days
+	}
+	days := d.Year * yearLength
+	for i := 0; i < d.Month-1 && i < len(c.Months); i++ {
+		days += c.Months[i].Days
+	}
+	days += d.Day - 1
+
+	weekday := days % c.WeekLength
+	if weekday < 0 {
+		weekday += c.WeekLength
+	}
+
+	return c.Weekdays[weekday]
+}
+
+func (c *GameCalendar) Format(d *WorldDate) string {
+	if d == nil {
+		return ""
+	}
+
+	month := fmt.Sprintf("%d", d.Month)
+	if d.Month >= 1 && d.Month <= len(c.Months) {
+		month = c.Months[d.Month-1].Name
+	}
+
+	formatted := fmt.Sprintf("%d %s %d", d.Day, month, d.Year)
+	if era := c.Era(d.Year); era != "" {
+		formatted += ", " + era
+	}
+
+	return formatted
+}
+
+// Compare treats nil as the latest date
+func (d *WorldDate) Compare(other *WorldDate) int {
+	switch {
+	case d == nil && other == nil:
+		return 0
+	case d == nil:
+		return 1
+	case other == nil:
+		return -1
+	}
+
+	if d.Year != other.Year {
+		return cmp.Compare(d.Year, other.Year)
+	}
+	if d.Month != other.Month {
+		return cmp.Compare(d.Month, other.Month)
+	}
+
+	return cmp.Compare(d.Day, other.Day)
+}
@@ -0,0 +1,120 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"personae-fasti/api/models/reqData"
+
+	"github.com/uptrace/bun"
+)
+
+type CharStatus string
+
+const (
+	CharActive  CharStatus = "active"
+	CharRetired CharStatus = "retired"
+	CharDead    CharStatus = "dead"
+)
+
+func (s CharStatus) Valid() bool {
+	return s == CharActive || s == CharRetired || s == CharDead
+}
+
+// Former is true for the retired and dead chars
+func (c *Char) Former() bool {
+	return c.Status == CharRetired || c.Status == CharDead
+}
+
+// TransferChar gives the char to the player of the game or to the GM as
+// a ward. The hidden char stays hidden by its new owner
+func (s *Storage) TransferChar(char *Char, transfer *reqData.CharTransfer, player *Player) (*Char, error) {
+	game := player.CurrentGame
+	if transfer.PlayerID != game.GMID {
+		inGame, err := s.db.NewSelect().Model((*PlayerGame)(nil)).
+			Where("player_id = ? AND game_id = ?", transfer.PlayerID, game.ID).
+			Exists(context.Background())
+		if err != nil {
+			return nil, err
+		} else if !inGame {
+			return nil, fmt.Errorf("player %d does not play the game %d", transfer.PlayerID, game.ID)
+		}
+	}
+
+	oldHiddenBy := char.HiddenBy
+	hiddenBy := 0
+	if char.HiddenBy != 0 {
+		hiddenBy = transfer.PlayerID
+	}
+
+	result, err := updateVersion(s.db.NewUpdate().Model(char).WherePK().
+		Set("player_id = ?", transfer.PlayerID).
+		Set("hidden_by = ?", hiddenBy), transfer.Version).
+		Returning("*").Exec(context.Background())
+	if err == nil {
+		err = checkVersion(result)
+	}
+	if err == nil {
+		s.publishEntityUpdate(char.GameID, player, oldHiddenBy, hiddenBy, &EntityEventData{
+			EntityType: CharEntity, ID: char.ID, Name: char.Name, Title: char.Title, Description: char.Description,
+		})
+	}
+
+	return char, err
+}
+
+// SetCharStatus retires, kills or brings back the char. The time and the
+// session default to now and the current session, an active char has
+// neither
+func (s *Storage) SetCharStatus(char *Char, statusUpdate *reqData.CharStatusUpdate, player *Player) (*Char, error) {
+	status := CharStatus(statusUpdate.Status)
+	if !status.Valid() {
+		return nil, fmt.Errorf("unknown char status %q", statusUpdate.Status)
+	}
+
+	var leftTime *time.Time
+	leftSession := 0
+	if status != CharActive {
+		leftTime = statusUpdate.Time
+		if leftTime == nil {
+			now := time.Now().UTC()
+			leftTime = &now
+		}
+
+		if statusUpdate.SessionNumber != nil {
+			exists, err := s.db.NewSelect().Model((*Session)(nil)).
+				Where("game_id = ? AND number = ?", char.GameID, *statusUpdate.SessionNumber).
+				Exists(context.Background())
+			if err != nil {
+				return nil, err
+			} else if !exists {
+				return nil, fmt.Errorf("no session %d in the game %d", *statusUpdate.SessionNumber, char.GameID)
+			}
+			leftSession = *statusUpdate.SessionNumber
+		} else {
+			session, err := s.currentSession(player.CurrentGame)
+			if err != nil {
+				return nil, err
+			} else if session != nil {
+				leftSession = session.Number
+			}
+		}
+	}
+
+	result, err := updateVersion(s.db.NewUpdate().Model(char).WherePK().
+		Set("status = ?", status).
+		Set("left_time = ?", leftTime).
+		Set("left_session = ?", bun.NullZero(leftSession)), statusUpdate.Version).
+		Returning("*").Exec(context.Background())
+	if err == nil {
+		err = checkVersion(result)
+	}
+	if err == nil {
+		s.publishEntityUpdate(char.GameID, player, char.HiddenBy, char.HiddenBy, &EntityEventData{
+			EntityType: CharEntity, ID: char.ID, Name: char.Name, Title: char.Title, Description: char.Description,
+		})
+	}
+
+	return char, err
+}
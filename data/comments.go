@@ -0,0 +1,411 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"personae-fasti/api/models/reqData"
+	gu "personae-fasti/gewi-utils"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	MaxCommentLength = 4000
+	MaxEmojiLength   = 8
+)
+
+// Comment answers a record or another comment of it. Hidden comments are
+// seen only by their author like hidden records
+type Comment struct {
+	bun.BaseModel `bun:"table:record_comment"`
+
+	ID int `bun:"id,pk,autoincrement" json:"id"`
+
+	RecordID int     `bun:"record_id,notnull" json:"recordID"`
+	Record   *Record `bun:"rel:belongs-to,join:record_id=id" json:"-"`
+	GameID   int     `bun:"game_id,notnull" json:"gameID"`
+	PlayerID int     `bun:"player_id,notnull" json:"playerID"`
+	Player   *Player `bun:"rel:belongs-to,join:player_id=id" json:"-"`
+	// Zero for the comments to the record itself
+	ParentID int    `bun:"parent_id,nullzero" json:"parentID,omitempty"`
+	Text     string `bun:"text,notnull" json:"text"`
+	HiddenBy int    `bun:"hidden_by,default:0" json:"hiddenBy"`
+	Version  int    `bun:"version,notnull,default:1" json:"version"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp" json:"created"`
+	Updated *time.Time `bun:"updated,nullzero,notnull,default:current_timestamp" json:"updated"`
+	Deleted *time.Time `bun:"deleted,default:null" json:"-"`
+}
+
+// Reaction is an emoji of the player on the record. A player may put
+// several different emojis
+type Reaction struct {
+	bun.BaseModel `bun:"table:record_reaction"`
+
+	RecordID int    `bun:"record_id,pk"`
+	PlayerID int    `bun:"player_id,pk"`
+	Emoji    string `bun:"emoji,pk"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp"`
+}
+
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+	// The player put this reaction too
+	Mine bool `json:"mine"`
+}
+
+type CommentEventData struct {
+	ID       int    `json:"id"`
+	RecordID int    `json:"recordID"`
+	ParentID int    `json:"parentID,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Author   int    `json:"authorID"`
+}
+
+type ReactionEventData struct {
+	RecordID int    `json:"recordID"`
+	Emoji    string `json:"emoji"`
+	Removed  bool   `json:"removed"`
+}
+
+// visibleCommentsQuery limits the comments to the ones of the records the
+// player sees
+func visibleCommentsQuery(q *bun.SelectQuery, player *Player) *bun.SelectQuery {
+	return q.
+		Where("comment.deleted IS NULL").
+		Where("comment.hidden_by IN (0, ?)", player.ID).
+		Where(`EXISTS (
+			SELECT 1 FROM record WHERE record.id = comment.record_id AND record.deleted IS NULL AND record.hidden_by IN (0, ?)
+		)`, player.ID)
+}
+
+// GetRecordComments returns the comments of the record the player sees in
+// the order they were written
+func (s *Storage) GetRecordComments(record *Record, player *Player) ([]Comment, error) {
+	comments := []Comment{}
+	err := s.db.NewSelect().Model(&comments).
+		Apply(func(q *bun.SelectQuery) *bun.SelectQuery {
+			return visibleCommentsQuery(q, player)
+		}).
+		Where("comment.record_id = ?", record.ID).
+		Relation("Player").
+		Order("comment.id ASC").
+		Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// GetCommentByID returns nil if there is no comment or it is deleted
+func (s *Storage) GetCommentByID(commentID int) (*Comment, error) {
+	comments := []Comment{}
+	err := s.db.NewSelect().Model(&comments).
+		Where("id = ?", commentID).
+		Where("deleted IS NULL").
+		Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	} else if len(comments) == 0 {
+		return nil, nil
+	}
+
+	return &comments[0], nil
+}
+
+func validateCommentText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("comment is empty")
+	} else if utf8.RuneCountInString(text) > MaxCommentLength {
+		return "", fmt.Errorf("comment is longer than %d characters", MaxCommentLength)
+	}
+
+	return text, nil
+}
+
+// commentHiddenBy is the player who may see the events of the comment
+func commentHiddenBy(record *Record, comment *Comment) int {
+	return gu.TernaryInt(comment.HiddenBy != 0, comment.HiddenBy, record.HiddenBy)
+}
+
+func (s *Storage) CreateComment(record *Record, commentCreate *reqData.CommentCreate, player *Player) (*Comment, error) {
+	text, err := validateCommentText(commentCreate.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	if commentCreate.ParentID != 0 {
+		parent, err := s.GetCommentByID(commentCreate.ParentID)
+		if err != nil {
+			return nil, err
+		} else if parent == nil || parent.RecordID != record.ID || (parent.HiddenBy != 0 && parent.HiddenBy != player.ID) {
+			return nil, fmt.Errorf("no comment %d to reply in the record %d", commentCreate.ParentID, record.ID)
+		}
+	}
+
+	comment := Comment{
+		RecordID: record.ID,
+		GameID:   record.GameID,
+		PlayerID: player.ID,
+		ParentID: commentCreate.ParentID,
+		Text:     text,
+		HiddenBy: gu.TernaryInt(commentCreate.Hidden, player.ID, 0),
+	}
+
+	_, err = s.db.NewInsert().Model(&comment).Returning("*").Exec(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	comment.Player = player
+
+	s.publish(Event{
+		Type:     CommentCreatedEvent,
+		GameID:   comment.GameID,
+		PlayerID: player.ID,
+		Username: player.Username,
+		HiddenBy: commentHiddenBy(record, &comment),
+		Data:     commentEventData(&comment),
+	})
+
+	return &comment, nil
+}
+
+// UpdateComment changes the text and visibility of the comment. Only the
+// author may edit it
+func (s *Storage) UpdateComment(record *Record, comment *Comment, commentUpdate *reqData.CommentUpdate, player *Player) error {
+	text, err := validateCommentText(commentUpdate.Text)
+	if err != nil {
+		return err
+	}
+
+	oldHiddenBy := commentHiddenBy(record, comment)
+	comment.Text = text
+	comment.HiddenBy = gu.TernaryInt(commentUpdate.Hidden, player.ID, 0)
+
+	result, err := updateVersion(s.db.NewUpdate().Model(comment).WherePK().
+		Set("text = ?", comment.Text).
+		Set("hidden_by = ?", comment.HiddenBy).
+		Set("updated = current_timestamp"), commentUpdate.Version).
+		Returning("updated, version").
+		Exec(context.Background())
+	if err == nil {
+		err = checkVersion(result)
+	}
+	if err != nil {
+		return err
+	}
+
+	// A comment hidden from everyone else looks deleted for them
+	event := Event{
+		Type:     CommentUpdatedEvent,
+		GameID:   comment.GameID,
+		PlayerID: player.ID,
+		Username: player.Username,
+		HiddenBy: commentHiddenBy(record, comment),
+		Data:     commentEventData(comment),
+	}
+	if oldHiddenBy == 0 && event.HiddenBy != 0 {
+		event.Type = CommentDeletedEvent
+		event.HiddenBy = 0
+		event.Data = &CommentEventData{ID: comment.ID, RecordID: comment.RecordID, Author: comment.PlayerID}
+	}
+	s.publish(event)
+
+	return nil
+}
+
+// DeleteComment keeps the replies to the comment
+func (s *Storage) DeleteComment(record *Record, comment *Comment, player *Player) error {
+	_, err := s.db.NewUpdate().Model(comment).
+		Set("deleted = current_timestamp").
+		WherePK().
+		Exec(context.Background())
+	if err != nil {
+		return err
+	}
+
+	s.publish(Event{
+		Type:     CommentDeletedEvent,
+		GameID:   comment.GameID,
+		PlayerID: player.ID,
+		Username: player.Username,
+		HiddenBy: commentHiddenBy(record, comment),
+		Data:     &CommentEventData{ID: comment.ID, RecordID: comment.RecordID, Author: comment.PlayerID},
+	})
+
+	return nil
+}
+
+func commentEventData(comment *Comment) *CommentEventData {
+	return &CommentEventData{
+		ID:       comment.ID,
+		RecordID: comment.RecordID,
+		ParentID: comment.ParentID,
+		Text:     comment.Text,
+		Author:   comment.PlayerID,
+	}
+}
+
+// validateEmoji lets only short strings without letters and digits be
+// reactions
+func validateEmoji(emoji string) (string, error) {
+	emoji = strings.TrimSpace(emoji)
+	if emoji == "" || utf8.RuneCountInString(emoji) > MaxEmojiLength {
+		return "", fmt.Errorf("reaction %q is not an emoji", emoji)
+	}
+	for _, r := range emoji {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			return "", fmt.Errorf("reaction %q is not an emoji", emoji)
+		}
+	}
+
+	return emoji, nil
+}
+
+// SetReaction puts the emoji of the player on the record or removes it
+func (s *Storage) SetReaction(record *Record, reactionSet *reqData.ReactionSet, player *Player) error {
+	emoji, err := validateEmoji(reactionSet.Emoji)
+	if err != nil {
+		return err
+	}
+
+	reaction := Reaction{
+		RecordID: record.ID,
+		PlayerID: player.ID,
+		Emoji:    emoji,
+	}
+	if reactionSet.Remove {
+		_, err = s.db.NewDelete().Model(&reaction).WherePK().Exec(context.Background())
+	} else {
+		_, err = s.db.NewInsert().Model(&reaction).On("CONFLICT DO NOTHING").Exec(context.Background())
+	}
+	if err != nil {
+		return err
+	}
+
+	s.publish(Event{
+		Type:     ReactionChangedEvent,
+		GameID:   record.GameID,
+		PlayerID: player.ID,
+		Username: player.Username,
+		HiddenBy: record.HiddenBy,
+		Data:     &ReactionEventData{RecordID: record.ID, Emoji: emoji, Removed: reactionSet.Remove},
+	})
+
+	return nil
+}
+
+// FillRecordFeedback counts the comments the player sees, the ones
+// written by others since the player's last action and the reactions
+func (s *Storage) FillRecordFeedback(records []Record, player *Player) error {
+	if len(records) == 0 {
+		return nil
+	}
+	ids := make([]int, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+
+	var commentCounts []struct {
+		RecordID int `bun:"record_id"`
+		Count    int `bun:"count"`
+		Unread   int `bun:"unread"`
+	}
+	err := s.db.NewSelect().
+		TableExpr("record_comment AS comment").
+		ColumnExpr("comment.record_id, COUNT(*) AS count").
+		ColumnExpr("COUNT(*) FILTER (WHERE comment.player_id != ? AND comment.created > ?) AS unread", player.ID, player.CommentsReadTime()).
+		Apply(func(q *bun.SelectQuery) *bun.SelectQuery {
+			return visibleCommentsQuery(q, player)
+		}).
+		Where("comment.record_id IN (?)", bun.In(ids)).
+		Group("comment.record_id").
+		Scan(context.Background(), &commentCounts)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	var reactionCounts []struct {
+		RecordID int    `bun:"record_id"`
+		Emoji    string `bun:"emoji"`
+		Count    int    `bun:"count"`
+		Mine     bool   `bun:"mine"`
+	}
+	err = s.db.NewSelect().
+		TableExpr("record_reaction").
+		ColumnExpr("record_id, emoji, COUNT(*) AS count").
+		ColumnExpr("bool_or(player_id = ?) AS mine", player.ID).
+		Where("record_id IN (?)", bun.In(ids)).
+		Group("record_id", "emoji").
+		OrderExpr("record_id, MIN(created)").
+		Scan(context.Background(), &reactionCounts)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	for i := range records {
+		records[i].Comments = 0
+		records[i].UnreadComments = 0
+		records[i].Reactions = []ReactionCount{}
+		for _, count := range commentCounts {
+			if count.RecordID == records[i].ID {
+				records[i].Comments = count.Count
+				records[i].UnreadComments = count.Unread
+			}
+		}
+		for _, count := range reactionCounts {
+			if count.RecordID == records[i].ID {
+				records[i].Reactions = append(records[i].Reactions, ReactionCount{Emoji: count.Emoji, Count: count.Count, Mine: count.Mine})
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetUnreadComments returns the comments of the game written by others
+// since the player has read them
+func (s *Storage) GetUnreadComments(game *Game, player *Player) ([]Comment, error) {
+	comments := []Comment{}
+	err := s.db.NewSelect().Model(&comments).
+		Apply(func(q *bun.SelectQuery) *bun.SelectQuery {
+			return visibleCommentsQuery(q, player)
+		}).
+		Where("comment.game_id = ?", game.ID).
+		Where("comment.player_id != ?", player.ID).
+		Where("comment.created > ?", player.CommentsReadTime()).
+		Relation("Player").
+		Order("comment.id ASC").
+		Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// MarkCommentsRead moves the player's comments marker to now, so the
+// comments written before are not unread anymore
+func (s *Storage) MarkCommentsRead(player *Player) error {
+	now := time.Now().UTC()
+	_, err := s.db.NewUpdate().Model(player).
+		Set("comments_read = ?", now).
+		WherePK().
+		Exec(context.Background())
+	if err != nil {
+		return err
+	}
+	player.CommentsRead = &now
+
+	return nil
+}
@@ -0,0 +1,376 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// The digest of a player who has never marked it seen starts a week ago
+const defaultDigestPeriod = 7 * 24 * time.Hour
+
+// Quest changes of the digest
+const (
+	QuestStarted   = "started"
+	QuestCompleted = "completed"
+	QuestFailed    = "failed"
+)
+
+// Digest is what changed in the game between Since and Until as the
+// player sees it
+type Digest struct {
+	GameID   int       `json:"gameID"`
+	GameName string    `json:"gameName"`
+	Since    time.Time `json:"since"`
+	Until    time.Time `json:"until"`
+
+	Sessions      []DigestSession `json:"sessions"`
+	NewRecords    []DigestRecord  `json:"newRecords"`
+	EditedRecords []DigestRecord  `json:"editedRecords"`
+	NPCs          []DigestEntity  `json:"npcs"`
+	Locations     []DigestEntity  `json:"locations"`
+	Quests        []DigestQuest   `json:"quests"`
+	Tasks         []DigestTask    `json:"tasks"`
+}
+
+type DigestSession struct {
+	Number int    `json:"number"`
+	Name   string `json:"name"`
+}
+
+type DigestRecord struct {
+	ID       int        `json:"id"`
+	Text     string     `json:"text"`
+	AuthorID int        `json:"authorID"`
+	Author   string     `json:"author"`
+	QuestID  int        `json:"questID,omitempty"`
+	Created  *time.Time `json:"created"`
+	Updated  *time.Time `json:"updated"`
+}
+
+type DigestEntity struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Title string `json:"title,omitempty"`
+}
+
+type DigestQuest struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Title  string `json:"title,omitempty"`
+	Change string `json:"change"`
+}
+
+// DigestTask sums up the progress of the task over the digest period
+type DigestTask struct {
+	QuestID   int    `json:"questID"`
+	QuestName string `json:"questName"`
+	TaskID    int    `json:"taskID"`
+	TaskName  string `json:"taskName"`
+	Previous  int    `json:"previous"`
+	Current   int    `json:"current"`
+	Capacity  int    `json:"capacity"`
+	Finished  bool   `json:"finished"`
+}
+
+// Empty is true if nothing changed
+func (d *Digest) Empty() bool {
+	return len(d.Sessions) == 0 && len(d.NewRecords) == 0 && len(d.EditedRecords) == 0 &&
+		len(d.NPCs) == 0 && len(d.Locations) == 0 && len(d.Quests) == 0 && len(d.Tasks) == 0
+}
+
+// GetDigestSince returns the last-seen marker of the player in the game
+func (s *Storage) GetDigestSince(game *Game, player *Player) (time.Time, error) {
+	var lastSeen sql.NullTime
+	err := s.db.NewSelect().Model((*PlayerGame)(nil)).
+		Column("last_seen").
+		Where("player_id = ? AND game_id = ?", player.ID, game.ID).
+		Scan(context.Background(), &lastSeen)
+	if err != nil && err != sql.ErrNoRows {
+		return time.Time{}, err
+	}
+
+	if !lastSeen.Valid {
+		return time.Now().UTC().Add(-defaultDigestPeriod), nil
+	}
+
+	return lastSeen.Time.UTC(), nil
+}
+
+// MarkDigestSeen moves the last-seen marker of the player in the game
+func (s *Storage) MarkDigestSeen(game *Game, player *Player, seen time.Time) error {
+	_, err := s.db.NewUpdate().Model((*PlayerGame)(nil)).
+		Set("last_seen = ?", seen.UTC()).
+		Where("player_id = ? AND game_id = ?", player.ID, game.ID).
+		Exec(context.Background())
+	return err
+}
+
+// GetDigest collects the changes of the game the player sees
+func (s *Storage) GetDigest(game *Game, player *Player, since time.Time) (*Digest, error) {
+	ctx := context.Background()
+	digest := &Digest{
+		GameID:        game.ID,
+		GameName:      game.Name,
+		Since:         since.UTC(),
+		Until:         time.Now().UTC(),
+		Sessions:      []DigestSession{},
+		NewRecords:    []DigestRecord{},
+		EditedRecords: []DigestRecord{},
+		NPCs:          []DigestEntity{},
+		Locations:     []DigestEntity{},
+		Quests:        []DigestQuest{},
+		Tasks:         []DigestTask{},
+	}
+	visible := func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.Where("?TableAlias.hidden_by IN (0, ?)", player.ID).Where("?TableAlias.deleted IS NULL")
+	}
+
+	// An old session without the start time starts when the previous one
+	// ends
+	sessions := []Session{}
+	err := s.db.NewSelect().Model(&sessions).
+		Join("LEFT JOIN session AS previous ON previous.game_id = session.game_id AND previous.number = session.number - 1").
+		Where("session.game_id = ?", game.ID).
+		Where("session.number > 0").
+		Where("COALESCE(session.start_time, previous.end_time) > ?", digest.Since).
+		Where("COALESCE(session.start_time, previous.end_time) <= ?", digest.Until).
+		Order("session.number ASC").
+		Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get digest sessions: %w", err)
+	}
+	for _, session := range sessions {
+		digest.Sessions = append(digest.Sessions, DigestSession{Number: session.Number, Name: session.Name})
+	}
+
+	// Own new records are not news for the player
+	newRecords := []Record{}
+	err = s.db.NewSelect().Model(&newRecords).
+		Apply(visible).
+		Where("record.game_id = ?", game.ID).
+		Where("record.player_id != ?", player.ID).
+		Where("record.created > ? AND record.created <= ?", digest.Since, digest.Until).
+		Relation("Player").
+		Order("record.id ASC").
+		Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get digest records: %w", err)
+	}
+
+	editedRecords := []Record{}
+	err = s.db.NewSelect().Model(&editedRecords).
+		Apply(visible).
+		Where("record.game_id = ?", game.ID).
+		Where("record.player_id != ?", player.ID).
+		Where("record.created <= ?", digest.Since).
+		Where("record.updated > ? AND record.updated <= ?", digest.Since, digest.Until).
+		Relation("Player").
+		Order("record.updated ASC").
+		Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get digest records: %w", err)
+	}
+	digest.NewRecords = digestRecords(newRecords)
+	digest.EditedRecords = digestRecords(editedRecords)
+
+	npcs := []NPC{}
+	err = s.db.NewSelect().Model(&npcs).
+		Apply(visible).
+		Where("npc.game_id = ?", game.ID).
+		Where("npc.created > ? AND npc.created <= ?", digest.Since, digest.Until).
+		Order("npc.id ASC").
+		Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get digest npcs: %w", err)
+	}
+	for _, npc := range npcs {
+		digest.NPCs = append(digest.NPCs, DigestEntity{ID: npc.ID, Name: npc.Name, Title: npc.Title})
+	}
+
+	locations := []Location{}
+	err = s.db.NewSelect().Model(&locations).
+		Apply(visible).
+		Where("location.game_id = ?", game.ID).
+		Where("location.created > ? AND location.created <= ?", digest.Since, digest.Until).
+		Order("location.id ASC").
+		Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get digest locations: %w", err)
+	}
+	for _, location := range locations {
+		digest.Locations = append(digest.Locations, DigestEntity{ID: location.ID, Name: location.Name, Title: location.Title})
+	}
+
+	quests := []Quest{}
+	err = s.db.NewSelect().Model(&quests).
+		Apply(visible).
+		Where("quest.game_id = ?", game.ID).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("quest.created > ? AND quest.created <= ?", digest.Since, digest.Until).
+				WhereOr("quest.finished > ? AND quest.finished <= ?", digest.Since, digest.Until)
+		}).
+		Order("quest.id ASC").
+		Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get digest quests: %w", err)
+	}
+	for _, quest := range quests {
+		if quest.Created != nil && quest.Created.After(digest.Since) {
+			digest.Quests = append(digest.Quests, DigestQuest{ID: quest.ID, Name: quest.Name, Title: quest.Title, Change: QuestStarted})
+		}
+		if quest.Finished != nil && quest.Finished.After(digest.Since) {
+			change := QuestFailed
+			if quest.Successful {
+				change = QuestCompleted
+			}
+			digest.Quests = append(digest.Quests, DigestQuest{ID: quest.ID, Name: quest.Name, Title: quest.Title, Change: change})
+		}
+	}
+
+	progress := []QuestTaskProgress{}
+	err = s.db.NewSelect().Model(&progress).
+		Relation("Quest").
+		Relation("Task").
+		Where("quest_task_progress.game_id = ?", game.ID).
+		Where("quest_task_progress.created > ? AND quest_task_progress.created <= ?", digest.Since, digest.Until).
+		Where("quest.hidden_by IN (0, ?) AND quest.deleted IS NULL", player.ID).
+		Where("task.hidden_by IN (0, ?)", player.ID).
+		Order("quest_task_progress.id ASC").
+		Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get digest tasks: %w", err)
+	}
+	tasks := map[int]int{}
+	for _, change := range progress {
+		i, ok := tasks[change.TaskID]
+		if !ok {
+			i = len(digest.Tasks)
+			tasks[change.TaskID] = i
+			digest.Tasks = append(digest.Tasks, DigestTask{
+				QuestID:   change.QuestID,
+				QuestName: change.Quest.Name,
+				TaskID:    change.TaskID,
+				TaskName:  change.Task.Name,
+				Previous:  change.Previous,
+				Capacity:  change.Task.Capacity,
+			})
+		}
+		digest.Tasks[i].Current = change.Current
+		digest.Tasks[i].Finished = change.Finished
+	}
+
+	return digest, nil
+}
+
+func digestRecords(records []Record) []DigestRecord {
+	digestRecords := []DigestRecord{}
+	for _, record := range records {
+		digestRecord := DigestRecord{
+			ID:       record.ID,
+			Text:     record.Text,
+			AuthorID: record.PlayerID,
+			QuestID:  record.QuestID,
+			Created:  record.Created,
+			Updated:  record.Updated,
+		}
+		if record.Player != nil {
+			digestRecord.Author = record.Player.Username
+		}
+		digestRecords = append(digestRecords, digestRecord)
+	}
+
+	return digestRecords
+}
+
+// Markdown renders the digest to send it as a message
+func (d *Digest) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s: what's new\n\n", d.GameName)
+	fmt.Fprintf(&sb, "*%s - %s*\n", d.Since.Format("2006-01-02 15:04"), d.Until.Format("2006-01-02 15:04"))
+
+	if d.Empty() {
+		sb.WriteString("\nNothing changed.\n")
+		return sb.String()
+	}
+
+	if len(d.Sessions) > 0 {
+		sb.WriteString("\n## Sessions\n\n")
+		for _, session := range d.Sessions {
+			line := fmt.Sprintf("- Session %d", session.Number)
+			if session.Name != "" {
+				line += ": " + session.Name
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	writeRecords := func(title string, records []DigestRecord) {
+		if len(records) == 0 {
+			return
+		}
+		sb.WriteString("\n## " + title + "\n")
+		for _, record := range records {
+			header := ""
+			if record.Created != nil {
+				header = record.Created.UTC().Format("2006-01-02 15:04")
+			}
+			if record.Author != "" {
+				header += " - " + record.Author
+			}
+			text := strings.TrimSpace(StripMentions(record.Text))
+			fmt.Fprintf(&sb, "\n**%s**\n\n> %s\n", header, strings.ReplaceAll(text, "\n", "\n> "))
+		}
+	}
+	writeRecords("New records", d.NewRecords)
+	writeRecords("Edited records", d.EditedRecords)
+
+	writeEntities := func(title string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+		sb.WriteString("\n## " + title + "\n\n")
+		for _, name := range names {
+			sb.WriteString("- " + name + "\n")
+		}
+	}
+	npcs := []string{}
+	for _, npc := range d.NPCs {
+		npcs = append(npcs, entityLine(npc.Name, npc.Title))
+	}
+	writeEntities("New NPCs", npcs)
+	locations := []string{}
+	for _, location := range d.Locations {
+		locations = append(locations, entityLine(location.Name, location.Title))
+	}
+	writeEntities("New locations", locations)
+
+	quests := []string{}
+	for _, quest := range d.Quests {
+		quests = append(quests, entityLine(quest.Name, quest.Title)+" - "+quest.Change)
+	}
+	for _, task := range d.Tasks {
+		line := fmt.Sprintf("%s: %s", task.QuestName, task.TaskName)
+		if task.Finished {
+			line += " - done"
+		} else if task.Capacity > 0 {
+			line += fmt.Sprintf(" - %d/%d", task.Current, task.Capacity)
+		}
+		quests = append(quests, line)
+	}
+	writeEntities("Quests", quests)
+
+	return sb.String()
+}
+
+func entityLine(name, title string) string {
+	line := "**" + name + "**"
+	if title != "" {
+		line += ", " + title
+	}
+	return line
+}
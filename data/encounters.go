@@ -0,0 +1,750 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"personae-fasti/api/models/reqData"
+	gu "personae-fasti/gewi-utils"
+
+	"github.com/uptrace/bun"
+)
+
+// Types of the encounter changes
+const (
+	CombatantAddedChange   = "combatant.added"
+	CombatantUpdatedChange = "combatant.updated"
+	CombatantRemovedChange = "combatant.removed"
+	TurnChange             = "turn"
+	DamageChange           = "damage"
+	ConditionChange        = "condition"
+	EncounterEndedChange   = "ended"
+)
+
+var (
+	ErrNoSession      = errors.New("no session is started")
+	ErrEncounterEnded = errors.New("encounter is already ended")
+)
+
+// Encounter is a fight of the session. Round is zero until the first turn
+type Encounter struct {
+	bun.BaseModel `bun:"table:encounter"`
+
+	ID int `bun:"id,pk,autoincrement" json:"id"`
+
+	GameID    int      `bun:"game_id,notnull" json:"gameID"`
+	SessionID int      `bun:"session_id,notnull" json:"sessionID"`
+	Session   *Session `bun:"rel:belongs-to,join:session_id=id" json:"-"`
+	Name      string   `bun:"name,notnull,default:''" json:"name"`
+
+	Round  int `bun:"round,notnull,default:0" json:"round"`
+	TurnID int `bun:"turn_id,nullzero" json:"turnID"`
+	// Summary record created at the end
+	RecordID int `bun:"record_id,nullzero" json:"recordID,omitempty"`
+
+	Combatants []Combatant `bun:"rel:has-many,join:id=encounter_id" json:"combatants"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp" json:"created"`
+	Ended   *time.Time `bun:"ended,nullzero" json:"ended"`
+}
+
+// Combatant is a char, an NPC or anyone else in the encounter. Hidden ones
+// are seen by the GM only
+type Combatant struct {
+	bun.BaseModel `bun:"table:encounter_combatant"`
+
+	ID          int    `bun:"id,pk,autoincrement" json:"id"`
+	EncounterID int    `bun:"encounter_id,notnull" json:"encounterID"`
+	CharID      int    `bun:"char_id,nullzero" json:"charID,omitempty"`
+	NPCID       int    `bun:"npc_id,nullzero" json:"npcID,omitempty"`
+	Name        string `bun:"name,notnull" json:"name"`
+
+	Initiative int      `bun:"initiative,notnull,default:0" json:"initiative"`
+	HP         int      `bun:"hp,notnull,default:0" json:"hp"`
+	MaxHP      int      `bun:"max_hp,notnull,default:0" json:"maxHP"`
+	Conditions []string `bun:"conditions,type:jsonb" json:"conditions"`
+	Hidden     bool     `bun:"hidden,notnull,default:false" json:"hidden"`
+
+	// Totals for the summary
+	Damage  int `bun:"damage,notnull,default:0" json:"damage"`
+	Healing int `bun:"healing,notnull,default:0" json:"healing"`
+}
+
+// EncounterChange is polled by the clients. Its ID is the change ID
+type EncounterChange struct {
+	bun.BaseModel `bun:"table:encounter_change"`
+
+	ID          int64          `bun:"id,pk,autoincrement" json:"id"`
+	EncounterID int            `bun:"encounter_id,notnull" json:"encounterID"`
+	Type        string         `bun:"type,notnull" json:"type"`
+	CombatantID int            `bun:"combatant_id,nullzero" json:"combatantID,omitempty"`
+	Data        map[string]any `bun:"data,type:jsonb" json:"data,omitempty"`
+	Hidden      bool           `bun:"hidden,notnull,default:false" json:"-"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp" json:"created"`
+}
+
+type EncounterEventData struct {
+	EncounterID int    `json:"encounterID"`
+	ChangeID    int64  `json:"changeID"`
+	Type        string `json:"type"`
+}
+
+// Defeated combatants are skipped in the turn order. Combatants without
+// tracked HP are never defeated
+func (c *Combatant) Defeated() bool {
+	return c.MaxHP > 0 && c.HP <= 0
+}
+
+// Combatant returns nil if there is no combatant with the ID
+func (e *Encounter) Combatant(id int) *Combatant {
+	for i := range e.Combatants {
+		if e.Combatants[i].ID == id {
+			return &e.Combatants[i]
+		}
+	}
+
+	return nil
+}
+
+// sortCombatants puts the combatants in the turn order: higher initiative
+// first, the one added earlier wins a tie
+func sortCombatants(combatants []Combatant) {
+	sort.SliceStable(combatants, func(i, j int) bool {
+		if combatants[i].Initiative != combatants[j].Initiative {
+			return combatants[i].Initiative > combatants[j].Initiative
+		}
+		return combatants[i].ID < combatants[j].ID
+	})
+}
+
+// nextTurn returns the combatant to act after the current one and the
+// round. Passing the end of the order starts a new round
+func (e *Encounter) nextTurn() (int, int, error) {
+	current := -1
+	for i := range e.Combatants {
+		if e.Combatants[i].ID == e.TurnID {
+			current = i
+			break
+		}
+	}
+
+	count := len(e.Combatants)
+	for step := 1; step <= count; step++ {
+		i := current + step
+		combatant := &e.Combatants[i%count]
+		if combatant.Defeated() {
+			continue
+		}
+
+		if e.Round == 0 {
+			return combatant.ID, 1, nil
+		} else if i >= count {
+			return combatant.ID, e.Round + 1, nil
+		}
+		return combatant.ID, e.Round, nil
+	}
+
+	return 0, 0, fmt.Errorf("encounter has no combatants to take a turn")
+}
+
+func (s *Storage) getEncounterCombatants(ctx context.Context, db bun.IDB, encounters []Encounter) error {
+	ids := []int{}
+	for _, encounter := range encounters {
+		ids = append(ids, encounter.ID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	combatants := []Combatant{}
+	err := db.NewSelect().Model(&combatants).Where("encounter_id IN (?)", bun.In(ids)).Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	sortCombatants(combatants)
+
+	for i := range encounters {
+		encounters[i].Combatants = []Combatant{}
+		for _, combatant := range combatants {
+			if combatant.EncounterID == encounters[i].ID {
+				encounters[i].Combatants = append(encounters[i].Combatants, combatant)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetEncounterByID returns nil if there is no encounter
+func (s *Storage) GetEncounterByID(encounterID int) (*Encounter, error) {
+	encounters := []Encounter{}
+	err := s.db.NewSelect().Model(&encounters).
+		Where("encounter.id = ?", encounterID).
+		Relation("Session").
+		Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	} else if len(encounters) == 0 {
+		return nil, nil
+	}
+
+	if err := s.getEncounterCombatants(context.Background(), s.db, encounters); err != nil {
+		return nil, err
+	}
+
+	return &encounters[0], nil
+}
+
+// GetSessionEncounters returns the encounters of the session, of the
+// current one if the number is zero
+func (s *Storage) GetSessionEncounters(game *Game, sessionNumber int) ([]Encounter, error) {
+	encounters := []Encounter{}
+	query := s.db.NewSelect().Model(&encounters).
+		Where("encounter.game_id = ?", game.ID).
+		Relation("Session").
+		Order("encounter.id ASC")
+
+	if sessionNumber != 0 {
+		query = query.Where("session.number = ?", sessionNumber)
+	} else {
+		session, err := s.currentSession(game)
+		if err != nil {
+			return nil, err
+		} else if session == nil {
+			return encounters, nil
+		}
+		query = query.Where("encounter.session_id = ?", session.ID)
+	}
+
+	err := query.Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if err := s.getEncounterCombatants(context.Background(), s.db, encounters); err != nil {
+		return nil, err
+	}
+
+	return encounters, nil
+}
+
+func (s *Storage) CreateEncounter(encounterCreate *reqData.EncounterCreate, player *Player) (*Encounter, error) {
+	session, err := s.currentSession(player.CurrentGame)
+	if err != nil {
+		return nil, err
+	} else if session == nil {
+		return nil, ErrNoSession
+	}
+
+	encounter := Encounter{
+		GameID:     player.CurrentGameID,
+		SessionID:  session.ID,
+		Session:    session,
+		Name:       strings.TrimSpace(encounterCreate.Name),
+		Combatants: []Combatant{},
+	}
+
+	_, err = s.db.NewInsert().Model(&encounter).Returning("*").Exec(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return &encounter, nil
+}
+
+// changeEncounter runs the update and saves its changes in one transaction,
+// then tells the game clients about them. The encounter row is locked and
+// the encounter is reloaded first, so the update works on the state the
+// concurrent changes left
+func (s *Storage) changeEncounter(encounter *Encounter, player *Player, update func(ctx context.Context, tx bun.Tx) ([]EncounterChange, error)) error {
+	if encounter.Ended != nil {
+		return ErrEncounterEnded
+	}
+
+	var changes []EncounterChange
+	err := s.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.lockEncounter(ctx, tx, encounter); err != nil {
+			return err
+		}
+
+		var err error
+		changes, err = update(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for i := range changes {
+			changes[i].EncounterID = encounter.ID
+		}
+		_, err = tx.NewInsert().Model(&changes).Returning("*").Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to insert encounter changes: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		s.publish(Event{
+			Type:     EncounterChangedEvent,
+			GameID:   encounter.GameID,
+			PlayerID: player.ID,
+			Username: player.Username,
+			HiddenBy: gu.TernaryInt(change.Hidden, player.CurrentGame.GMID, 0),
+			Data: &EncounterEventData{
+				EncounterID: encounter.ID,
+				ChangeID:    change.ID,
+				Type:        change.Type,
+			},
+		})
+	}
+
+	return nil
+}
+
+// lockEncounter selects the encounter for update and puts its current state
+// and combatants in place
+func (s *Storage) lockEncounter(ctx context.Context, tx bun.Tx, encounter *Encounter) error {
+	encounters := []Encounter{}
+	err := tx.NewSelect().Model(&encounters).
+		Where("id = ?", encounter.ID).
+		For("UPDATE").
+		Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	} else if len(encounters) == 0 {
+		return fmt.Errorf("no encounter with id %d", encounter.ID)
+	} else if encounters[0].Ended != nil {
+		return ErrEncounterEnded
+	}
+
+	if err := s.getEncounterCombatants(ctx, tx, encounters); err != nil {
+		return err
+	}
+
+	locked := &encounters[0]
+	encounter.Name = locked.Name
+	encounter.Round = locked.Round
+	encounter.TurnID = locked.TurnID
+	encounter.RecordID = locked.RecordID
+	encounter.Combatants = locked.Combatants
+
+	return nil
+}
+
+// updateRunningEncounter runs the update of the encounter row if it is not
+// ended yet
+func updateRunningEncounter(ctx context.Context, query *bun.UpdateQuery) error {
+	result, err := query.Where("ended IS NULL").Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrEncounterEnded
+	}
+
+	return nil
+}
+
+func (s *Storage) AddCombatant(encounter *Encounter, combatantCreate *reqData.CombatantCreate, player *Player) (*Combatant, error) {
+	combatant := Combatant{
+		EncounterID: encounter.ID,
+		CharID:      combatantCreate.CharID,
+		NPCID:       combatantCreate.NPCID,
+		Name:        strings.TrimSpace(combatantCreate.Name),
+		Initiative:  combatantCreate.Initiative,
+		HP:          combatantCreate.HP,
+		MaxHP:       max(combatantCreate.MaxHP, combatantCreate.HP),
+		Conditions:  []string{},
+		Hidden:      combatantCreate.Hidden,
+	}
+	if combatant.Name == "" {
+		return nil, fmt.Errorf("combatant name is empty")
+	}
+
+	err := s.changeEncounter(encounter, player, func(ctx context.Context, tx bun.Tx) ([]EncounterChange, error) {
+		_, err := tx.NewInsert().Model(&combatant).Returning("*").Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		encounter.Combatants = append(encounter.Combatants, combatant)
+		sortCombatants(encounter.Combatants)
+
+		return []EncounterChange{{
+			Type:        CombatantAddedChange,
+			CombatantID: combatant.ID,
+			Hidden:      combatant.Hidden,
+		}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &combatant, nil
+}
+
+func (s *Storage) UpdateCombatant(encounter *Encounter, combatantUpdate *reqData.CombatantUpdate, player *Player) error {
+	name := strings.TrimSpace(combatantUpdate.Name)
+	if name == "" {
+		return fmt.Errorf("combatant name is empty")
+	}
+
+	return s.changeEncounter(encounter, player, func(ctx context.Context, tx bun.Tx) ([]EncounterChange, error) {
+		combatant := encounter.Combatant(combatantUpdate.ID)
+		if combatant == nil {
+			return nil, fmt.Errorf("no combatant %d in the encounter %d", combatantUpdate.ID, encounter.ID)
+		}
+
+		updated := *combatant
+		updated.Name = name
+		updated.Initiative = combatantUpdate.Initiative
+		updated.HP = combatantUpdate.HP
+		updated.MaxHP = max(combatantUpdate.MaxHP, 0)
+		updated.Hidden = combatantUpdate.Hidden
+
+		_, err := tx.NewUpdate().Model(&updated).
+			Column("name", "initiative", "hp", "max_hp", "hidden").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		change := EncounterChange{
+			Type:        CombatantUpdatedChange,
+			CombatantID: combatant.ID,
+			// Players see a combatant appear when the GM reveals it
+			Hidden: combatant.Hidden && updated.Hidden,
+		}
+		*combatant = updated
+		sortCombatants(encounter.Combatants)
+
+		return []EncounterChange{change}, nil
+	})
+}
+
+func (s *Storage) RemoveCombatant(encounter *Encounter, combatantID int, player *Player) error {
+	return s.changeEncounter(encounter, player, func(ctx context.Context, tx bun.Tx) ([]EncounterChange, error) {
+		combatant := encounter.Combatant(combatantID)
+		if combatant == nil {
+			return nil, fmt.Errorf("no combatant %d in the encounter %d", combatantID, encounter.ID)
+		}
+
+		// The turn of the removed combatant passes to the next one
+		turnID, round := encounter.TurnID, encounter.Round
+		if turnID == combatant.ID && encounter.Round != 0 {
+			var err error
+			if turnID, round, err = encounter.nextTurn(); err != nil || turnID == combatant.ID {
+				turnID = 0
+			}
+		}
+
+		_, err := tx.NewDelete().Model((*Combatant)(nil)).Where("id = ?", combatant.ID).Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		err = updateRunningEncounter(ctx, tx.NewUpdate().Model((*Encounter)(nil)).
+			Set("turn_id = ?", turnID).
+			Set("round = ?", round).
+			Where("id = ?", encounter.ID))
+		if err != nil {
+			return nil, err
+		}
+
+		change := EncounterChange{
+			Type:        CombatantRemovedChange,
+			CombatantID: combatant.ID,
+			Hidden:      combatant.Hidden,
+		}
+		encounter.TurnID, encounter.Round = turnID, round
+		encounter.Combatants = slices.DeleteFunc(encounter.Combatants, func(c Combatant) bool {
+			return c.ID == combatantID
+		})
+
+		return []EncounterChange{change}, nil
+	})
+}
+
+// NextTurn passes the turn to the next combatant that is not defeated.
+// The first call starts the first round
+func (s *Storage) NextTurn(encounter *Encounter, player *Player) error {
+	return s.changeEncounter(encounter, player, func(ctx context.Context, tx bun.Tx) ([]EncounterChange, error) {
+		turnID, round, err := encounter.nextTurn()
+		if err != nil {
+			return nil, err
+		}
+
+		err = updateRunningEncounter(ctx, tx.NewUpdate().Model((*Encounter)(nil)).
+			Set("turn_id = ?", turnID).
+			Set("round = ?", round).
+			Where("id = ?", encounter.ID))
+		if err != nil {
+			return nil, err
+		}
+		encounter.TurnID, encounter.Round = turnID, round
+
+		return []EncounterChange{{
+			Type:        TurnChange,
+			CombatantID: turnID,
+			Data:        map[string]any{"round": round},
+			Hidden:      encounter.Combatant(turnID).Hidden,
+		}}, nil
+	})
+}
+
+// ApplyDamage changes HP of the combatants. Negative amount heals but not
+// over the max HP
+func (s *Storage) ApplyDamage(encounter *Encounter, damage *reqData.EncounterDamage, player *Player) error {
+	if len(damage.CombatantIDs) == 0 {
+		return fmt.Errorf("no combatants to apply damage")
+	} else if damage.Amount == 0 {
+		return fmt.Errorf("damage amount is zero")
+	}
+
+	return s.changeEncounter(encounter, player, func(ctx context.Context, tx bun.Tx) ([]EncounterChange, error) {
+		updated := []Combatant{}
+		changes := []EncounterChange{}
+		for _, id := range damage.CombatantIDs {
+			combatant := encounter.Combatant(id)
+			if combatant == nil {
+				return nil, fmt.Errorf("no combatant %d in the encounter %d", id, encounter.ID)
+			} else if slices.ContainsFunc(updated, func(c Combatant) bool { return c.ID == id }) {
+				continue
+			}
+
+			c := *combatant
+			if damage.Amount > 0 {
+				c.HP = max(c.HP-damage.Amount, 0)
+				c.Damage += damage.Amount
+			} else {
+				c.HP -= damage.Amount
+				if c.MaxHP > 0 {
+					c.HP = min(c.HP, c.MaxHP)
+				}
+				c.Healing -= damage.Amount
+			}
+			updated = append(updated, c)
+
+			changes = append(changes, EncounterChange{
+				Type:        DamageChange,
+				CombatantID: c.ID,
+				Data:        map[string]any{"amount": damage.Amount, "hp": c.HP, "defeated": c.Defeated()},
+				Hidden:      c.Hidden,
+			})
+		}
+
+		for i := range updated {
+			_, err := tx.NewUpdate().Model(&updated[i]).Column("hp", "damage", "healing").WherePK().Exec(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, c := range updated {
+			*encounter.Combatant(c.ID) = c
+		}
+
+		return changes, nil
+	})
+}
+
+// SetCondition adds the condition to the combatant or removes it
+func (s *Storage) SetCondition(encounter *Encounter, condition *reqData.EncounterCondition, player *Player) error {
+	name := strings.TrimSpace(condition.Condition)
+	if name == "" {
+		return fmt.Errorf("condition is empty")
+	}
+
+	return s.changeEncounter(encounter, player, func(ctx context.Context, tx bun.Tx) ([]EncounterChange, error) {
+		combatant := encounter.Combatant(condition.CombatantID)
+		if combatant == nil {
+			return nil, fmt.Errorf("no combatant %d in the encounter %d", condition.CombatantID, encounter.ID)
+		}
+
+		updated := *combatant
+		updated.Conditions = slices.DeleteFunc(slices.Clone(combatant.Conditions), func(c string) bool {
+			return strings.EqualFold(c, name)
+		})
+		if !condition.Remove {
+			updated.Conditions = append(updated.Conditions, name)
+		}
+
+		_, err := tx.NewUpdate().Model(&updated).Column("conditions").WherePK().Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		*combatant = updated
+
+		return []EncounterChange{{
+			Type:        ConditionChange,
+			CombatantID: combatant.ID,
+			Data:        map[string]any{"condition": name, "removed": condition.Remove},
+			Hidden:      combatant.Hidden,
+		}}, nil
+	})
+}
+
+var encounterTexts = map[string]map[string]string{
+	"en": {
+		"title":    "Encounter %q ended after %d rounds.",
+		"untitled": "Encounter ended after %d rounds.",
+		"hp":       "%d/%d HP",
+		"damage":   "took %d damage",
+		"healing":  "healed %d",
+		"defeated": "defeated",
+	},
+	"ru": {
+		"title":    "Бой «%s» окончен, раундов: %d.",
+		"untitled": "Бой окончен, раундов: %d.",
+		"hp":       "%d/%d ОЗ",
+		"damage":   "получено урона: %d",
+		"healing":  "вылечено: %d",
+		"defeated": "повержен",
+	},
+}
+
+// encounterSummary is the text of the record about the ended encounter.
+// Hidden combatants are left out
+func encounterSummary(encounter *Encounter, lang string) string {
+	texts, ok := encounterTexts[lang]
+	if !ok {
+		texts = encounterTexts[DefaultLang]
+	}
+
+	lines := []string{}
+	if encounter.Name != "" {
+		lines = append(lines, fmt.Sprintf(texts["title"], encounter.Name, encounter.Round))
+	} else {
+		lines = append(lines, fmt.Sprintf(texts["untitled"], encounter.Round))
+	}
+
+	for _, c := range encounter.Combatants {
+		if c.Hidden {
+			continue
+		}
+
+		name := strings.ReplaceAll(c.Name, "`", "'")
+		if c.CharID != 0 {
+			name = fmt.Sprintf("@char:%d`%s`", c.CharID, name)
+		} else if c.NPCID != 0 {
+			name = fmt.Sprintf("@npc:%d`%s`", c.NPCID, name)
+		}
+
+		details := []string{}
+		if c.Defeated() {
+			details = append(details, texts["defeated"])
+		} else if c.MaxHP > 0 {
+			details = append(details, fmt.Sprintf(texts["hp"], c.HP, c.MaxHP))
+		}
+		if c.Damage > 0 {
+			details = append(details, fmt.Sprintf(texts["damage"], c.Damage))
+		}
+		if c.Healing > 0 {
+			details = append(details, fmt.Sprintf(texts["healing"], c.Healing))
+		}
+		details = append(details, c.Conditions...)
+
+		line := "- " + name
+		if len(details) > 0 {
+			line += ": " + strings.Join(details, ", ")
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// EndEncounter stops the encounter and adds the summary record on behalf
+// of the player in their language in the same transaction
+func (s *Storage) EndEncounter(encounter *Encounter, player *Player) (*Record, error) {
+	session, err := s.currentSession(player.CurrentGame)
+	if err != nil {
+		return nil, err
+	}
+
+	var record *Record
+	err = s.changeEncounter(encounter, player, func(ctx context.Context, tx bun.Tx) ([]EncounterChange, error) {
+		var err error
+		record, err = s.insertRecordTx(ctx, tx, &reqData.RecordInsert{Text: encounterSummary(encounter, player.Lang())}, player, session)
+		if err != nil {
+			return nil, err
+		}
+
+		ended := time.Now().UTC()
+		err = updateRunningEncounter(ctx, tx.NewUpdate().Model((*Encounter)(nil)).
+			Set("ended = ?", ended).
+			Set("record_id = ?", record.ID).
+			Where("id = ?", encounter.ID))
+		if err != nil {
+			return nil, err
+		}
+		encounter.Ended = &ended
+		encounter.RecordID = record.ID
+
+		return []EncounterChange{{
+			Type: EncounterEndedChange,
+			Data: map[string]any{"recordID": record.ID},
+		}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishRecordCreated(record, player)
+
+	return record, nil
+}
+
+// GetEncounterChanges returns the changes after the change ID and the last
+// change ID of the encounter. Players don't get hidden changes
+func (s *Storage) GetEncounterChanges(encounter *Encounter, since int64, gm bool) ([]EncounterChange, int64, error) {
+	changes := []EncounterChange{}
+	query := s.db.NewSelect().Model(&changes).
+		Where("encounter_id = ?", encounter.ID).
+		Where("id > ?", since).
+		Order("id ASC")
+	if !gm {
+		query = query.Where("hidden = false")
+	}
+
+	err := query.Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, 0, err
+	}
+
+	lastChangeID, err := s.GetEncounterLastChangeID(encounter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return changes, max(lastChangeID, since), nil
+}
+
+// GetEncounterLastChangeID is zero if the encounter has no changes
+func (s *Storage) GetEncounterLastChangeID(encounter *Encounter) (int64, error) {
+	var lastChangeID int64
+	err := s.db.NewSelect().Model((*EncounterChange)(nil)).
+		ColumnExpr("COALESCE(MAX(id), 0)").
+		Where("encounter_id = ?", encounter.ID).
+		Scan(context.Background(), &lastChangeID)
+	if err != nil {
+		return 0, err
+	}
+
+	return lastChangeID, nil
+}
@@ -0,0 +1,186 @@
+package data
+
+import (
+	"slices"
+	"time"
+)
+
+type EventType string
+
+const (
+	RecordCreatedEvent  EventType = "record.created"
+	RecordUpdatedEvent  EventType = "record.updated"
+	RecordDeletedEvent  EventType = "record.deleted"
+	SessionStartedEvent EventType = "session.started"
+	QuestStatusEvent    EventType = "quest.status"
+	EntityRevealedEvent EventType = "entity.revealed"
+
+	// Events for the game clients only
+	EntityCreatedEvent    EventType = "entity.created"
+	EntityUpdatedEvent    EventType = "entity.updated"
+	EntityDeletedEvent    EventType = "entity.deleted"
+	QuestTasksEvent       EventType = "quest.tasks"
+	SessionUpdatedEvent   EventType = "session.updated"
+	RollCreatedEvent      EventType = "roll.created"
+	EncounterChangedEvent EventType = "encounter.changed"
+	CommentCreatedEvent   EventType = "comment.created"
+	CommentUpdatedEvent   EventType = "comment.updated"
+	CommentDeletedEvent   EventType = "comment.deleted"
+	ReactionChangedEvent  EventType = "reaction.changed"
+
+	// Events for the player's clients only
+	NotificationCreatedEvent EventType = "notification.created"
+)
+
+var WebhookEventTypes = []EventType{
+	RecordCreatedEvent,
+	RecordUpdatedEvent,
+	RecordDeletedEvent,
+	SessionStartedEvent,
+	QuestStatusEvent,
+	EntityRevealedEvent,
+}
+
+func IsWebhookEvent(eventType EventType) bool {
+	return slices.Contains(WebhookEventTypes, eventType)
+}
+
+// Event is published by the storage after a change is saved. Events of
+// hidden content have HiddenBy set and are only for that player
+type Event struct {
+	Type     EventType `json:"type"`
+	GameID   int       `json:"gameID"`
+	PlayerID int       `json:"playerID"`
+	Username string    `json:"username,omitempty"`
+	HiddenBy int       `json:"-"`
+	Time     time.Time `json:"time"`
+	Data     any       `json:"data"`
+}
+
+func (e *Event) VisibleTo(playerID int) bool {
+	return e.HiddenBy == 0 || e.HiddenBy == playerID
+}
+
+type RecordEventData struct {
+	ID      int        `json:"id"`
+	Text    string     `json:"text"`
+	Author  int        `json:"authorID"`
+	QuestID int        `json:"questID,omitempty"`
+	Created *time.Time `json:"created,omitempty"`
+	// Text before the update to find the new mentions, not sent to the
+	// clients
+	OldText string `json:"-"`
+}
+
+type SessionEventData struct {
+	Number int    `json:"number"`
+	Name   string `json:"name,omitempty"`
+}
+
+type QuestEventData struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Title      string `json:"title,omitempty"`
+	Status     string `json:"status"`
+	Successful bool   `json:"successful"`
+}
+
+type QuestTasksEventData struct {
+	QuestID int   `json:"questID"`
+	TaskIDs []int `json:"taskIDs"`
+}
+
+type EntityEventData struct {
+	EntityType  string `json:"entityType"`
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Subscribe adds the listener of all events. Listeners are called
+// synchronously and must not block
+func (s *Storage) Subscribe(listener func(Event)) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *Storage) publish(event Event) {
+	event.Time = time.Now().UTC()
+
+	s.listenersMu.RLock()
+	defer s.listenersMu.RUnlock()
+
+	for _, listener := range s.listeners {
+		listener(event)
+	}
+}
+
+func recordEventData(record *Record) *RecordEventData {
+	return &RecordEventData{
+		ID:      record.ID,
+		Text:    record.Text,
+		Author:  record.PlayerID,
+		QuestID: record.QuestID,
+		Created: record.Created,
+	}
+}
+
+// publishRecordUpdate tells about a record change by its visibility before
+// and after: a record hidden from everyone else looks deleted for them,
+// and a record shown to them is revealed
+func (s *Storage) publishRecordUpdate(oldRecord *Record, record *Record, player *Player) {
+	event := Event{
+		GameID:   oldRecord.GameID,
+		PlayerID: player.ID,
+		Username: player.Username,
+		HiddenBy: record.HiddenBy,
+		Data:     recordEventData(record),
+	}
+
+	switch {
+	case oldRecord.HiddenBy == 0 && record.HiddenBy != 0:
+		event.Type = RecordDeletedEvent
+		event.HiddenBy = 0
+		event.Data = &RecordEventData{ID: record.ID}
+	case oldRecord.HiddenBy != 0 && record.HiddenBy == 0:
+		event.Type = EntityRevealedEvent
+		event.Data = &EntityEventData{EntityType: RecordEntity, ID: record.ID, Description: record.Text}
+	default:
+		event.Type = RecordUpdatedEvent
+		event.Data.(*RecordEventData).OldText = oldRecord.Text
+	}
+
+	s.publish(event)
+}
+
+func (s *Storage) publishEntity(eventType EventType, gameID int, player *Player, hiddenBy int, entity *EntityEventData) {
+	s.publish(Event{
+		Type:     eventType,
+		GameID:   gameID,
+		PlayerID: player.ID,
+		Username: player.Username,
+		HiddenBy: hiddenBy,
+		Data:     entity,
+	})
+}
+
+// publishEntityUpdate tells about an entity change by its visibility like
+// publishRecordUpdate. An entity hidden from everyone else is published
+// without its content so the clients reload it
+func (s *Storage) publishEntityUpdate(gameID int, player *Player, oldHiddenBy, hiddenBy int, entity *EntityEventData) {
+	switch {
+	case oldHiddenBy != 0 && hiddenBy == 0:
+		s.publishEntity(EntityRevealedEvent, gameID, player, 0, entity)
+	case oldHiddenBy == 0 && hiddenBy != 0:
+		s.publishEntity(EntityUpdatedEvent, gameID, player, 0, &EntityEventData{EntityType: entity.EntityType, ID: entity.ID})
+	case oldHiddenBy != hiddenBy:
+		// A hidden entity given to another player is gone for the old one
+		s.publishEntity(EntityDeletedEvent, gameID, player, oldHiddenBy, &EntityEventData{EntityType: entity.EntityType, ID: entity.ID})
+		s.publishEntity(EntityUpdatedEvent, gameID, player, hiddenBy, entity)
+	default:
+		s.publishEntity(EntityUpdatedEvent, gameID, player, hiddenBy, entity)
+	}
+}
@@ -0,0 +1,425 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"personae-fasti/api/models/reqData"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	CharEntity     = "char"
+	NPCEntity      = "npc"
+	LocationEntity = "location"
+	ItemEntity     = "item"
+	FactionEntity  = "faction"
+	QuestEntity    = "quest"
+	RecordEntity   = "record"
+)
+
+type CustomFieldType string
+
+const (
+	TextField      CustomFieldType = "text"
+	NumberField    CustomFieldType = "number"
+	EnumField      CustomFieldType = "enum"
+	BooleanField   CustomFieldType = "boolean"
+	ReferenceField CustomFieldType = "reference"
+)
+
+// CustomField is a GM defined field of an entity type schema
+type CustomField struct {
+	bun.BaseModel `bun:"table:custom_field"`
+
+	ID int `bun:"id,pk,autoincrement"`
+
+	// Keys are unique among the fields not deleted, see InitTables
+	GameID     int    `bun:"game_id,notnull"`
+	Game       *Game  `bun:"rel:belongs-to,join:game_id=id"`
+	EntityType string `bun:"entity_type,notnull"`
+	Key        string `bun:"key,notnull"`
+
+	Name     string          `bun:"name,notnull"`
+	Type     CustomFieldType `bun:"type,notnull"`
+	Options  []string        `bun:"options,type:jsonb"`
+	RefType  string          `bun:"ref_type,notnull,default:''"`
+	Required bool            `bun:"required,default:false"`
+	Order    int             `bun:"sort_order,default:0"`
+	Version  int             `bun:"version,notnull,default:1"`
+
+	Deleted *time.Time `bun:"deleted,default:null"`
+}
+
+// CustomFieldValue keeps values as text, typed values are restored by the field type
+type CustomFieldValue struct {
+	bun.BaseModel `bun:"table:custom_field_value"`
+
+	FieldID    int          `bun:"field_id,pk"`
+	Field      *CustomField `bun:"rel:belongs-to,join:field_id=id"`
+	EntityID   int          `bun:"entity_id,pk"`
+	EntityType string       `bun:"entity_type,notnull"`
+	GameID     int          `bun:"game_id,notnull"`
+
+	Value string `bun:"value,notnull"`
+}
+
+var customFieldEntities = []string{CharEntity, NPCEntity, LocationEntity}
+
+var referenceEntities = map[string]any{
+	CharEntity:     (*Char)(nil),
+	NPCEntity:      (*NPC)(nil),
+	LocationEntity: (*Location)(nil),
+	ItemEntity:     (*Item)(nil),
+	FactionEntity:  (*Faction)(nil),
+}
+
+func (s *Storage) GetGameCustomFields(gameID int, entityType string) ([]CustomField, error) {
+	fields := []CustomField{}
+
+	q := s.db.NewSelect().Model(&fields).Where("game_id = ? AND deleted IS NULL", gameID)
+	if entityType != "" {
+		q = q.Where("entity_type = ?", entityType)
+	}
+
+	err := q.Order("entity_type ASC", "sort_order ASC", "id ASC").Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func (s *Storage) GetCustomFieldByID(fieldID int) (*CustomField, error) {
+	field := CustomField{ID: fieldID}
+
+	err := s.db.NewSelect().Model(&field).WherePK().Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &field, nil
+}
+
+func (s *Storage) CreateCustomField(fieldCreate *reqData.CustomFieldCreate, game *Game) (*CustomField, error) {
+	field := CustomField{
+		GameID:     game.ID,
+		EntityType: fieldCreate.EntityType,
+		Key:        fieldCreate.Key,
+		Name:       fieldCreate.Name,
+		Type:       CustomFieldType(fieldCreate.Type),
+		Options:    fieldCreate.Options,
+		RefType:    fieldCreate.RefType,
+		Required:   fieldCreate.Required,
+		Order:      fieldCreate.Order,
+	}
+
+	if err := field.validateSchema(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.db.NewInsert().Model(&field).Returning("*").Exec(context.Background())
+
+	return &field, err
+}
+
+// UpdateCustomField keeps key, entity and value type so stored values stay valid
+func (s *Storage) UpdateCustomField(fieldUpdate *reqData.CustomFieldUpdate, field *CustomField) (*CustomField, error) {
+	field.Name = fieldUpdate.Name
+	field.Options = fieldUpdate.Options
+	field.Required = fieldUpdate.Required
+	field.Order = fieldUpdate.Order
+
+	if err := field.validateSchema(); err != nil {
+		return nil, err
+	}
+
+	result, err := updateVersion(s.db.NewUpdate().Model(field).WherePK().
+		Set("name = ?", field.Name).
+		Set("options = ?", field.Options).
+		Set("required = ?", field.Required).
+		Set("sort_order = ?", field.Order), fieldUpdate.Version).
+		Returning("version").Exec(context.Background())
+	if err == nil {
+		err = checkVersion(result)
+	}
+
+	return field, err
+}
+
+func (s *Storage) DeleteCustomField(field *CustomField) error {
+	now := time.Now().UTC()
+	field.Deleted = &now
+
+	ctx := context.Background()
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().Model(field).Column("deleted").WherePK().Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewDelete().Model((*CustomFieldValue)(nil)).Where("field_id = ?", field.ID).Exec(ctx)
+		return err
+	})
+}
+
+func (f *CustomField) validateSchema() error {
+	if !slices.Contains(customFieldEntities, f.EntityType) {
+		return fmt.Errorf("custom fields are not supported for %q", f.EntityType)
+	}
+	if f.Key == "" || f.Name == "" {
+		return fmt.Errorf("custom field must have a key and a name")
+	}
+
+	switch f.Type {
+	case TextField, NumberField, BooleanField:
+	case EnumField:
+		if len(f.Options) == 0 {
+			return fmt.Errorf("enum field %q must have options", f.Key)
+		}
+	case ReferenceField:
+		if _, ok := referenceEntities[f.RefType]; !ok {
+			return fmt.Errorf("reference field %q has unknown entity type %q", f.Key, f.RefType)
+		}
+	default:
+		return fmt.Errorf("unknown custom field type %q", f.Type)
+	}
+
+	if f.Options == nil {
+		f.Options = []string{}
+	}
+
+	return nil
+}
+
+// normalizeValue checks JSON value against the field and converts it to the
+// stored text. A reference must point to an entity the player sees
+func (s *Storage) normalizeValue(f *CustomField, value any, player *Player) (string, error) {
+	switch f.Type {
+	case TextField:
+		if text, ok := value.(string); ok {
+			return text, nil
+		}
+	case NumberField:
+		if number, ok := value.(float64); ok {
+			return strconv.FormatFloat(number, 'f', -1, 64), nil
+		}
+	case BooleanField:
+		if flag, ok := value.(bool); ok {
+			return strconv.FormatBool(flag), nil
+		}
+	case EnumField:
+		if option, ok := value.(string); ok {
+			if !slices.Contains(f.Options, option) {
+				return "", fmt.Errorf("field %q value %q is not one of %v", f.Key, option, f.Options)
+			}
+			return option, nil
+		}
+	case ReferenceField:
+		if number, ok := value.(float64); ok && number == float64(int(number)) {
+			exists, err := s.db.NewSelect().Model(referenceEntities[f.RefType]).
+				Where("id = ? AND game_id = ? AND deleted IS NULL", int(number), f.GameID).
+				Where("hidden_by IN (0, ?)", player.ID).
+				Exists(context.Background())
+			if err != nil {
+				return "", err
+			} else if !exists {
+				return "", fmt.Errorf("field %q refers to missing %s %d", f.Key, f.RefType, int(number))
+			}
+			return strconv.Itoa(int(number)), nil
+		}
+	}
+
+	return "", fmt.Errorf("field %q expects a %s value", f.Key, f.Type)
+}
+
+// ParseValue restores typed value of the stored text
+func (f *CustomField) ParseValue(value string) any {
+	switch f.Type {
+	case NumberField:
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return number
+		}
+	case BooleanField:
+		if flag, err := strconv.ParseBool(value); err == nil {
+			return flag
+		}
+	case ReferenceField:
+		if id, err := strconv.Atoi(value); err == nil {
+			return id
+		}
+	}
+
+	return value
+}
+
+// ValidateEntityFields checks values before an entity is saved. On create every
+// required field must be present, on update omitted fields stay untouched
+func (s *Storage) ValidateEntityFields(gameID int, entityType string, values map[string]any, create bool, player *Player) (map[int]*string, error) {
+	fields, err := s.GetGameCustomFields(gameID, entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := map[int]*string{}
+	known := map[string]bool{}
+	for i := range fields {
+		field := &fields[i]
+		known[field.Key] = true
+
+		value, present := values[field.Key]
+		if !present || value == nil {
+			if field.Required && (create || present) {
+				return nil, fmt.Errorf("field %q is required", field.Key)
+			}
+			if present {
+				normalized[field.ID] = nil
+			}
+			continue
+		}
+
+		text, err := s.normalizeValue(field, value, player)
+		if err != nil {
+			return nil, err
+		}
+		normalized[field.ID] = &text
+	}
+
+	for key := range values {
+		if !known[key] {
+			return nil, fmt.Errorf("unknown %s field %q", entityType, key)
+		}
+	}
+
+	return normalized, nil
+}
+
+// SetEntityFields stores values validated by ValidateEntityFields, nil value removes it
+func (s *Storage) SetEntityFields(gameID int, entityType string, entityID int, values map[int]*string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		for fieldID, value := range values {
+			if value == nil {
+				_, err := tx.NewDelete().Model((*CustomFieldValue)(nil)).
+					Where("field_id = ? AND entity_id = ?", fieldID, entityID).
+					Exec(ctx)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			fieldValue := CustomFieldValue{
+				FieldID:    fieldID,
+				EntityID:   entityID,
+				EntityType: entityType,
+				GameID:     gameID,
+				Value:      *value,
+			}
+			_, err := tx.NewInsert().Model(&fieldValue).
+				On("CONFLICT (field_id, entity_id) DO UPDATE").
+				Set("value = EXCLUDED.value").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetEntityFields returns typed values by field key skipping the references
+// to the entities hidden from the player
+func (s *Storage) GetEntityFields(entityType string, entityID int, player *Player) (map[string]any, error) {
+	var values []CustomFieldValue
+	err := s.db.NewSelect().Model(&values).
+		Relation("Field").
+		Where("custom_field_value.entity_type = ? AND custom_field_value.entity_id = ?", entityType, entityID).
+		Where("field.deleted IS NULL").
+		Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	fields := map[string]any{}
+	for _, value := range values {
+		parsed := value.Field.ParseValue(value.Value)
+		if id, ok := parsed.(int); ok && value.Field.Type == ReferenceField {
+			visible, err := s.db.NewSelect().Model(referenceEntities[value.Field.RefType]).
+				Where("id = ? AND deleted IS NULL AND hidden_by IN (0, ?)", id, player.ID).
+				Exists(context.Background())
+			if err != nil {
+				return nil, err
+			} else if !visible {
+				continue
+			}
+		}
+		fields[value.Field.Key] = parsed
+	}
+
+	return fields, nil
+}
+
+// FilterEntitiesByFields returns IDs of entities whose fields equal every filter value
+func (s *Storage) FilterEntitiesByFields(gameID int, entityType string, filters map[string]string) ([]int, error) {
+	fields, err := s.GetGameCustomFields(gameID, entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldsByKey := map[string]*CustomField{}
+	for i := range fields {
+		fieldsByKey[fields[i].Key] = &fields[i]
+	}
+
+	values := map[int]string{}
+	for key, value := range filters {
+		field, ok := fieldsByKey[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown %s field %q", entityType, key)
+		}
+		// Stored numbers and flags are canonical, so are the filters
+		switch parsed := field.ParseValue(value).(type) {
+		case float64:
+			value = strconv.FormatFloat(parsed, 'f', -1, 64)
+		case bool:
+			value = strconv.FormatBool(parsed)
+		}
+		values[field.ID] = value
+	}
+
+	ids := []int{}
+	if len(values) == 0 {
+		return ids, nil
+	}
+
+	err = s.db.NewSelect().Model((*CustomFieldValue)(nil)).
+		Column("entity_id").
+		Where("game_id = ? AND entity_type = ?", gameID, entityType).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			for fieldID, value := range values {
+				q = q.WhereOr("(field_id = ? AND value = ?)", fieldID, value)
+			}
+			return q
+		}).
+		Group("entity_id").
+		Having("COUNT(DISTINCT field_id) = ?", len(values)).
+		Scan(context.Background(), &ids)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return ids, nil
+}
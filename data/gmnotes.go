@@ -0,0 +1,182 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const MaxGMNoteLength = 8000
+
+// GMNote is a secret of the GM about an entity. Notes live apart from the
+// entities, so the player queries, exports and suggestions never see them
+type GMNote struct {
+	bun.BaseModel `bun:"table:gm_note"`
+
+	ID int `bun:"id,pk,autoincrement" json:"id"`
+
+	GameID     int    `bun:"game_id,notnull" json:"gameID"`
+	EntityType string `bun:"entity_type,notnull" json:"entityType"`
+	EntityID   int    `bun:"entity_id,notnull" json:"entityID"`
+	Text       string `bun:"text,notnull" json:"text"`
+	Version    int    `bun:"version,notnull,default:1" json:"version"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp" json:"created"`
+	Updated *time.Time `bun:"updated,nullzero,notnull,default:current_timestamp" json:"updated"`
+	Deleted *time.Time `bun:"deleted,default:null" json:"-"`
+}
+
+var gmNoteEntities = map[string]any{
+	CharEntity:     (*Char)(nil),
+	NPCEntity:      (*NPC)(nil),
+	LocationEntity: (*Location)(nil),
+	QuestEntity:    (*Quest)(nil),
+	RecordEntity:   (*Record)(nil),
+}
+
+// IsGM is true if the player masters the current game
+func (p *Player) IsGM() bool {
+	return p.CurrentGame != nil && p.CurrentGame.GMID == p.ID
+}
+
+func validateGMNoteText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("note text is empty")
+	} else if len(text) > MaxGMNoteLength {
+		return "", fmt.Errorf("note is longer than %d bytes", MaxGMNoteLength)
+	}
+
+	return text, nil
+}
+
+// GetEntityGMNotes returns the notes of the entity, the oldest first
+func (s *Storage) GetEntityGMNotes(entityType string, entityID int) ([]GMNote, error) {
+	notes := []GMNote{}
+	err := s.db.NewSelect().Model(&notes).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Where("deleted IS NULL").
+		Order("id ASC").
+		Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+// FillRecordGMNotes sets the notes of every record in place if the
+// player is the GM of the records' game
+func (s *Storage) FillRecordGMNotes(records []Record, player *Player) error {
+	if len(records) == 0 || !player.IsGM() {
+		return nil
+	}
+	ids := make([]int, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+
+	notes := []GMNote{}
+	err := s.db.NewSelect().Model(&notes).
+		Where("entity_type = ? AND entity_id IN (?)", RecordEntity, bun.In(ids)).
+		Where("game_id = ?", player.CurrentGame.ID).
+		Where("deleted IS NULL").
+		Order("id ASC").
+		Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	for i := range records {
+		for _, note := range notes {
+			if note.EntityID == records[i].ID {
+				records[i].GMNotes = append(records[i].GMNotes, note)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetGMNoteByID returns nil if there is no note
+func (s *Storage) GetGMNoteByID(noteID int) (*GMNote, error) {
+	var note GMNote
+	err := s.db.NewSelect().Model(&note).
+		Where("id = ? AND deleted IS NULL", noteID).
+		Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+// CreateGMNote attaches the note to the entity of the game
+func (s *Storage) CreateGMNote(game *Game, entityType string, entityID int, text string) (*GMNote, error) {
+	model, ok := gmNoteEntities[entityType]
+	if !ok {
+		return nil, fmt.Errorf("notes cannot be attached to %q", entityType)
+	}
+	text, err := validateGMNoteText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.db.NewSelect().Model(model).
+		Where("id = ? AND game_id = ? AND deleted IS NULL", entityID, game.ID).
+		Exists(context.Background())
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, fmt.Errorf("no %s with id %d in the game %d", entityType, entityID, game.ID)
+	}
+
+	note := &GMNote{
+		GameID:     game.ID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Text:       text,
+	}
+	_, err = s.db.NewInsert().Model(note).Returning("*").Exec(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+func (s *Storage) UpdateGMNote(note *GMNote, text string, version int) (*GMNote, error) {
+	text, err := validateGMNoteText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	note.Text = text
+	result, err := updateVersion(s.db.NewUpdate().Model(note).WherePK().
+		Set("text = ?", note.Text).
+		Set("updated = current_timestamp"), version).
+		Returning("*").
+		Exec(context.Background())
+	if err == nil {
+		err = checkVersion(result)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+func (s *Storage) DeleteGMNote(note *GMNote) error {
+	_, err := s.db.NewUpdate().Model(note).
+		Set("deleted = current_timestamp").
+		WherePK().
+		Exec(context.Background())
+	return err
+}
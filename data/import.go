@@ -0,0 +1,801 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/uptrace/bun"
+)
+
+var ErrArchiveVersion = errors.New("unsupported archive version")
+
+// ImportConflict is a problem found in the archive. Conflicting references
+// are dropped on import, unmatched players are replaced with the importer
+type ImportConflict struct {
+	Type    string `json:"type"`
+	ID      int    `json:"id,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportReport struct {
+	DryRun    bool             `json:"dryRun"`
+	GameID    int              `json:"gameID,omitempty"`
+	Name      string           `json:"name"`
+	Counts    map[string]int   `json:"counts"`
+	Players   map[string]int   `json:"players"`
+	Conflicts []ImportConflict `json:"conflicts"`
+
+	// IDs maps archive IDs to the created ones by entity type
+	IDs map[string]map[int]int `json:"-"`
+}
+
+// gameImport keeps the state shared by archive checks and inserts
+type gameImport struct {
+	archive *GameArchive
+	player  *Player
+	report  *ImportReport
+
+	// known archive IDs by entity type
+	known   map[string]map[int]bool
+	players map[string]int
+	fields  map[int]ArchiveField
+}
+
+func (i *gameImport) conflict(entityType string, id int, format string, args ...any) {
+	i.report.Conflicts = append(i.report.Conflicts, ImportConflict{
+		Type:    entityType,
+		ID:      id,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (i *gameImport) exists(entityType string, id int) bool {
+	return i.known[entityType][id]
+}
+
+// playerID maps archive username to a player, other players become the importer
+func (i *gameImport) playerID(username string) int {
+	if username == "" {
+		return 0
+	}
+	if id, ok := i.players[username]; ok {
+		return id
+	}
+
+	return i.player.ID
+}
+
+// newID returns the created ID of an archive entity or 0 if it was not imported
+func (i *gameImport) newID(entityType string, id int) int {
+	return i.report.IDs[entityType][id]
+}
+
+// ImportGame creates a new game of the archive with the player as GM.
+// On dry run only the report of the archive checks is returned
+func (s *Storage) ImportGame(archive *GameArchive, name string, player *Player, dryRun bool) (*ImportReport, error) {
+	if archive.Version < 1 || archive.Version > ArchiveVersion {
+		return nil, fmt.Errorf("%w %d", ErrArchiveVersion, archive.Version)
+	}
+
+	if name == "" {
+		name = archive.Game.Name
+	}
+
+	imp := &gameImport{
+		archive: archive,
+		player:  player,
+		report: &ImportReport{
+			DryRun:    dryRun,
+			Name:      name,
+			Counts:    map[string]int{},
+			Players:   map[string]int{},
+			Conflicts: []ImportConflict{},
+			IDs:       map[string]map[int]int{},
+		},
+		known:   map[string]map[int]bool{},
+		players: map[string]int{},
+		fields:  map[int]ArchiveField{},
+	}
+
+	usernames := []string{}
+	for _, archivePlayer := range archive.Players {
+		usernames = append(usernames, archivePlayer.Username)
+	}
+	fellows, err := s.getFellowPlayers(player, usernames)
+	if err != nil {
+		return nil, err
+	}
+	imp.checkPlayers(fellows)
+
+	games, err := s.GetPlayerGames(player)
+	if err != nil {
+		return nil, err
+	}
+	for _, game := range games {
+		if game.Name == name && game.GMID == player.ID {
+			imp.conflict("game", game.ID, "you already lead a game named %q", name)
+		}
+	}
+
+	imp.checkArchive()
+
+	if dryRun {
+		return imp.report, nil
+	}
+
+	err = s.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		return s.insertImport(ctx, tx, imp)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return imp.report, nil
+}
+
+// checkPlayers maps the archive GM and the importer to the importer and
+// other archive players to the accounts of the same username who already
+// share a game with the importer. A hand-written archive cannot add
+// strangers to the game or credit them with text they never wrote, the
+// content of the unmatched players goes to the importer
+func (i *gameImport) checkPlayers(fellows map[string]int) {
+	if i.archive.Game.GM != "" {
+		i.players[i.archive.Game.GM] = i.player.ID
+	}
+	i.players[i.player.Username] = i.player.ID
+
+	reported := map[string]bool{}
+	for _, archivePlayer := range i.archive.Players {
+		username := archivePlayer.Username
+		if _, ok := i.players[username]; ok || reported[username] {
+			continue
+		}
+		if id, ok := fellows[username]; ok {
+			i.players[username] = id
+			continue
+		}
+		reported[username] = true
+		i.conflict("player", 0, "player %q does not share a game with you, their content goes to %q", username, i.player.Username)
+	}
+
+	for username, id := range i.players {
+		i.report.Players[username] = id
+	}
+}
+
+// getFellowPlayers returns the IDs of the players by username who share a
+// game with the player
+func (s *Storage) getFellowPlayers(player *Player, usernames []string) (map[string]int, error) {
+	fellows := map[string]int{}
+	if len(usernames) == 0 {
+		return fellows, nil
+	}
+
+	players := []Player{}
+	err := s.db.NewSelect().Model(&players).
+		Where("player.username IN (?)", bun.In(usernames)).
+		Where("player.deleted IS NULL").
+		Where(`EXISTS (
+			SELECT 1 FROM players_games AS theirs
+			JOIN players_games AS mine ON mine.game_id = theirs.game_id
+			WHERE theirs.player_id = player.id AND mine.player_id = ?
+		)`, player.ID).
+		Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	for _, fellow := range players {
+		fellows[fellow.Username] = fellow.ID
+	}
+
+	return fellows, nil
+}
+
+// checkArchive collects archive IDs and reports broken references
+func (i *gameImport) checkArchive() {
+	archive := i.archive
+
+	collect := func(entityType string, id int) {
+		if i.known[entityType] == nil {
+			i.known[entityType] = map[int]bool{}
+		}
+		if i.known[entityType][id] {
+			i.conflict(entityType, id, "duplicated %s id %d, only the first one is imported", entityType, id)
+		}
+		i.known[entityType][id] = true
+		i.report.Counts[entityType]++
+	}
+
+	for _, session := range archive.Sessions {
+		collect("session", session.ID)
+	}
+	for _, char := range archive.Chars {
+		collect(CharEntity, char.ID)
+	}
+	for _, npc := range archive.NPCs {
+		collect(NPCEntity, npc.ID)
+	}
+	for _, location := range archive.Locations {
+		collect(LocationEntity, location.ID)
+	}
+	for _, item := range archive.Items {
+		collect(ItemEntity, item.ID)
+	}
+	for _, faction := range archive.Factions {
+		collect(FactionEntity, faction.ID)
+	}
+	for _, quest := range archive.Quests {
+		collect(QuestEntity, quest.ID)
+		for _, task := range quest.Tasks {
+			collect("task", task.ID)
+		}
+	}
+	for _, record := range archive.Records {
+		collect(RecordEntity, record.ID)
+	}
+	for _, event := range archive.Events {
+		collect("event", event.ID)
+	}
+	for _, field := range archive.Fields {
+		collect("field", field.ID)
+		i.fields[field.ID] = field
+	}
+
+	if template := archive.Game.Settings.SheetTemplate; template != "" && GetSheetTemplate(template) == nil {
+		i.conflict("settings", 0, "unknown sheet template %q is replaced with %q", template, DefaultSheetTemplate)
+	}
+
+	calendar := archive.Game.Calendar
+	if calendar != nil {
+		if err := calendar.Validate(); err != nil {
+			i.conflict("calendar", 0, "calendar is invalid and is replaced with the default one: %v", err)
+			archive.Game.Calendar = nil
+		}
+	}
+
+	for _, location := range archive.Locations {
+		if location.ParentID != 0 && !i.exists(LocationEntity, location.ParentID) {
+			i.conflict(LocationEntity, location.ID, "parent location %d is missing", location.ParentID)
+		}
+	}
+
+	for _, item := range archive.Items {
+		ownerType, ok := itemOwnerEntities[item.OwnerType]
+		if !ok {
+			i.conflict(ItemEntity, item.ID, "unknown owner type %d, the item goes to the party stash", item.OwnerType)
+		} else if ownerType != "" && !i.exists(ownerType, item.OwnerID) {
+			i.conflict(ItemEntity, item.ID, "owner %s %d is missing, the item goes to the party stash", ownerType, item.OwnerID)
+		}
+	}
+
+	for _, faction := range archive.Factions {
+		for _, member := range faction.Members {
+			if member.CharID != 0 && !i.exists(CharEntity, member.CharID) {
+				i.conflict(FactionEntity, faction.ID, "member char %d is missing", member.CharID)
+			} else if member.NPCID != 0 && !i.exists(NPCEntity, member.NPCID) {
+				i.conflict(FactionEntity, faction.ID, "member npc %d is missing", member.NPCID)
+			}
+		}
+	}
+
+	for _, quest := range archive.Quests {
+		for _, linked := range []int{quest.ParentID, quest.ChildID, quest.HeadID} {
+			if linked != 0 && !i.exists(QuestEntity, linked) {
+				i.conflict(QuestEntity, quest.ID, "linked quest %d is missing", linked)
+			}
+		}
+		for _, reward := range quest.Rewards {
+			for _, charID := range reward.CharIDs {
+				if !i.exists(CharEntity, charID) {
+					i.conflict(QuestEntity, quest.ID, "reward %q recipient char %d is missing", reward.Name, charID)
+				}
+			}
+		}
+	}
+
+	for _, record := range archive.Records {
+		if record.QuestID != 0 && !i.exists(QuestEntity, record.QuestID) {
+			i.conflict(RecordEntity, record.ID, "quest %d is missing", record.QuestID)
+		}
+
+		for _, match := range mentionRegexp.FindAllStringSubmatch(record.Text, -1) {
+			id, err := strconv.Atoi(match[2])
+			if err != nil || !i.exists(match[1], id) {
+				i.conflict(RecordEntity, record.ID, "mention %s refers to a missing entity and becomes plain text", match[0])
+			}
+		}
+	}
+
+	for _, mention := range archive.Mentions {
+		if !i.exists(RecordEntity, mention.RecordID) || !i.exists(mention.Type, mention.ID) {
+			i.conflict(RecordEntity, mention.RecordID, "mention link to %s %d is broken", mention.Type, mention.ID)
+		}
+	}
+
+	for _, value := range archive.Values {
+		field, ok := i.fields[value.FieldID]
+		if !ok {
+			i.conflict("field", value.FieldID, "value of missing field %d", value.FieldID)
+		} else if !i.exists(field.EntityType, value.EntityID) {
+			i.conflict("field", value.FieldID, "value of field %q belongs to missing %s %d", field.Key, field.EntityType, value.EntityID)
+		} else if refID, err := strconv.Atoi(value.Value); field.Type == ReferenceField && (err != nil || !i.exists(field.RefType, refID)) {
+			i.conflict("field", value.FieldID, "field %q refers to missing %s %s", field.Key, field.RefType, value.Value)
+		}
+	}
+
+	for _, entityTag := range archive.Tags {
+		if !i.exists(entityTag.EntityType, entityTag.EntityID) {
+			i.conflict("tag", 0, "tag %q belongs to missing %s %d", entityTag.Tag, entityTag.EntityType, entityTag.EntityID)
+		}
+	}
+}
+
+var itemOwnerEntities = map[ItemOwnerType]string{
+	PartyStash:    "",
+	CharOwner:     CharEntity,
+	NPCOwner:      NPCEntity,
+	LocationOwner: LocationEntity,
+}
+
+func (i *gameImport) mapID(entityType string, oldID, newID int) {
+	if i.report.IDs[entityType] == nil {
+		i.report.IDs[entityType] = map[int]int{}
+	}
+	i.report.IDs[entityType][oldID] = newID
+}
+
+// rewriteMentions points record mentions to the imported entities
+func (i *gameImport) rewriteMentions(text string) string {
+	return mentionRegexp.ReplaceAllStringFunc(text, func(mention string) string {
+		match := mentionRegexp.FindStringSubmatch(mention)
+		id, _ := strconv.Atoi(match[2])
+		if newID := i.newID(match[1], id); newID != 0 {
+			return fmt.Sprintf("@%s:%d`%s`", match[1], newID, match[3])
+		}
+
+		return match[3]
+	})
+}
+
+func (s *Storage) insertImport(ctx context.Context, tx bun.Tx, imp *gameImport) error {
+	archive := imp.archive
+	hidden := imp.playerID
+
+	game := Game{Name: imp.report.Name, GMID: imp.player.ID}
+	_, err := tx.NewInsert().Model(&game).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create game: %w", err)
+	}
+	imp.report.GameID = game.ID
+
+	settings := GameSettings{
+		GameID:              game.ID,
+		AllowAllEditRecords: archive.Game.Settings.AllowAllEditRecords,
+		SheetTemplate:       archive.Game.Settings.SheetTemplate,
+	}
+	if GetSheetTemplate(settings.SheetTemplate) == nil {
+		settings.SheetTemplate = DefaultSheetTemplate
+	}
+	_, err = tx.NewInsert().Model(&settings).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create game settings: %w", err)
+	}
+
+	if archive.Game.Calendar != nil {
+		calendar := *archive.Game.Calendar
+		calendar.GameID = game.ID
+		_, err = tx.NewInsert().Model(&calendar).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create calendar: %w", err)
+		}
+	}
+
+	playerIDs := []int{}
+	for _, id := range imp.players {
+		if !slices.Contains(playerIDs, id) {
+			playerIDs = append(playerIDs, id)
+		}
+	}
+	playerGames := []PlayerGame{}
+	for _, id := range playerIDs {
+		playerGames = append(playerGames, PlayerGame{PlayerID: id, GameID: game.ID})
+	}
+	_, err = tx.NewInsert().Model(&playerGames).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add players: %w", err)
+	}
+
+	for _, archiveSession := range archive.Sessions {
+		session := Session{
+			GameID:     game.ID,
+			Number:     archiveSession.Number,
+			Name:       archiveSession.Name,
+			WorldStart: archiveSession.WorldStart,
+			WorldEnd:   archiveSession.WorldEnd,
+			StartTime:  archiveSession.StartTime,
+			EndTime:    archiveSession.EndTime,
+		}
+		if _, err = tx.NewInsert().Model(&session).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to import session %d: %w", archiveSession.ID, err)
+		}
+		imp.mapID("session", archiveSession.ID, session.ID)
+	}
+
+	for _, archiveChar := range archive.Chars {
+		if imp.newID(CharEntity, archiveChar.ID) != 0 {
+			continue
+		}
+		status := archiveChar.Status
+		if !status.Valid() {
+			status = CharActive
+		}
+		char := Char{
+			Name:        archiveChar.Name,
+			Title:       archiveChar.Title,
+			Description: archiveChar.Description,
+			PlayerID:    imp.playerID(archiveChar.Player),
+			GameID:      game.ID,
+			HiddenBy:    hidden(archiveChar.HiddenBy),
+			Status:      status,
+			LeftTime:    archiveChar.LeftTime,
+			LeftSession: archiveChar.LeftSession,
+			Created:     archiveChar.Created,
+		}
+		if _, err = tx.NewInsert().Model(&char).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to import char %d: %w", archiveChar.ID, err)
+		}
+		imp.mapID(CharEntity, archiveChar.ID, char.ID)
+	}
+
+	for _, archiveNPC := range archive.NPCs {
+		if imp.newID(NPCEntity, archiveNPC.ID) != 0 {
+			continue
+		}
+		npc := NPC{
+			Name:        archiveNPC.Name,
+			Title:       archiveNPC.Title,
+			Description: archiveNPC.Description,
+			GameID:      game.ID,
+			CreatedByID: imp.playerID(archiveNPC.CreatedBy),
+			HiddenBy:    hidden(archiveNPC.HiddenBy),
+			Created:     archiveNPC.Created,
+		}
+		if _, err = tx.NewInsert().Model(&npc).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to import npc %d: %w", archiveNPC.ID, err)
+		}
+		imp.mapID(NPCEntity, archiveNPC.ID, npc.ID)
+	}
+
+	// Parents are set after all the locations get their new IDs
+	locations := []Location{}
+	for _, archiveLocation := range archive.Locations {
+		if imp.newID(LocationEntity, archiveLocation.ID) != 0 {
+			continue
+		}
+		location := Location{
+			Name:        archiveLocation.Name,
+			Title:       archiveLocation.Title,
+			Description: archiveLocation.Description,
+			GameID:      game.ID,
+			CreatedByID: imp.playerID(archiveLocation.CreatedBy),
+			HiddenBy:    hidden(archiveLocation.HiddenBy),
+			Created:     archiveLocation.Created,
+		}
+		if _, err = tx.NewInsert().Model(&location).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to import location %d: %w", archiveLocation.ID, err)
+		}
+		imp.mapID(LocationEntity, archiveLocation.ID, location.ID)
+		location.ParentID = archiveLocation.ParentID
+		locations = append(locations, location)
+	}
+	for _, location := range locations {
+		if location.ParentID == 0 {
+			continue
+		}
+		location.ParentID = imp.newID(LocationEntity, location.ParentID)
+		if _, err = tx.NewUpdate().Model(&location).Column("pid").WherePK().Exec(ctx); err != nil {
+			return fmt.Errorf("failed to set parent of location %d: %w", location.ID, err)
+		}
+	}
+
+	for _, archiveItem := range archive.Items {
+		if imp.newID(ItemEntity, archiveItem.ID) != 0 {
+			continue
+		}
+		item := Item{
+			Name:        archiveItem.Name,
+			Title:       archiveItem.Title,
+			Description: archiveItem.Description,
+			Quantity:    archiveItem.Quantity,
+			OwnerType:   PartyStash,
+			GameID:      game.ID,
+			CreatedByID: imp.playerID(archiveItem.CreatedBy),
+			HiddenBy:    hidden(archiveItem.HiddenBy),
+			Created:     archiveItem.Created,
+		}
+		if ownerType := itemOwnerEntities[archiveItem.OwnerType]; ownerType != "" {
+			if ownerID := imp.newID(ownerType, archiveItem.OwnerID); ownerID != 0 {
+				item.OwnerType = archiveItem.OwnerType
+				item.OwnerID = ownerID
+			}
+		}
+		if _, err = tx.NewInsert().Model(&item).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to import item %d: %w", archiveItem.ID, err)
+		}
+		imp.mapID(ItemEntity, archiveItem.ID, item.ID)
+	}
+
+	for _, archiveFaction := range archive.Factions {
+		if imp.newID(FactionEntity, archiveFaction.ID) != 0 {
+			continue
+		}
+		faction := Faction{
+			Name:        archiveFaction.Name,
+			Title:       archiveFaction.Title,
+			Description: archiveFaction.Description,
+			GameID:      game.ID,
+			CreatedByID: imp.playerID(archiveFaction.CreatedBy),
+			HiddenBy:    hidden(archiveFaction.HiddenBy),
+			Created:     archiveFaction.Created,
+		}
+		if _, err = tx.NewInsert().Model(&faction).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to import faction %d: %w", archiveFaction.ID, err)
+		}
+		imp.mapID(FactionEntity, archiveFaction.ID, faction.ID)
+
+		members := []FactionMember{}
+		for _, archiveMember := range archiveFaction.Members {
+			member := FactionMember{
+				FactionID: faction.ID,
+				CharID:    imp.newID(CharEntity, archiveMember.CharID),
+				NPCID:     imp.newID(NPCEntity, archiveMember.NPCID),
+				Rank:      archiveMember.Rank,
+			}
+			if member.CharID != 0 || member.NPCID != 0 {
+				members = append(members, member)
+			}
+		}
+		if len(members) > 0 {
+			if _, err = tx.NewInsert().Model(&members).Exec(ctx); err != nil {
+				return fmt.Errorf("failed to import faction %d members: %w", archiveFaction.ID, err)
+			}
+		}
+	}
+
+	// Quest links are set after all the quests get their new IDs
+	quests := []Quest{}
+	for _, archiveQuest := range archive.Quests {
+		if imp.newID(QuestEntity, archiveQuest.ID) != 0 {
+			continue
+		}
+		quest := Quest{
+			GameID:      game.ID,
+			Name:        archiveQuest.Name,
+			Title:       archiveQuest.Title,
+			Description: archiveQuest.Description,
+			Successful:  archiveQuest.Successful,
+			HiddenBy:    hidden(archiveQuest.HiddenBy),
+			Created:     archiveQuest.Created,
+			Finished:    archiveQuest.Finished,
+		}
+		if _, err = tx.NewInsert().Model(&quest).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to import quest %d: %w", archiveQuest.ID, err)
+		}
+		imp.mapID(QuestEntity, archiveQuest.ID, quest.ID)
+		quest.ParentID, quest.ChildID, quest.HeadID = archiveQuest.ParentID, archiveQuest.ChildID, archiveQuest.HeadID
+		quests = append(quests, quest)
+
+		for _, archiveTask := range archiveQuest.Tasks {
+			task := QuestTask{
+				GameID:      game.ID,
+				QuestID:     quest.ID,
+				Name:        archiveTask.Name,
+				Description: archiveTask.Description,
+				Type:        archiveTask.Type,
+				Capacity:    archiveTask.Capacity,
+				Current:     archiveTask.Current,
+				HiddenBy:    hidden(archiveTask.HiddenBy),
+				Finished:    archiveTask.Finished,
+			}
+			if _, err = tx.NewInsert().Model(&task).Exec(ctx); err != nil {
+				return fmt.Errorf("failed to import task %d: %w", archiveTask.ID, err)
+			}
+			imp.mapID("task", archiveTask.ID, task.ID)
+		}
+
+		for _, archiveReward := range archiveQuest.Rewards {
+			reward := QuestReward{
+				GameID:              game.ID,
+				QuestID:             quest.ID,
+				Type:                archiveReward.Type,
+				Name:                archiveReward.Name,
+				Description:         archiveReward.Description,
+				Amount:              archiveReward.Amount,
+				HiddenUntilFinished: archiveReward.HiddenUntilFinished,
+				HiddenBy:            hidden(archiveReward.HiddenBy),
+			}
+			if _, err = tx.NewInsert().Model(&reward).Exec(ctx); err != nil {
+				return fmt.Errorf("failed to import quest %d reward: %w", archiveQuest.ID, err)
+			}
+
+			rewardChars := []QuestRewardChar{}
+			for _, charID := range uniqueInts(archiveReward.CharIDs) {
+				if newCharID := imp.newID(CharEntity, charID); newCharID != 0 {
+					rewardChars = append(rewardChars, QuestRewardChar{RewardID: reward.ID, CharID: newCharID})
+				}
+			}
+			if len(rewardChars) > 0 {
+				if _, err = tx.NewInsert().Model(&rewardChars).Exec(ctx); err != nil {
+					return fmt.Errorf("failed to import quest %d reward chars: %w", archiveQuest.ID, err)
+				}
+			}
+		}
+	}
+	for _, quest := range quests {
+		if quest.ParentID == 0 && quest.ChildID == 0 && quest.HeadID == 0 {
+			continue
+		}
+		quest.ParentID = imp.newID(QuestEntity, quest.ParentID)
+		quest.ChildID = imp.newID(QuestEntity, quest.ChildID)
+		quest.HeadID = imp.newID(QuestEntity, quest.HeadID)
+		if _, err = tx.NewUpdate().Model(&quest).Column("parent_id", "child_id", "head_id").WherePK().Exec(ctx); err != nil {
+			return fmt.Errorf("failed to link quest %d: %w", quest.ID, err)
+		}
+	}
+
+	for _, archiveRecord := range archive.Records {
+		if imp.newID(RecordEntity, archiveRecord.ID) != 0 {
+			continue
+		}
+		record := Record{
+			Text:       imp.rewriteMentions(archiveRecord.Text),
+			PlayerID:   imp.playerID(archiveRecord.Player),
+			GameID:     game.ID,
+			HiddenBy:   hidden(archiveRecord.HiddenBy),
+			QuestID:    imp.newID(QuestEntity, archiveRecord.QuestID),
+			WorldStart: archiveRecord.WorldStart,
+			WorldEnd:   archiveRecord.WorldEnd,
+			Created:    archiveRecord.Created,
+			Updated:    archiveRecord.Updated,
+		}
+		if _, err = tx.NewInsert().Model(&record).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to import record %d: %w", archiveRecord.ID, err)
+		}
+		imp.mapID(RecordEntity, archiveRecord.ID, record.ID)
+
+		if err = insertRecordMentions(ctx, tx, &record); err != nil {
+			return fmt.Errorf("failed to import record %d mentions: %w", archiveRecord.ID, err)
+		}
+	}
+
+	for _, archiveEvent := range archive.Events {
+		event := TimelineEvent{
+			Name:        archiveEvent.Name,
+			Description: archiveEvent.Description,
+			WorldStart:  archiveEvent.WorldStart,
+			WorldEnd:    archiveEvent.WorldEnd,
+			GameID:      game.ID,
+			CreatedByID: imp.playerID(archiveEvent.CreatedBy),
+			HiddenBy:    hidden(archiveEvent.HiddenBy),
+		}
+		if _, err = tx.NewInsert().Model(&event).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to import event %d: %w", archiveEvent.ID, err)
+		}
+		imp.mapID("event", archiveEvent.ID, event.ID)
+	}
+
+	for _, archiveField := range archive.Fields {
+		if imp.newID("field", archiveField.ID) != 0 {
+			continue
+		}
+		field := CustomField{
+			GameID:     game.ID,
+			EntityType: archiveField.EntityType,
+			Key:        archiveField.Key,
+			Name:       archiveField.Name,
+			Type:       archiveField.Type,
+			Options:    archiveField.Options,
+			RefType:    archiveField.RefType,
+			Required:   archiveField.Required,
+			Order:      archiveField.Order,
+		}
+		if err = field.validateSchema(); err != nil {
+			return fmt.Errorf("failed to import field %d: %w", archiveField.ID, err)
+		}
+		if _, err = tx.NewInsert().Model(&field).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to import field %d: %w", archiveField.ID, err)
+		}
+		imp.mapID("field", archiveField.ID, field.ID)
+	}
+
+	values := []CustomFieldValue{}
+	for _, archiveValue := range archive.Values {
+		field, ok := imp.fields[archiveValue.FieldID]
+		if !ok {
+			continue
+		}
+		entityID := imp.newID(field.EntityType, archiveValue.EntityID)
+		if entityID == 0 {
+			continue
+		}
+
+		value := archiveValue.Value
+		if field.Type == ReferenceField {
+			refID, _ := strconv.Atoi(value)
+			if refID = imp.newID(field.RefType, refID); refID == 0 {
+				continue
+			}
+			value = strconv.Itoa(refID)
+		}
+
+		values = append(values, CustomFieldValue{
+			FieldID:    imp.newID("field", field.ID),
+			EntityID:   entityID,
+			EntityType: field.EntityType,
+			GameID:     game.ID,
+			Value:      value,
+		})
+	}
+	if len(values) > 0 {
+		if _, err = tx.NewInsert().Model(&values).On("CONFLICT DO NOTHING").Exec(ctx); err != nil {
+			return fmt.Errorf("failed to import field values: %w", err)
+		}
+	}
+
+	entityTags := map[string]map[int][]string{}
+	for _, archiveTag := range archive.Tags {
+		entityID := imp.newID(archiveTag.EntityType, archiveTag.EntityID)
+		if entityID == 0 {
+			continue
+		}
+		if entityTags[archiveTag.EntityType] == nil {
+			entityTags[archiveTag.EntityType] = map[int][]string{}
+		}
+		entityTags[archiveTag.EntityType][entityID] = append(entityTags[archiveTag.EntityType][entityID], archiveTag.Tag)
+	}
+	for entityType, entities := range entityTags {
+		for entityID, names := range entities {
+			if err = s.SetEntityTags(ctx, tx, game.ID, entityType, entityID, names); err != nil {
+				return fmt.Errorf("failed to import tags of %s %d: %w", entityType, entityID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// insertRecordMentions rebuilds records_* rows of the record text
+func insertRecordMentions(ctx context.Context, db bun.IDB, record *Record) error {
+	var err error
+
+	inserted := map[string]bool{}
+	for _, match := range mentionRegexp.FindAllStringSubmatch(record.Text, -1) {
+		id, convErr := strconv.Atoi(match[2])
+		if convErr != nil || inserted[match[1]+match[2]] {
+			continue
+		}
+		inserted[match[1]+match[2]] = true
+
+		switch match[1] {
+		case CharEntity:
+			_, err = db.NewInsert().Model(&RecordChar{RecordID: record.ID, CharID: id}).Exec(ctx)
+		case NPCEntity:
+			_, err = db.NewInsert().Model(&RecordNPC{RecordID: record.ID, NPCID: id}).Exec(ctx)
+		case LocationEntity:
+			_, err = db.NewInsert().Model(&RecordLocation{RecordID: record.ID, LocationID: id}).Exec(ctx)
+		case ItemEntity:
+			_, err = db.NewInsert().Model(&RecordItem{RecordID: record.ID, ItemID: id}).Exec(ctx)
+		case FactionEntity:
+			_, err = db.NewInsert().Model(&RecordFaction{RecordID: record.ID, FactionID: id}).Exec(ctx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package data
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/uptrace/bun"
@@ -13,26 +15,108 @@ func (s *Storage) InitTables() {
 	s.db.RegisterModel((*RecordChar)(nil))
 	s.db.RegisterModel((*RecordNPC)(nil))
 	s.db.RegisterModel((*RecordLocation)(nil))
+	s.db.RegisterModel((*QuestRewardChar)(nil))
+	s.db.RegisterModel((*RecordItem)(nil))
+	s.db.RegisterModel((*RecordFaction)(nil))
 
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Game)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*GameSettings)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*GameCalendar)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*TimelineEvent)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*CustomField)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*CustomFieldValue)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Tag)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*EntityTag)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Player)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Telegram)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*TelegramLink)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Char)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*NPC)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Location)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Item)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*ItemTransfer)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Faction)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*FactionMember)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*FactionStanding)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*FactionStandingChange)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Record)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Session)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Quest)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*QuestTask)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*QuestTaskProgress)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*QuestReward)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*QuestRewardGrant)(nil)).Exec(context.Background())
 
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*PlayerGame)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*RecordChar)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*RecordNPC)(nil)).Exec(context.Background())
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*RecordLocation)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*RecordItem)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*RecordFaction)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*QuestRewardChar)(nil)).Exec(context.Background())
+
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Roll)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Encounter)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Combatant)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*EncounterChange)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Comment)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Reaction)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Notification)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*GMNote)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*CharSheet)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*CharLevelUp)(nil)).Exec(context.Background())
+
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Webhook)(nil)).Exec(context.Background())
+	_, _ = s.db.NewCreateTable().IfNotExists().Model((*WebhookDelivery)(nil)).Exec(context.Background())
 
 	_, _ = s.db.NewCreateTable().IfNotExists().Model((*Log)(nil)).Exec(context.Background())
 
+	// Columns added to the tables created before
+	s.addColumnsIfNotExist((*Record)(nil), "world_start", "world_end")
+	s.addColumnsIfNotExist((*Session)(nil), "world_start", "world_end")
+	s.addColumnsIfNotExist((*Char)(nil), "version")
+	s.addColumnsIfNotExist((*NPC)(nil), "version")
+	s.addColumnsIfNotExist((*Location)(nil), "version")
+	s.addColumnsIfNotExist((*Item)(nil), "version")
+	s.addColumnsIfNotExist((*Faction)(nil), "version")
+	s.addColumnsIfNotExist((*Record)(nil), "version")
+	s.addColumnsIfNotExist((*Quest)(nil), "version")
+	s.addColumnsIfNotExist((*PlayerGame)(nil), "last_seen")
+	s.addColumnsIfNotExist((*GameSettings)(nil), "sheet_template")
+	s.addColumnsIfNotExist((*Char)(nil), "status", "left_time", "left_session")
+	s.addColumnsIfNotExist((*TimelineEvent)(nil), "version")
+	s.addColumnsIfNotExist((*CustomField)(nil), "version")
+	s.addColumnsIfNotExist((*Comment)(nil), "version")
+	s.addColumnsIfNotExist((*GMNote)(nil), "version")
+	s.addColumnsIfNotExist((*Session)(nil), "start_time")
+	s.addColumnsIfNotExist((*Player)(nil), "comments_read")
+
+	// Keys of the deleted custom fields may be taken again
+	_, _ = s.db.ExecContext(context.Background(), "ALTER TABLE custom_field DROP CONSTRAINT IF EXISTS game_entity_key")
+	_, _ = s.db.NewCreateIndex().IfNotExists().Model((*CustomField)(nil)).
+		Index("custom_field_game_entity_key").
+		Unique().
+		Column("game_id", "entity_type", "key").
+		Where("deleted IS NULL").
+		Exec(context.Background())
+
+}
+
+func (s *Storage) addColumnsIfNotExist(model any, columns ...string) {
+	table := s.db.Table(reflect.TypeOf(model))
+	for _, column := range columns {
+		field, ok := table.FieldMap[column]
+		if !ok {
+			continue
+		}
+
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table.SQLName, field.SQLName, field.CreateTableSQLType)
+		if field.SQLDefault != "" {
+			query += " DEFAULT " + field.SQLDefault
+		}
+
+		_, _ = s.db.ExecContext(context.Background(), query)
+	}
 }
 
 type Game struct {
@@ -51,11 +135,14 @@ type Game struct {
 
 	NPCs      []NPC      `bun:"rel:has-many,join:id=game_id"`
 	Locations []Location `bun:"rel:has-many,join:id=game_id"`
+	Items     []Item     `bun:"rel:has-many,join:id=game_id"`
+	Factions  []Faction  `bun:"rel:has-many,join:id=game_id"`
 
 	Records []Record `bun:"rel:has-many,join:id=game_id"`
 	Quests  []Quest  `bun:"rel:has-many,join:id=game_id"`
 
 	Settings *GameSettings `bun:"rel:has-one,join:id=game_id"`
+	Calendar *GameCalendar `bun:"rel:has-one,join:id=game_id"`
 
 	Created *time.Time `bun:"created,default:current_timestamp"`
 	Deleted *time.Time `bun:"deleted,default:null"`
@@ -67,7 +154,8 @@ type GameSettings struct {
 	GameID int   `bun:"game_id,pk"`
 	Game   *Game `bun:"rel:belongs-to,join:game_id=id"`
 
-	AllowAllEditRecords bool `bun:"allow_all_edit_records,default:false"`
+	AllowAllEditRecords bool   `bun:"allow_all_edit_records,default:false"`
+	SheetTemplate       string `bun:"sheet_template,notnull,default:'generic'"`
 }
 
 type Player struct {
@@ -89,7 +177,19 @@ type Player struct {
 
 	Registered *time.Time `bun:"registeredTime,nullzero,notnull,default:current_timestamp"`
 	LastAction *time.Time `bun:"lastActionTime,nullzero,notnull,default:current_timestamp"`
-	Deleted    *time.Time `bun:"deleted,default:null"`
+	// Comments of others written after it are unread. Empty until the
+	// first action after the marker was split from LastAction
+	CommentsRead *time.Time `bun:"comments_read,nullzero"`
+	Deleted      *time.Time `bun:"deleted,default:null"`
+}
+
+// CommentsReadTime is the comments marker, LastAction kept it before
+func (p *Player) CommentsReadTime() *time.Time {
+	if p.CommentsRead != nil {
+		return p.CommentsRead
+	}
+
+	return p.LastAction
 }
 
 type Telegram struct {
@@ -114,6 +214,12 @@ type Char struct {
 	GameID   int     `bun:"game_id"`
 	Game     *Game   `bun:"rel:belongs-to,join:game_id=id"`
 	HiddenBy int     `bun:"hidden_by,default:0" json:"hiddenBy"`
+	Version  int     `bun:"version,notnull,default:1" json:"version"`
+
+	// When and in which session the char was retired or killed
+	Status      CharStatus `bun:"status,notnull,default:'active'"`
+	LeftTime    *time.Time `bun:"left_time,nullzero"`
+	LeftSession int        `bun:"left_session,nullzero"`
 
 	Records []Record `bun:"m2m:records_chars,join:Char=Record"`
 
@@ -128,6 +234,9 @@ type PlayerGame struct {
 	Player   *Player `bun:"rel:belongs-to,join:player_id=id"`
 	GameID   int     `bun:"game_id,pk"`
 	Game     *Game   `bun:"rel:belongs-to,join:game_id=id"`
+
+	// Last time the player marked the digest of the game seen
+	LastSeen *time.Time `bun:"last_seen,nullzero"`
 }
 
 type NPC struct {
@@ -146,6 +255,7 @@ type NPC struct {
 	CreatedByID int     `bun:"created_by_id"`
 	CreatedBy   *Player `bun:"rel:belongs-to,join:created_by_id=id"`
 	HiddenBy    int     `bun:"hidden_by,default:0" json:"hiddenBy"`
+	Version     int     `bun:"version,notnull,default:1" json:"version"`
 
 	Created *time.Time `bun:"created,default:current_timestamp"`
 	Deleted *time.Time `bun:"deleted,default:null"`
@@ -168,11 +278,139 @@ type Location struct {
 	CreatedByID int     `bun:"created_by_id"`
 	CreatedBy   *Player `bun:"rel:belongs-to,join:created_by_id=id"`
 	HiddenBy    int     `bun:"hidden_by,default:0" json:"hiddenBy"`
+	Version     int     `bun:"version,notnull,default:1" json:"version"`
+
+	Created *time.Time `bun:"created,default:current_timestamp"`
+	Deleted *time.Time `bun:"deleted,default:null"`
+}
+
+type ItemOwnerType int
+
+const (
+	PartyStash ItemOwnerType = iota
+	CharOwner
+	NPCOwner
+	LocationOwner
+)
+
+type Item struct {
+	bun.BaseModel `bun:"table:item"`
+
+	ID          int    `bun:"id,pk,autoincrement"`
+	Name        string `bun:"name,notnull"`
+	Title       string `bun:"title"`
+	Description string `bun:"description"`
+	Quantity    int    `bun:"quantity,default:1"`
+
+	OwnerType ItemOwnerType `bun:"owner_type,default:0"`
+	OwnerID   int           `bun:"owner_id,default:0"`
+
+	GameID  int      `bun:"game_id"`
+	Game    *Game    `bun:"rel:belongs-to,join:game_id=id"`
+	Records []Record `bun:"m2m:records_items,join:Item=Record"`
+
+	CreatedByID int     `bun:"created_by_id"`
+	CreatedBy   *Player `bun:"rel:belongs-to,join:created_by_id=id"`
+	HiddenBy    int     `bun:"hidden_by,default:0" json:"hiddenBy"`
+	Version     int     `bun:"version,notnull,default:1" json:"version"`
+
+	Created *time.Time `bun:"created,default:current_timestamp"`
+	Deleted *time.Time `bun:"deleted,default:null"`
+}
+
+type ItemTransfer struct {
+	bun.BaseModel `bun:"table:item_transfer"`
+
+	ID int `bun:"id,pk,autoincrement"`
+
+	ItemID int   `bun:"item_id,notnull"`
+	Item   *Item `bun:"rel:belongs-to,join:item_id=id"`
+	GameID int   `bun:"game_id,notnull"`
+
+	FromType ItemOwnerType `bun:"from_type,default:0"`
+	FromID   int           `bun:"from_id,default:0"`
+	ToType   ItemOwnerType `bun:"to_type,default:0"`
+	ToID     int           `bun:"to_id,default:0"`
+	Quantity int           `bun:"quantity,default:0"`
+
+	PlayerID  int      `bun:"player_id,notnull"`
+	Player    *Player  `bun:"rel:belongs-to,join:player_id=id"`
+	SessionID int      `bun:"session_id,nullzero"`
+	Session   *Session `bun:"rel:belongs-to,join:session_id=id"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp"`
+}
+
+type Faction struct {
+	bun.BaseModel `bun:"table:faction"`
+
+	ID          int    `bun:"id,pk,autoincrement"`
+	Name        string `bun:"name,notnull"`
+	Title       string `bun:"title"`
+	Description string `bun:"description"`
+
+	GameID  int             `bun:"game_id"`
+	Game    *Game           `bun:"rel:belongs-to,join:game_id=id"`
+	Members []FactionMember `bun:"rel:has-many,join:id=faction_id"`
+	Records []Record        `bun:"m2m:records_factions,join:Faction=Record"`
+
+	CreatedByID int     `bun:"created_by_id"`
+	CreatedBy   *Player `bun:"rel:belongs-to,join:created_by_id=id"`
+	HiddenBy    int     `bun:"hidden_by,default:0" json:"hiddenBy"`
+	Version     int     `bun:"version,notnull,default:1" json:"version"`
 
 	Created *time.Time `bun:"created,default:current_timestamp"`
 	Deleted *time.Time `bun:"deleted,default:null"`
 }
 
+// FactionMember is either a char or an NPC
+type FactionMember struct {
+	bun.BaseModel `bun:"table:faction_member"`
+
+	ID int `bun:"id,pk,autoincrement"`
+
+	FactionID int      `bun:"faction_id,notnull"`
+	Faction   *Faction `bun:"rel:belongs-to,join:faction_id=id"`
+	CharID    int      `bun:"char_id,nullzero"`
+	Char      *Char    `bun:"rel:belongs-to,join:char_id=id"`
+	NPCID     int      `bun:"npc_id,nullzero"`
+	NPC       *NPC     `bun:"rel:belongs-to,join:npc_id=id"`
+
+	Rank string `bun:"rank,notnull,default:''"`
+}
+
+type FactionStanding struct {
+	bun.BaseModel `bun:"table:faction_standing"`
+
+	FactionID int      `bun:"faction_id,pk"`
+	Faction   *Faction `bun:"rel:belongs-to,join:faction_id=id"`
+	CharID    int      `bun:"char_id,pk"`
+	Char      *Char    `bun:"rel:belongs-to,join:char_id=id"`
+
+	Standing int `bun:"standing,default:0"`
+}
+
+type FactionStandingChange struct {
+	bun.BaseModel `bun:"table:faction_standing_change"`
+
+	ID int `bun:"id,pk,autoincrement"`
+
+	GameID    int      `bun:"game_id,notnull"`
+	FactionID int      `bun:"faction_id,notnull"`
+	CharID    int      `bun:"char_id,notnull"`
+	Char      *Char    `bun:"rel:belongs-to,join:char_id=id"`
+	PlayerID  int      `bun:"player_id,notnull"`
+	Player    *Player  `bun:"rel:belongs-to,join:player_id=id"`
+	SessionID int      `bun:"session_id,nullzero"`
+	Session   *Session `bun:"rel:belongs-to,join:session_id=id"`
+
+	Delta    int    `bun:"delta,default:0"`
+	Standing int    `bun:"standing,default:0"`
+	Reason   string `bun:"reason,notnull,default:''"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp"`
+}
+
 type Record struct {
 	bun.BaseModel `bun:"table:record"`
 
@@ -182,16 +420,32 @@ type Record struct {
 	Chars     []Char     `bun:"m2m:records_chars,join:Record=Char" json:"chars,omitempty"`
 	NPCs      []NPC      `bun:"m2m:records_npcs,join:Record=NPC" json:"npcs,omitempty"`
 	Locations []Location `bun:"m2m:records_locations,join:Record=Location" json:"locations,omitempty"`
+	Items     []Item     `bun:"m2m:records_items,join:Record=Item" json:"items,omitempty"`
+	Factions  []Faction  `bun:"m2m:records_factions,join:Record=Faction" json:"factions,omitempty"`
 
 	PlayerID int     `bun:"player_id" json:"playerID"`
 	Player   *Player `bun:"rel:belongs-to,join:player_id=id"`
 	GameID   int     `bun:"game_id" json:"gameID"`
 	Game     *Game   `bun:"rel:belongs-to,join:game_id=id"`
 	HiddenBy int     `bun:"hidden_by,default:0" json:"hiddenBy"`
+	Version  int     `bun:"version,notnull,default:1" json:"version"`
 
 	QuestID int    `bun:"quest_id" json:"questID"`
 	Quest   *Quest `bun:"rel:belongs-to,join:quest_id=id" json:"quest"`
 
+	WorldStart *WorldDate `bun:"world_start,type:jsonb" json:"worldStart"`
+	WorldEnd   *WorldDate `bun:"world_end,type:jsonb" json:"worldEnd"`
+
+	Tags  []string `bun:"-" json:"tags,omitempty"`
+	Rolls []Roll   `bun:"rel:has-many,join:id=record_id" json:"rolls,omitempty"`
+
+	// Counted for the player who requests the records
+	Comments       int             `bun:"-" json:"comments"`
+	UnreadComments int             `bun:"-" json:"unreadComments"`
+	Reactions      []ReactionCount `bun:"-" json:"reactions"`
+	// Filled only for the GM
+	GMNotes []GMNote `bun:"-" json:"gmNotes,omitempty"`
+
 	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp" json:"created"`
 	Updated *time.Time `bun:"updated,nullzero,notnull,default:current_timestamp" json:"updated"`
 	Deleted *time.Time `bun:"deleted,default:null" json:"-"`
@@ -224,6 +478,24 @@ type RecordLocation struct {
 	Location   *Location `bun:"rel:belongs-to,join:location_id=id"`
 }
 
+type RecordItem struct {
+	bun.BaseModel `bun:"records_items"`
+
+	RecordID int     `bun:"record_id,pk,autoincrement"`
+	Record   *Record `bun:"rel:belongs-to,join:record_id=id"`
+	ItemID   int     `bun:"item_id,pk"`
+	Item     *Item   `bun:"rel:belongs-to,join:item_id=id"`
+}
+
+type RecordFaction struct {
+	bun.BaseModel `bun:"records_factions"`
+
+	RecordID  int      `bun:"record_id,pk,autoincrement"`
+	Record    *Record  `bun:"rel:belongs-to,join:record_id=id"`
+	FactionID int      `bun:"faction_id,pk"`
+	Faction   *Faction `bun:"rel:belongs-to,join:faction_id=id"`
+}
+
 type Session struct {
 	bun.BaseModel `bun:"session"`
 
@@ -235,7 +507,13 @@ type Session struct {
 	Number int    `bun:"number,notnull" json:"number"`
 	Name   string `bun:",notnull,default:''" json:"name"`
 
-	EndTime *time.Time `bun:"end_time,nullzero" json:"endTime"`
+	WorldStart *WorldDate `bun:"world_start,type:jsonb" json:"worldStart"`
+	WorldEnd   *WorldDate `bun:"world_end,type:jsonb" json:"worldEnd"`
+
+	// Empty for the sessions started before it was saved, they start when
+	// the previous one ends
+	StartTime *time.Time `bun:"start_time,nullzero" json:"startTime"`
+	EndTime   *time.Time `bun:"end_time,nullzero" json:"endTime"`
 }
 
 type Quest struct {
@@ -259,11 +537,13 @@ type Quest struct {
 	HeadID   int    `bun:"head_id"`
 	Head     *Quest `bun:"rel:belongs-to,join:head_id=id"`
 
-	Tasks []QuestTask `bun:"rel:has-many,join:id=quest_id"`
+	Tasks   []QuestTask   `bun:"rel:has-many,join:id=quest_id"`
+	Rewards []QuestReward `bun:"rel:has-many,join:id=quest_id"`
 
 	Successful bool `bun:"successful,default:false" json:"successful"`
 
 	HiddenBy int `bun:"hidden_by,default:0" json:"hiddenBy"`
+	Version  int `bun:"version,notnull,default:1" json:"version"`
 
 	Created  *time.Time `bun:"created,default:current_timestamp"`
 	Deleted  *time.Time `bun:"deleted,default:null"`
@@ -297,3 +577,91 @@ type QuestTask struct {
 
 	Finished *time.Time `bun:"finished,default:null"`
 }
+
+type QuestTaskProgress struct {
+	bun.BaseModel `bun:"quest_task_progress"`
+
+	ID int `bun:"id,pk,autoincrement" json:"id"`
+
+	GameID    int        `bun:"game_id,notnull"`
+	Game      *Game      `bun:"rel:belongs-to,join:game_id=id"`
+	QuestID   int        `bun:"quest_id,notnull"`
+	Quest     *Quest     `bun:"rel:belongs-to,join:quest_id=id"`
+	TaskID    int        `bun:"task_id,notnull"`
+	Task      *QuestTask `bun:"rel:belongs-to,join:task_id=id"`
+	PlayerID  int        `bun:"player_id,notnull"`
+	Player    *Player    `bun:"rel:belongs-to,join:player_id=id"`
+	SessionID int        `bun:"session_id,nullzero"`
+	Session   *Session   `bun:"rel:belongs-to,join:session_id=id"`
+	RecordID  int        `bun:"record_id,nullzero"`
+	Record    *Record    `bun:"rel:belongs-to,join:record_id=id"`
+
+	Previous int  `bun:"previous,default:0"`
+	Current  int  `bun:"current,default:0"`
+	Finished bool `bun:"finished,default:false"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp"`
+}
+
+type QuestRewardType int
+
+const (
+	Experience QuestRewardType = iota
+	Gold
+	ItemReward
+	Boon
+)
+
+// QuestReward amount is granted to every recipient: the assigned chars
+// or, if none are assigned, every character of the game
+type QuestReward struct {
+	bun.BaseModel `bun:"quest_reward"`
+
+	ID int `bun:"id,pk,autoincrement" json:"id"`
+
+	GameID  int    `bun:"game_id,notnull"`
+	Game    *Game  `bun:"rel:belongs-to,join:game_id=id"`
+	QuestID int    `bun:"quest_id,notnull"`
+	Quest   *Quest `bun:"rel:belongs-to,join:quest_id=id"`
+
+	Type        QuestRewardType `bun:",default:0" json:"type"`
+	Name        string          `bun:",notnull,default:''" json:"name"`
+	Description string          `bun:",notnull,default:''" json:"description"`
+	Amount      int             `bun:"amount,default:0" json:"amount"`
+
+	Chars []Char `bun:"m2m:quest_rewards_chars,join:Reward=Char"`
+
+	HiddenUntilFinished bool `bun:"hidden_until_finished,default:false" json:"hiddenUntilFinished"`
+	HiddenBy            int  `bun:"hidden_by,default:0" json:"hiddenBy"`
+}
+
+type QuestRewardChar struct {
+	bun.BaseModel `bun:"quest_rewards_chars"`
+
+	RewardID int          `bun:"reward_id,pk"`
+	Reward   *QuestReward `bun:"rel:belongs-to,join:reward_id=id"`
+	CharID   int          `bun:"char_id,pk"`
+	Char     *Char        `bun:"rel:belongs-to,join:char_id=id"`
+}
+
+// QuestRewardGrant copies reward data so the grant stays intact
+// if the quest rewards are changed later
+type QuestRewardGrant struct {
+	bun.BaseModel `bun:"quest_reward_grant"`
+
+	ID int `bun:"id,pk,autoincrement" json:"id"`
+
+	GameID   int          `bun:"game_id,notnull"`
+	QuestID  int          `bun:"quest_id,notnull"`
+	Quest    *Quest       `bun:"rel:belongs-to,join:quest_id=id"`
+	RewardID int          `bun:"reward_id,notnull"`
+	Reward   *QuestReward `bun:"rel:belongs-to,join:reward_id=id"`
+	CharID   int          `bun:"char_id,notnull"`
+	Char     *Char        `bun:"rel:belongs-to,join:char_id=id"`
+
+	Type   QuestRewardType `bun:",default:0"`
+	Name   string          `bun:",notnull,default:''"`
+	Amount int             `bun:"amount,default:0"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,174 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Types of the notifications
+const (
+	MentionNotification      = "mention"
+	RecordEditedNotification = "record.edited"
+	SharedNotification       = "shared"
+	SessionNotification      = "session.started"
+)
+
+// Notification tells the player about a change of the game made by
+// someone else. Read is nil while it is unread
+type Notification struct {
+	bun.BaseModel `bun:"table:notification"`
+
+	ID int `bun:"id,pk,autoincrement" json:"id"`
+
+	PlayerID   int     `bun:"player_id,notnull" json:"-"`
+	GameID     int     `bun:"game_id,notnull" json:"gameID"`
+	Type       string  `bun:"type,notnull" json:"type"`
+	ActorID    int     `bun:"actor_id,nullzero" json:"actorID,omitempty"`
+	Actor      *Player `bun:"rel:belongs-to,join:actor_id=id" json:"-"`
+	EntityType string  `bun:"entity_type,notnull,default:''" json:"entityType,omitempty"`
+	EntityID   int     `bun:"entity_id,nullzero" json:"entityID,omitempty"`
+	// Short text of the change: the record, the entity name or the session
+	Text string `bun:"text,notnull,default:''" json:"text"`
+
+	Read    *time.Time `bun:"read,nullzero" json:"read"`
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp" json:"created"`
+}
+
+type NotificationEventData struct {
+	ID         int    `json:"id"`
+	Type       string `json:"type"`
+	EntityType string `json:"entityType,omitempty"`
+	EntityID   int    `json:"entityID,omitempty"`
+	Text       string `json:"text"`
+}
+
+// MentionedIDs returns the IDs of the entities of the type mentioned in
+// the text
+func MentionedIDs(text string, entityType string) []int {
+	ids := []int{}
+	for _, match := range mentionRegexp.FindAllStringSubmatch(text, -1) {
+		if match[1] != entityType {
+			continue
+		}
+		if id, err := strconv.Atoi(match[2]); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// GetCharsByIDs returns the chars of the game that are not deleted
+func (s *Storage) GetCharsByIDs(gameID int, charIDs []int) ([]Char, error) {
+	chars := []Char{}
+	if len(charIDs) == 0 {
+		return chars, nil
+	}
+
+	err := s.db.NewSelect().Model(&chars).
+		Where("id IN (?)", bun.In(charIDs)).
+		Where("game_id = ?", gameID).
+		Where("deleted IS NULL").
+		Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return chars, nil
+}
+
+// GetPlayerByID returns nil if there is no player
+func (s *Storage) GetPlayerByID(playerID int) (*Player, error) {
+	var player Player
+
+	err := s.db.NewSelect().Model(&player).
+		Where("player.id = ? AND player.deleted IS NULL", playerID).
+		Relation("Telegram").
+		Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &player, nil
+}
+
+// CreateNotifications saves the notifications and tells each player's
+// clients about theirs
+func (s *Storage) CreateNotifications(notifications []Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	_, err := s.db.NewInsert().Model(&notifications).Returning("*").Exec(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, notification := range notifications {
+		s.publish(Event{
+			Type:     NotificationCreatedEvent,
+			GameID:   notification.GameID,
+			PlayerID: notification.ActorID,
+			HiddenBy: notification.PlayerID,
+			Data: &NotificationEventData{
+				ID:         notification.ID,
+				Type:       notification.Type,
+				EntityType: notification.EntityType,
+				EntityID:   notification.EntityID,
+				Text:       notification.Text,
+			},
+		})
+	}
+
+	return nil
+}
+
+// GetPlayerNotifications returns the newest notifications of the player
+// in all the games
+func (s *Storage) GetPlayerNotifications(player *Player, unreadOnly bool, limit int) ([]Notification, error) {
+	notifications := []Notification{}
+	query := s.db.NewSelect().Model(&notifications).
+		Where("notification.player_id = ?", player.ID).
+		Relation("Actor").
+		Order("notification.id DESC").
+		Limit(limit)
+	if unreadOnly {
+		query = query.Where("notification.read IS NULL")
+	}
+
+	err := query.Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (s *Storage) CountUnreadNotifications(player *Player) (int, error) {
+	return s.db.NewSelect().Model((*Notification)(nil)).
+		Where("player_id = ?", player.ID).
+		Where("read IS NULL").
+		Count(context.Background())
+}
+
+// MarkNotificationsRead marks the notifications of the player read, all of
+// them if there are no IDs. The comments stay unread, they have their own
+// marker
+func (s *Storage) MarkNotificationsRead(player *Player, notificationIDs []int) error {
+	query := s.db.NewUpdate().Model((*Notification)(nil)).
+		Set("read = current_timestamp").
+		Where("player_id = ?", player.ID).
+		Where("read IS NULL")
+	if len(notificationIDs) > 0 {
+		query = query.Where("id IN (?)", bun.In(notificationIDs))
+	}
+
+	_, err := query.Exec(context.Background())
+	return err
+}
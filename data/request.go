@@ -16,7 +16,7 @@ import (
 func (s *Storage) GetPlayerByAccessKey(accesskey string) (*Player, error) {
 	var player Player
 
-	err := s.db.NewSelect().Model(&player).Where("accesskey = ?", accesskey).Relation("CurrentGame.Settings").Relation("CurrentGame.Sessions").Scan(context.Background())
+	err := s.db.NewSelect().Model(&player).Where("accesskey = ?", accesskey).Relation("CurrentGame.Settings").Relation("CurrentGame.Sessions").Relation("Telegram").Scan(context.Background())
 	if err != nil {
 		return nil, err
 	}
@@ -24,6 +24,24 @@ func (s *Storage) GetPlayerByAccessKey(accesskey string) (*Player, error) {
 	return &player, nil
 }
 
+// UpdateLastAction moves the player's last action to now. The comments
+// marker LastAction kept before is saved on the first move
+func (s *Storage) UpdateLastAction(player *Player) error {
+	now := time.Now().UTC()
+	_, err := s.db.NewUpdate().Model(player).
+		Set(`comments_read = COALESCE(comments_read, "lastActionTime")`).
+		Set(`"lastActionTime" = ?`, now).
+		WherePK().
+		Returning("comments_read").
+		Exec(context.Background())
+	if err != nil {
+		return err
+	}
+	player.LastAction = &now
+
+	return nil
+}
+
 func (s *Storage) GetCurrentGamePlayers(game *Game) ([]Player, error) {
 	err := s.db.NewSelect().Model(game).WherePK().Relation("Players").Scan(context.Background())
 	if err != nil {
@@ -46,6 +64,7 @@ func (s *Storage) GetCurrentGameRecordsForPlayer(game *Game, player *Player) ([]
 			return q.Where("record.deleted IS NULL")
 		}).
 		Relation("Quest").
+		Relation("Rolls").
 		Scan(context.Background(), &records)
 	if err != nil {
 		return nil, err
@@ -53,6 +72,16 @@ func (s *Storage) GetCurrentGameRecordsForPlayer(game *Game, player *Player) ([]
 		return []Record{}, nil
 	}
 
+	if err := s.FillRecordTags(records); err != nil {
+		return nil, err
+	}
+	if err := s.FillRecordFeedback(records, player); err != nil {
+		return nil, err
+	}
+	if err := s.FillRecordGMNotes(records, player); err != nil {
+		return nil, err
+	}
+
 	return records, nil
 
 	// === Old implementation without hidden records === //
@@ -97,6 +126,7 @@ func (s *Storage) StartNewGameSession(game *Game) (*Session, error) {
 		return nil, err
 	}
 
+	var newSession *Session
 	err = s.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
 		sessionNumber := 0
 		currentTime := time.Now().UTC()
@@ -130,9 +160,10 @@ func (s *Storage) StartNewGameSession(game *Game) (*Session, error) {
 			sessionNumber++
 		}
 
-		newSession := &Session{
-			GameID: game.ID,
-			Number: sessionNumber,
+		newSession = &Session{
+			GameID:    game.ID,
+			Number:    sessionNumber,
+			StartTime: &currentTime,
 		}
 
 		_, err = s.db.NewInsert().Model(newSession).Exec(context.Background())
@@ -144,40 +175,129 @@ func (s *Storage) StartNewGameSession(game *Game) (*Session, error) {
 
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(Event{
+		Type:     SessionStartedEvent,
+		GameID:   game.ID,
+		PlayerID: game.GMID,
+		Data:     &SessionEventData{Number: newSession.Number, Name: newSession.Name},
+	})
 
 	return currentSession, nil
 }
 
+// GetRecordForPlayer returns nil if the record is deleted or hidden
+// from the player
+func (s *Storage) GetRecordForPlayer(recordID int, player *Player) (*Record, error) {
+	records := []Record{}
+	err := s.db.NewSelect().Model(&records).
+		Where("record.id = ? AND record.deleted IS NULL", recordID).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("record.hidden_by = 0").WhereOr("record.hidden_by = ?", player.ID)
+		}).
+		Relation("Quest").
+		Relation("Rolls").
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	} else if len(records) == 0 {
+		return nil, nil
+	}
+
+	if err := s.FillRecordTags(records); err != nil {
+		return nil, err
+	}
+	if err := s.FillRecordFeedback(records, player); err != nil {
+		return nil, err
+	}
+	if err := s.FillRecordGMNotes(records, player); err != nil {
+		return nil, err
+	}
+
+	return &records[0], nil
+}
+
 func (s *Storage) InsertNewRecord(recordInsert *reqData.RecordInsert, p *Player) error {
+	_, err := s.insertRecord(recordInsert, p)
+	return err
+}
+
+func (s *Storage) insertRecord(recordInsert *reqData.RecordInsert, p *Player) (*Record, error) {
+	session, err := s.currentSession(p.CurrentGame)
+	if err != nil {
+		return nil, err
+	}
+
+	var record *Record
+	err = s.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		record, err = s.insertRecordTx(ctx, tx, recordInsert, p, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishRecordCreated(record, p)
+
+	return record, nil
+}
+
+// insertRecordTx inserts the record with its mentions, rolls and tags in
+// the transaction of the caller, who publishes it after the commit
+func (s *Storage) insertRecordTx(ctx context.Context, tx bun.Tx, recordInsert *reqData.RecordInsert, p *Player, session *Session) (*Record, error) {
+	text, rollResults := rollRecordText(recordInsert.Text, nil)
 	record := Record{
-		Text:     recordInsert.Text,
+		Text:     text,
 		PlayerID: p.ID,
 		GameID:   p.CurrentGameID,
 		QuestID:  recordInsert.QuestID,
 		HiddenBy: gu.TernaryInt(recordInsert.Hidden, p.ID, 0),
+
+		WorldStart: WorldDateFromRequest(recordInsert.WorldStart),
+		WorldEnd:   WorldDateFromRequest(recordInsert.WorldEnd),
 	}
 
-	err := s.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
-		// Insert Record
-		result, err := s.db.NewInsert().Model(&record).Exec(context.Background())
-		if err != nil {
-			return err
-		}
-		if result == nil {
-			return fmt.Errorf("empty insert")
-		}
-		// Insert Mentions
-		if err := s.InsertMentionsForRecord(&record); err != nil {
-			return err
-		}
+	if err := s.validateWorldRange(record.GameID, record.WorldStart, record.WorldEnd); err != nil {
+		return nil, err
+	}
 
-		return nil
-	})
+	// Insert Record
+	_, err := tx.NewInsert().Model(&record).Returning("*").Exec(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	// Insert Mentions
+	if err := insertRecordMentions(ctx, tx, &record); err != nil {
+		return nil, err
+	}
+
+	// Insert Rolls
+	if err := insertRecordRolls(ctx, tx, &record, rollResults, session); err != nil {
+		return nil, err
+	}
+
+	// Insert Tags
+	tags := append(ParseTags(record.Text), recordInsert.Tags...)
+	if err := s.SetEntityTags(ctx, tx, record.GameID, RecordEntity, record.ID, tags); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (s *Storage) publishRecordCreated(record *Record, p *Player) {
+	s.publish(Event{
+		Type:     RecordCreatedEvent,
+		GameID:   record.GameID,
+		PlayerID: p.ID,
+		Username: p.Username,
+		HiddenBy: record.HiddenBy,
+		Data:     recordEventData(record),
+	})
 }
 
 func (s *Storage) UpdateRecord(recordUpdate *reqData.RecordUpdate, p *Player) error {
@@ -200,16 +320,51 @@ func (s *Storage) UpdateRecord(recordUpdate *reqData.RecordUpdate, p *Player) er
 		Updated:  &now,
 		QuestID:  recordUpdate.QuestID,
 		HiddenBy: gu.TernaryInt(recordUpdate.Hidden, p.ID, 0),
+
+		WorldStart: WorldDateFromRequest(recordUpdate.WorldStart),
+		WorldEnd:   WorldDateFromRequest(recordUpdate.WorldEnd),
+	}
+
+	if err := s.validateWorldRange(oldRecord.GameID, record.WorldStart, record.WorldEnd); err != nil {
+		return err
 	}
 
+	session, err := s.currentSession(p.CurrentGame)
+	if err != nil {
+		return err
+	}
+	record.GameID = oldRecord.GameID
+	record.PlayerID = oldRecord.PlayerID
+
 	err = s.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		// Roll New Expressions
+		var rolls []Roll
+		err := tx.NewSelect().Model(&rolls).Where("record_id = ?", record.ID).Scan(ctx)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		text, rollResults := rollRecordText(record.Text, rolls)
+		record.Text = text
+
 		// Update Record
-		result, err := s.db.NewUpdate().Model(&record).Column("text", "updated", "hidden_by", "quest_id").WherePK().Exec(context.Background())
+		result, err := updateVersion(tx.NewUpdate().Model(&record).WherePK().
+			Set("text = ?", record.Text).
+			Set("updated = ?", record.Updated).
+			Set("hidden_by = ?", record.HiddenBy).
+			Set("quest_id = ?", record.QuestID).
+			Set("world_start = ?", record.WorldStart).
+			Set("world_end = ?", record.WorldEnd), recordUpdate.Version).
+			Returning("version").Exec(ctx)
 		if err != nil {
 			return err
 		}
-		if result == nil {
-			return fmt.Errorf("empty insert")
+		if err := checkVersion(result); err != nil {
+			return err
+		}
+
+		// Insert Rolls
+		if err := insertRecordRolls(ctx, tx, &record, rollResults, session); err != nil {
+			return err
 		}
 
 		// Delete Old Mentions
@@ -222,12 +377,21 @@ func (s *Storage) UpdateRecord(recordUpdate *reqData.RecordUpdate, p *Player) er
 			return err
 		}
 
+		// Replace Tags
+		tags := append(ParseTags(record.Text), recordUpdate.Tags...)
+		if err := s.SetEntityTags(ctx, tx, oldRecord.GameID, RecordEntity, record.ID, tags); err != nil {
+			return err
+		}
+
 		return nil
 	})
 	if err != nil {
 		return err
 	}
 
+	record.Created = oldRecord.Created
+	s.publishRecordUpdate(&oldRecord, &record, p)
+
 	return nil
 }
 
@@ -259,6 +423,15 @@ func (s *Storage) DeleteRecord(recordID int, p *Player) error {
 		return fmt.Errorf("empty delete")
 	}
 
+	s.publish(Event{
+		Type:     RecordDeletedEvent,
+		GameID:   oldRecord.GameID,
+		PlayerID: p.ID,
+		Username: p.Username,
+		HiddenBy: oldRecord.HiddenBy,
+		Data:     &RecordEventData{ID: recordID},
+	})
+
 	return nil
 }
 
@@ -307,6 +480,11 @@ func (s *Storage) CreateChar(charCreate *reqData.CharCreate, player *Player) (*C
 		Column("name", "title", "description", "hidden_by", "player_id", "game_id").
 		Returning("*").Exec(context.Background(), &char)
 	//Exec(context.Background())
+	if err == nil {
+		s.publishEntity(EntityCreatedEvent, char.GameID, player, char.HiddenBy, &EntityEventData{
+			EntityType: CharEntity, ID: char.ID, Name: char.Name, Title: char.Title, Description: char.Description,
+		})
+	}
 
 	return &char, err
 }
@@ -317,12 +495,21 @@ func (s *Storage) UpdateChar(charUpdate *reqData.CharUpdate, char *Char, player
 		hiddenBy = player.ID
 	}
 
-	_, err := s.db.NewUpdate().Model(char).WherePK().
+	oldHiddenBy := char.HiddenBy
+	result, err := updateVersion(s.db.NewUpdate().Model(char).WherePK().
 		Set("name = ?", charUpdate.Name).
 		Set("title = ?", charUpdate.Title).
 		Set("description = ?", charUpdate.Description).
-		Set("hidden_by = ?", hiddenBy).
+		Set("hidden_by = ?", hiddenBy), charUpdate.Version).
 		Returning("*").Exec(context.Background())
+	if err == nil {
+		err = checkVersion(result)
+	}
+	if err == nil {
+		s.publishEntityUpdate(char.GameID, player, oldHiddenBy, hiddenBy, &EntityEventData{
+			EntityType: CharEntity, ID: char.ID, Name: charUpdate.Name, Title: charUpdate.Title, Description: charUpdate.Description,
+		})
+	}
 	return char, err
 }
 
@@ -370,6 +557,11 @@ func (s *Storage) CreateNPC(npcCreate *reqData.NPCCreate, player *Player) (*NPC,
 	_, err := s.db.NewInsert().Model(&npc).
 		Column("name", "title", "description", "hidden_by", "created_by_id", "game_id").
 		Returning("*").Exec(context.Background(), &npc)
+	if err == nil {
+		s.publishEntity(EntityCreatedEvent, npc.GameID, player, npc.HiddenBy, &EntityEventData{
+			EntityType: NPCEntity, ID: npc.ID, Name: npc.Name, Title: npc.Title, Description: npc.Description,
+		})
+	}
 
 	return &npc, err
 }
@@ -380,12 +572,21 @@ func (s *Storage) UpdateNPC(npcUpdate *reqData.NPCUpdate, npc *NPC, player *Play
 		hiddenBy = player.ID
 	}
 
-	_, err := s.db.NewUpdate().Model(npc).WherePK().
+	oldHiddenBy := npc.HiddenBy
+	result, err := updateVersion(s.db.NewUpdate().Model(npc).WherePK().
 		Set("name = ?", npcUpdate.Name).
 		Set("title = ?", npcUpdate.Title).
 		Set("description = ?", npcUpdate.Description).
-		Set("hidden_by = ?", hiddenBy).
+		Set("hidden_by = ?", hiddenBy), npcUpdate.Version).
 		Returning("*").Exec(context.Background())
+	if err == nil {
+		err = checkVersion(result)
+	}
+	if err == nil {
+		s.publishEntityUpdate(npc.GameID, player, oldHiddenBy, hiddenBy, &EntityEventData{
+			EntityType: NPCEntity, ID: npc.ID, Name: npcUpdate.Name, Title: npcUpdate.Title, Description: npcUpdate.Description,
+		})
+	}
 	return npc, err
 }
 
@@ -442,153 +643,628 @@ func (s *Storage) CreateLocation(locationCreate *reqData.LocationCreate, player
 	_, err := s.db.NewInsert().Model(&location).
 		Column("name", "title", "description", "pid", "hidden_by", "created_by_id", "game_id").
 		Returning("*").Exec(context.Background(), &location)
+	if err == nil {
+		s.publishEntity(EntityCreatedEvent, location.GameID, player, location.HiddenBy, &EntityEventData{
+			EntityType: LocationEntity, ID: location.ID, Name: location.Name, Title: location.Title, Description: location.Description,
+		})
+	}
 
 	return &location, err
 }
 
 func (s *Storage) UpdateLocation(locationUpdate *reqData.LocationUpdate, location *Location, player *Player) (*Location, error) {
-	_, err := s.db.NewUpdate().Model(location).WherePK().
+	oldHiddenBy := location.HiddenBy
+	hiddenBy := gu.TernaryInt(locationUpdate.Hidden, player.ID, 0)
+	result, err := updateVersion(s.db.NewUpdate().Model(location).WherePK().
 		Set("name = ?", locationUpdate.Name).
 		Set("title = ?", locationUpdate.Title).
 		Set("description = ?", locationUpdate.Description).
 		Set("pid = ?", locationUpdate.ParentID).
-		Set("hidden_by = ?", gu.TernaryInt(locationUpdate.Hidden, player.ID, 0)).
+		Set("hidden_by = ?", hiddenBy), locationUpdate.Version).
 		Returning("*").Exec(context.Background())
+	if err == nil {
+		err = checkVersion(result)
+	}
+	if err == nil {
+		s.publishEntityUpdate(location.GameID, player, oldHiddenBy, hiddenBy, &EntityEventData{
+			EntityType: LocationEntity, ID: location.ID, Name: locationUpdate.Name, Title: locationUpdate.Title, Description: locationUpdate.Description,
+		})
+	}
 	return location, err
 }
 
-func (s *Storage) GetCurrentGameQuests(game *Game) ([]Quest, error) {
-	var quests []Quest
-	err := s.db.NewSelect().Model(&quests).Where("game_id = ? AND deleted is NULL", game.ID).Scan(context.Background())
+func (s *Storage) GetCurrentGameItems(game *Game) ([]Item, error) {
+	var items []Item
+	err := s.db.NewSelect().Model(&items).Where("game_id = ? AND deleted IS NULL", game.ID).Order("id ASC").Scan(context.Background())
 	if err != nil {
 		return nil, err
-	} else if err == sql.ErrNoRows || quests == nil {
-		return quests, nil
+	} else if err == sql.ErrNoRows || items == nil {
+		return []Item{}, nil
 	}
 
-	return quests, nil
+	return items, nil
 }
 
-func (s *Storage) GetQuestByID(questID int) (*Quest, error) {
-	quest := Quest{
-		ID: questID,
+func (s *Storage) GetItemByID(itemID int) (*Item, error) {
+	item := Item{
+		ID: itemID,
 	}
 
-	err := s.db.NewSelect().Model(&quest).WherePK().Relation("Records").Relation("Tasks").Scan(context.Background())
+	err := s.db.NewSelect().Model(&item).WherePK().Relation("Records").Scan(context.Background())
 	if err == sql.ErrNoRows {
 		return nil, nil
 	} else if err != nil {
 		return nil, err
 	}
 
-	return &quest, nil
+	return &item, nil
 }
 
-func (s *Storage) CreateQuest(questCreate *reqData.QuestCreate, tasksCreate []reqData.TaskCreate, player *Player) (*Quest, error) {
-	var quest *Quest
-	ctx := context.Background()
+func (s *Storage) CreateItem(itemCreate *reqData.ItemCreate, player *Player) (*Item, error) {
+	ownerType := ItemOwnerType(itemCreate.OwnerType)
+	if err := s.checkItemOwner(ownerType, itemCreate.OwnerID, player.CurrentGameID); err != nil {
+		return nil, err
+	}
 
-	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		quest = &Quest{
-			Name:        questCreate.Name,
-			Title:       questCreate.Title,
-			Description: questCreate.Description,
-			GameID:      player.CurrentGameID,
-			ParentID:    questCreate.ParentID,
-			ChildID:     questCreate.ChildID,
-			HeadID:      questCreate.HeadID,
-			Successful:  questCreate.Successful,
-			HiddenBy:    gu.TernaryInt(questCreate.Hidden, player.ID, 0),
-		}
+	item := Item{
+		Name:        itemCreate.Name,
+		Title:       itemCreate.Title,
+		Description: itemCreate.Description,
+		Quantity:    gu.TernaryInt(itemCreate.Quantity > 0, itemCreate.Quantity, 1),
+		OwnerType:   ownerType,
+		OwnerID:     gu.TernaryInt(ownerType == PartyStash, 0, itemCreate.OwnerID),
+		HiddenBy:    gu.TernaryInt(itemCreate.Hidden, player.ID, 0),
+		CreatedByID: player.ID,
+		GameID:      player.CurrentGameID,
+	}
 
-		_, err := tx.NewInsert().Model(quest).
-			Column("name", "title", "description", "game_id", "parent_id", "child_id", "head_id", "successful", "hidden_by").
-			Returning("*").
-			Exec(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to insert quest: %w", err)
-		}
+	_, err := s.db.NewInsert().Model(&item).
+		Column("name", "title", "description", "quantity", "owner_type", "owner_id", "hidden_by", "created_by_id", "game_id").
+		Returning("*").Exec(context.Background(), &item)
+	if err == nil {
+		s.publishEntity(EntityCreatedEvent, item.GameID, player, item.HiddenBy, &EntityEventData{
+			EntityType: ItemEntity, ID: item.ID, Name: item.Name, Title: item.Title, Description: item.Description,
+		})
+	}
 
-		if len(tasksCreate) > 0 {
-			questTasks := make([]*QuestTask, len(tasksCreate))
-			for i, taskCreate := range tasksCreate {
-				questTasks[i] = &QuestTask{
-					GameID:      player.CurrentGameID,
-					QuestID:     quest.ID,
-					Name:        taskCreate.Name,
-					Description: taskCreate.Description,
-					Type:        QuestTaskType(taskCreate.Type),
-					Capacity:    taskCreate.Capacity,
-					HiddenBy:    gu.TernaryInt(taskCreate.Hidden, player.ID, 0),
-				}
-			}
+	return &item, err
+}
 
-			_, err = tx.NewInsert().Model(&questTasks).Exec(ctx)
-			if err != nil {
-				return fmt.Errorf("failed to insert tasks: %w", err)
-			}
+func (s *Storage) UpdateItem(itemUpdate *reqData.ItemUpdate, item *Item, player *Player) (*Item, error) {
+	if itemUpdate.Quantity < 1 {
+		return nil, ErrItemQuantity
+	}
+
+	oldHiddenBy := item.HiddenBy
+	hiddenBy := gu.TernaryInt(itemUpdate.Hidden, player.ID, 0)
+	result, err := updateVersion(s.db.NewUpdate().Model(item).WherePK().
+		Set("name = ?", itemUpdate.Name).
+		Set("title = ?", itemUpdate.Title).
+		Set("description = ?", itemUpdate.Description).
+		Set("quantity = ?", itemUpdate.Quantity).
+		Set("hidden_by = ?", hiddenBy), itemUpdate.Version).
+		Returning("*").Exec(context.Background())
+	if err == nil {
+		err = checkVersion(result)
+	}
+	if err == nil {
+		s.publishEntityUpdate(item.GameID, player, oldHiddenBy, hiddenBy, &EntityEventData{
+			EntityType: ItemEntity, ID: item.ID, Name: itemUpdate.Name, Title: itemUpdate.Title, Description: itemUpdate.Description,
+		})
+	}
+	return item, err
+}
+
+func (s *Storage) DeleteItem(item *Item) error {
+	now := time.Now().UTC()
+	item.Deleted = &now
+
+	result, err := s.db.NewUpdate().Model(item).Column("deleted").WherePK().Exec(context.Background())
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return fmt.Errorf("empty delete")
+	}
+
+	s.publish(Event{
+		Type:     EntityDeletedEvent,
+		GameID:   item.GameID,
+		HiddenBy: item.HiddenBy,
+		Data:     &EntityEventData{EntityType: ItemEntity, ID: item.ID},
+	})
+
+	return nil
+}
+
+func (s *Storage) TransferItem(itemTransfer *reqData.ItemTransfer, item *Item, player *Player) (*Item, error) {
+	ownerType := ItemOwnerType(itemTransfer.OwnerType)
+	ownerID := gu.TernaryInt(ownerType == PartyStash, 0, itemTransfer.OwnerID)
+	if err := s.checkItemOwner(ownerType, ownerID, item.GameID); err != nil {
+		return nil, err
+	}
+
+	currentSession, err := s.GetCurrentGameSession(player.CurrentGame)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	transfer := ItemTransfer{
+		ItemID:   item.ID,
+		GameID:   item.GameID,
+		FromType: item.OwnerType,
+		FromID:   item.OwnerID,
+		ToType:   ownerType,
+		ToID:     ownerID,
+		Quantity: item.Quantity,
+		PlayerID: player.ID,
+	}
+	if currentSession != nil {
+		transfer.SessionID = currentSession.ID
+	}
+
+	ctx := context.Background()
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		item.OwnerType = ownerType
+		item.OwnerID = ownerID
+
+		_, err := tx.NewUpdate().Model(item).Column("owner_type", "owner_id").WherePK().Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to change item owner: %w", err)
 		}
 
-		err = tx.NewSelect().
-			Model(quest).
-			Relation("Tasks").
-			Where("id = ?", quest.ID).
-			Scan(ctx)
+		_, err = tx.NewInsert().Model(&transfer).Exec(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to load quest with tasks: %w", err)
+			return fmt.Errorf("failed to insert item transfer: %w", err)
 		}
 
 		return nil
 	})
-
 	if err != nil {
 		return nil, err
 	}
 
-	return quest, nil
+	return item, nil
 }
 
-func (s *Storage) UpdateQuest(questUpdate *reqData.QuestUpdate, tasksUpdate []reqData.TaskUpdate, quest *Quest, player *Player) (*Quest, error) {
-	ctx := context.Background()
-	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		if _, err := s.db.NewUpdate().Model(quest).WherePK().
-			Set("name = ?", questUpdate.Name).
-			Set("title = ?", questUpdate.Title).
-			Set("description = ?", questUpdate.Description).
-			Set("hidden_by = ?", gu.TernaryInt(questUpdate.Hidden, player.ID, 0)).
-			Returning("*").Exec(context.Background()); err != nil {
-			return fmt.Errorf("failed to update quest: %w", err)
-		}
-
-		if len(tasksUpdate) == 0 {
+func (s *Storage) GetItemTransfers(item *Item) ([]ItemTransfer, error) {
+	transfers := []ItemTransfer{}
 
-			if _, err := tx.NewDelete().
-				Model((*QuestTask)(nil)).
-				Where("quest_id = ?", quest.ID).
-				Exec(ctx); err != nil {
-				return fmt.Errorf("failed to update quest: %w", err)
-			}
+	err := s.db.NewSelect().Model(&transfers).
+		Where("item_transfer.item_id = ?", item.ID).
+		Relation("Player").
+		Relation("Session").
+		Order("item_transfer.created ASC", "item_transfer.id ASC").
+		Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return transfers, nil
+	} else if err != nil {
+		return nil, err
+	}
 
-		} else {
+	return transfers, nil
+}
 
-			var values []any
-			var valuePlaceholders []string
+func (s *Storage) checkItemOwner(ownerType ItemOwnerType, ownerID int, gameID int) error {
+	var model any
+	switch ownerType {
+	case PartyStash:
+		return nil
+	case CharOwner:
+		model = (*Char)(nil)
+	case NPCOwner:
+		model = (*NPC)(nil)
+	case LocationOwner:
+		model = (*Location)(nil)
+	default:
+		return fmt.Errorf("unknown item owner type %d", ownerType)
+	}
 
-			for _, task := range tasksUpdate {
-				hiddenBy := gu.TernaryInt(task.Hidden, player.ID, 0)
-				values = append(values,
-					task.ID,
-					task.Name,
-					task.Description,
-					task.Type,
-					task.Capacity,
-					hiddenBy,
-				)
-			}
+	exists, err := s.db.NewSelect().Model(model).Where("id = ? AND game_id = ?", ownerID, gameID).Exists(context.Background())
+	if err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("item owner %d of type %d does not exist in the game %d", ownerID, ownerType, gameID)
+	}
 
-			for range tasksUpdate {
-				valuePlaceholders = append(valuePlaceholders, "(?,?,?,?,?,?)")
-			}
+	return nil
+}
+
+func (s *Storage) GetCurrentGameFactions(game *Game) ([]Faction, error) {
+	var factions []Faction
+	err := s.db.NewSelect().Model(&factions).Where("game_id = ? AND deleted IS NULL", game.ID).Order("id ASC").Scan(context.Background())
+	if err != nil {
+		return nil, err
+	} else if err == sql.ErrNoRows || factions == nil {
+		return []Faction{}, nil
+	}
+
+	return factions, nil
+}
+
+func (s *Storage) GetFactionByID(factionID int) (*Faction, error) {
+	faction := Faction{
+		ID: factionID,
+	}
+
+	err := s.db.NewSelect().Model(&faction).WherePK().
+		Relation("Records").
+		Relation("Members.Char").
+		Relation("Members.NPC").
+		Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &faction, nil
+}
+
+func (s *Storage) CreateFaction(factionCreate *reqData.FactionCreate, player *Player) (*Faction, error) {
+	faction := &Faction{
+		Name:        factionCreate.Name,
+		Title:       factionCreate.Title,
+		Description: factionCreate.Description,
+		HiddenBy:    gu.TernaryInt(factionCreate.Hidden, player.ID, 0),
+		CreatedByID: player.ID,
+		GameID:      player.CurrentGameID,
+	}
+
+	ctx := context.Background()
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(faction).
+			Column("name", "title", "description", "hidden_by", "created_by_id", "game_id").
+			Returning("*").Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to insert faction: %w", err)
+		}
+
+		return insertFactionMembers(ctx, tx, faction, factionCreate.Members)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishEntity(EntityCreatedEvent, faction.GameID, player, faction.HiddenBy, &EntityEventData{
+		EntityType: FactionEntity, ID: faction.ID, Name: faction.Name, Title: faction.Title, Description: faction.Description,
+	})
+
+	return s.GetFactionByID(faction.ID)
+}
+
+func (s *Storage) UpdateFaction(factionUpdate *reqData.FactionUpdate, faction *Faction, player *Player) (*Faction, error) {
+	oldHiddenBy := faction.HiddenBy
+	hiddenBy := gu.TernaryInt(factionUpdate.Hidden, player.ID, 0)
+
+	ctx := context.Background()
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := updateVersion(tx.NewUpdate().Model(faction).WherePK().
+			Set("name = ?", factionUpdate.Name).
+			Set("title = ?", factionUpdate.Title).
+			Set("description = ?", factionUpdate.Description).
+			Set("hidden_by = ?", hiddenBy), factionUpdate.Version).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update faction: %w", err)
+		}
+		if err := checkVersion(result); err != nil {
+			return err
+		}
+
+		if factionUpdate.Members == nil {
+			return nil
+		}
+
+		// Only the members the player sees are replaced, the hidden ones
+		// stay untouched
+		memberIDs := []int{}
+		for _, member := range AllowedFactionMembers(faction, player.ID) {
+			memberIDs = append(memberIDs, member.ID)
+		}
+		if len(memberIDs) > 0 {
+			_, err = tx.NewDelete().Model((*FactionMember)(nil)).Where("id IN (?)", bun.In(memberIDs)).Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to delete faction members: %w", err)
+			}
+		}
+
+		return insertFactionMembers(ctx, tx, faction, factionUpdate.Members)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishEntityUpdate(faction.GameID, player, oldHiddenBy, hiddenBy, &EntityEventData{
+		EntityType: FactionEntity, ID: faction.ID, Name: factionUpdate.Name, Title: factionUpdate.Title, Description: factionUpdate.Description,
+	})
+
+	return s.GetFactionByID(faction.ID)
+}
+
+func insertFactionMembers(ctx context.Context, tx bun.Tx, faction *Faction, membersUpdate []reqData.FactionMemberUpdate) error {
+	if len(membersUpdate) == 0 {
+		return nil
+	}
+
+	members := make([]FactionMember, len(membersUpdate))
+	for i, member := range membersUpdate {
+		if (member.CharID == 0) == (member.NPCID == 0) {
+			return errors.New("faction member must be either a char or an npc")
+		}
+
+		var model any = (*Char)(nil)
+		memberID := member.CharID
+		if member.NPCID != 0 {
+			model = (*NPC)(nil)
+			memberID = member.NPCID
+		}
+		exists, err := tx.NewSelect().Model(model).Where("id = ? AND game_id = ?", memberID, faction.GameID).Exists(ctx)
+		if err != nil {
+			return err
+		} else if !exists {
+			return fmt.Errorf("faction member %d does not exist in the game %d", memberID, faction.GameID)
+		}
+
+		members[i] = FactionMember{
+			FactionID: faction.ID,
+			CharID:    member.CharID,
+			NPCID:     member.NPCID,
+			Rank:      member.Rank,
+		}
+	}
+
+	_, err := tx.NewInsert().Model(&members).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to insert faction members: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetFactionStandings(faction *Faction) ([]FactionStanding, error) {
+	standings := []FactionStanding{}
+
+	err := s.db.NewSelect().Model(&standings).
+		Where("faction_standing.faction_id = ?", faction.ID).
+		Relation("Char").
+		Order("faction_standing.standing DESC").
+		Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return standings, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return standings, nil
+}
+
+func (s *Storage) GetFactionStandingChanges(faction *Faction) ([]FactionStandingChange, error) {
+	changes := []FactionStandingChange{}
+
+	err := s.db.NewSelect().Model(&changes).
+		Where("faction_standing_change.faction_id = ?", faction.ID).
+		Relation("Char").
+		Relation("Player").
+		Relation("Session").
+		Order("faction_standing_change.created ASC", "faction_standing_change.id ASC").
+		Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return changes, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (s *Storage) ChangeFactionStanding(standingChange *reqData.FactionStandingChange, faction *Faction, player *Player) (*FactionStanding, error) {
+	exists, err := s.db.NewSelect().Model((*Char)(nil)).
+		Where("id = ? AND game_id = ?", standingChange.CharID, faction.GameID).
+		Exists(context.Background())
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, fmt.Errorf("char %d does not exist in the game %d", standingChange.CharID, faction.GameID)
+	}
+
+	currentSession, err := s.GetCurrentGameSession(player.CurrentGame)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	standing := &FactionStanding{
+		FactionID: faction.ID,
+		CharID:    standingChange.CharID,
+		Standing:  standingChange.Delta,
+	}
+
+	ctx := context.Background()
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(standing).
+			On("CONFLICT (faction_id, char_id) DO UPDATE").
+			Set("standing = faction_standing.standing + EXCLUDED.standing").
+			Returning("*").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to change standing: %w", err)
+		}
+
+		change := FactionStandingChange{
+			GameID:    faction.GameID,
+			FactionID: faction.ID,
+			CharID:    standingChange.CharID,
+			PlayerID:  player.ID,
+			Delta:     standingChange.Delta,
+			Standing:  standing.Standing,
+			Reason:    standingChange.Reason,
+		}
+		if currentSession != nil {
+			change.SessionID = currentSession.ID
+		}
+
+		_, err = tx.NewInsert().Model(&change).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to log standing change: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return standing, nil
+}
+
+func (s *Storage) GetCurrentGameQuests(game *Game) ([]Quest, error) {
+	var quests []Quest
+	err := s.db.NewSelect().Model(&quests).Where("game_id = ? AND deleted is NULL", game.ID).Scan(context.Background())
+	if err != nil {
+		return nil, err
+	} else if err == sql.ErrNoRows || quests == nil {
+		return quests, nil
+	}
+
+	return quests, nil
+}
+
+func (s *Storage) GetQuestByID(questID int) (*Quest, error) {
+	quest := Quest{
+		ID: questID,
+	}
+
+	err := s.db.NewSelect().Model(&quest).WherePK().Relation("Records").Relation("Tasks").Relation("Rewards.Chars").Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &quest, nil
+}
+
+func (s *Storage) CreateQuest(questCreate *reqData.QuestCreate, tasksCreate []reqData.TaskCreate, rewardsCreate []reqData.RewardCreate, player *Player) (*Quest, error) {
+	var quest *Quest
+	ctx := context.Background()
+
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		quest = &Quest{
+			Name:        questCreate.Name,
+			Title:       questCreate.Title,
+			Description: questCreate.Description,
+			GameID:      player.CurrentGameID,
+			ParentID:    questCreate.ParentID,
+			ChildID:     questCreate.ChildID,
+			HeadID:      questCreate.HeadID,
+			Successful:  questCreate.Successful,
+			HiddenBy:    gu.TernaryInt(questCreate.Hidden, player.ID, 0),
+		}
+
+		_, err := tx.NewInsert().Model(quest).
+			Column("name", "title", "description", "game_id", "parent_id", "child_id", "head_id", "successful", "hidden_by").
+			Returning("*").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to insert quest: %w", err)
+		}
+
+		if len(tasksCreate) > 0 {
+			questTasks := make([]*QuestTask, len(tasksCreate))
+			for i, taskCreate := range tasksCreate {
+				questTasks[i] = &QuestTask{
+					GameID:      player.CurrentGameID,
+					QuestID:     quest.ID,
+					Name:        taskCreate.Name,
+					Description: taskCreate.Description,
+					Type:        QuestTaskType(taskCreate.Type),
+					Capacity:    taskCreate.Capacity,
+					HiddenBy:    gu.TernaryInt(taskCreate.Hidden, player.ID, 0),
+				}
+			}
+
+			_, err = tx.NewInsert().Model(&questTasks).Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to insert tasks: %w", err)
+			}
+		}
+
+		if err := insertQuestRewards(ctx, tx, quest, rewardsCreate, player); err != nil {
+			return err
+		}
+
+		err = tx.NewSelect().
+			Model(quest).
+			Relation("Tasks").
+			Relation("Rewards.Chars").
+			Where("id = ?", quest.ID).
+			Scan(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load quest with tasks: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishEntity(EntityCreatedEvent, quest.GameID, player, quest.HiddenBy, &EntityEventData{
+		EntityType: QuestEntity, ID: quest.ID, Name: quest.Name, Title: quest.Title, Description: quest.Description,
+	})
+
+	return quest, nil
+}
+
+func (s *Storage) UpdateQuest(questUpdate *reqData.QuestUpdate, tasksUpdate []reqData.TaskUpdate, rewardsUpdate []reqData.RewardCreate, quest *Quest, player *Player) (*Quest, error) {
+	oldHiddenBy := quest.HiddenBy
+	hiddenBy := gu.TernaryInt(questUpdate.Hidden, player.ID, 0)
+
+	ctx := context.Background()
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := updateVersion(tx.NewUpdate().Model(quest).WherePK().
+			Set("name = ?", questUpdate.Name).
+			Set("title = ?", questUpdate.Title).
+			Set("description = ?", questUpdate.Description).
+			Set("hidden_by = ?", hiddenBy), questUpdate.Version).
+			Returning("*").Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update quest: %w", err)
+		}
+		if err := checkVersion(result); err != nil {
+			return err
+		}
+
+		if len(tasksUpdate) == 0 {
+
+			if _, err := tx.NewDelete().
+				Model((*QuestTask)(nil)).
+				Where("quest_id = ?", quest.ID).
+				Exec(ctx); err != nil {
+				return fmt.Errorf("failed to update quest: %w", err)
+			}
+
+		} else {
+
+			var values []any
+			var valuePlaceholders []string
+
+			for _, task := range tasksUpdate {
+				hiddenBy := gu.TernaryInt(task.Hidden, player.ID, 0)
+				values = append(values,
+					task.ID,
+					task.Name,
+					task.Description,
+					task.Type,
+					task.Capacity,
+					hiddenBy,
+				)
+			}
+
+			for range tasksUpdate {
+				valuePlaceholders = append(valuePlaceholders, "(?,?,?,?,?,?)")
+			}
 
 			query := fmt.Sprintf(`
 				WITH input_data(id, name, description, type, capacity, hidden_by) AS (
@@ -636,13 +1312,32 @@ func (s *Storage) UpdateQuest(questUpdate *reqData.QuestUpdate, tasksUpdate []re
 
 		}
 
+		if rewardsUpdate != nil {
+			// Only the rewards the player sees are replaced, the hidden
+			// ones stay untouched
+			rewardIDs := []int{}
+			for _, reward := range AllowedQuestRewards(quest, player) {
+				rewardIDs = append(rewardIDs, reward.ID)
+			}
+			if err := deleteQuestRewards(ctx, tx, rewardIDs); err != nil {
+				return err
+			}
+			if err := insertQuestRewards(ctx, tx, quest, rewardsUpdate, player); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
 		return nil, fmt.Errorf("transaction failed: %w", err)
 	}
 
-	err = s.db.NewSelect().Model(quest).WherePK().Relation("Records").Relation("Tasks").Scan(context.Background())
+	s.publishEntityUpdate(quest.GameID, player, oldHiddenBy, hiddenBy, &EntityEventData{
+		EntityType: QuestEntity, ID: quest.ID, Name: questUpdate.Name, Title: questUpdate.Title, Description: questUpdate.Description,
+	})
+
+	err = s.db.NewSelect().Model(quest).WherePK().Relation("Records").Relation("Tasks").Relation("Rewards.Chars").Scan(context.Background())
 	if err == sql.ErrNoRows {
 		return nil, nil
 	} else if err != nil {
@@ -660,7 +1355,7 @@ func (s *Storage) DeleteQuest(questID int, p *Player) error {
 	}
 
 	// Delete Quest
-	result, err := s.db.NewUpdate().Model(&quest).Column("deleted").WherePK().Exec(context.Background())
+	result, err := s.db.NewUpdate().Model(&quest).Column("deleted").WherePK().Returning("game_id").Exec(context.Background())
 	if err != nil {
 		return err
 	}
@@ -668,6 +1363,9 @@ func (s *Storage) DeleteQuest(questID int, p *Player) error {
 		return fmt.Errorf("empty delete")
 	}
 
+	// Only the id is published, the quest may be hidden
+	s.publishEntity(EntityDeletedEvent, quest.GameID, p, 0, &EntityEventData{EntityType: QuestEntity, ID: questID})
+
 	return nil
 }
 
@@ -684,16 +1382,38 @@ func (s *Storage) GetTasksByQuest(quest *Quest) ([]QuestTask, error) {
 	return tasks, nil
 }
 
-func (s *Storage) UpdateQuestTasks(tasksUpdate []reqData.TaskPatch, quest *Quest, player *Player) ([]QuestTask, error) {
-	if len(tasksUpdate) == 0 || len(quest.Tasks) == 0 {
-		return nil, errors.New("empty tasks on update or quest itself")
+func (s *Storage) UpdateQuestTasks(tasksPatch *reqData.QuestTasksPatch, quest *Quest, player *Player) ([]QuestTask, error) {
+	if len(tasksPatch.Tasks) == 0 {
+		return nil, errors.New("empty tasks on update")
 	}
 
-	var tasks = quest.Tasks
-	var finishTime = time.Now().UTC()
-	for i := range tasks {
-		for _, task := range tasksUpdate {
-			if tasks[i].ID == task.ID {
+	currentSession, err := s.GetCurrentGameSession(player.CurrentGame)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var tasks []QuestTask
+	var changedTasks []QuestTask
+	var progressRecord *Record
+	ctx := context.Background()
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		// Load tasks instead of relying on preloaded quest relation
+		err := tx.NewSelect().Model(&tasks).Where("quest_id = ?", quest.ID).Order("id ASC").Scan(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load quest tasks: %w", err)
+		} else if len(tasks) == 0 {
+			return errors.New("quest has no tasks to update")
+		}
+
+		var progress []*QuestTaskProgress
+		var finishTime = time.Now().UTC()
+		for i := range tasks {
+			for _, task := range tasksPatch.Tasks {
+				if tasks[i].ID != task.ID || tasks[i].Current == task.Current {
+					continue
+				}
+
+				previous := tasks[i].Current
 				tasks[i].Current = task.Current
 				if tasks[i].Type == Binary {
 					if tasks[i].Current > 0 {
@@ -708,12 +1428,308 @@ func (s *Storage) UpdateQuestTasks(tasksUpdate []reqData.TaskPatch, quest *Quest
 						tasks[i].Finished = nil
 					}
 				}
+
+				taskProgress := &QuestTaskProgress{
+					GameID:   quest.GameID,
+					QuestID:  quest.ID,
+					TaskID:   tasks[i].ID,
+					PlayerID: player.ID,
+					Previous: previous,
+					Current:  tasks[i].Current,
+					Finished: tasks[i].Finished != nil,
+				}
+				if currentSession != nil {
+					taskProgress.SessionID = currentSession.ID
+				}
+
+				progress = append(progress, taskProgress)
+				changedTasks = append(changedTasks, tasks[i])
+			}
+		}
+
+		if len(changedTasks) == 0 {
+			return nil
+		}
+
+		_, err = tx.NewUpdate().Model(&changedTasks).Column("current", "finished").Bulk().Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update tasks: %w", err)
+		}
+
+		// Describe progress with a record if asked
+		if tasksPatch.Record {
+			record := &Record{
+				Text:     formTaskProgressText(quest, changedTasks, progress),
+				PlayerID: player.ID,
+				GameID:   quest.GameID,
+				QuestID:  quest.ID,
+				HiddenBy: gu.TernaryInt(tasksPatch.RecordHidden, player.ID, 0),
+			}
+
+			_, err = tx.NewInsert().Model(record).Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to insert progress record: %w", err)
+			}
+			progressRecord = record
+
+			for _, taskProgress := range progress {
+				taskProgress.RecordID = record.ID
+			}
+		}
+
+		_, err = tx.NewInsert().Model(&progress).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to insert task progress: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changedTasks) > 0 {
+		taskIDs := make([]int, len(changedTasks))
+		for i, task := range changedTasks {
+			taskIDs[i] = task.ID
+		}
+		s.publish(Event{
+			Type:     QuestTasksEvent,
+			GameID:   quest.GameID,
+			PlayerID: player.ID,
+			Username: player.Username,
+			HiddenBy: quest.HiddenBy,
+			Data:     &QuestTasksEventData{QuestID: quest.ID, TaskIDs: taskIDs},
+		})
+	}
+	if progressRecord != nil {
+		s.publish(Event{
+			Type:     RecordCreatedEvent,
+			GameID:   progressRecord.GameID,
+			PlayerID: player.ID,
+			Username: player.Username,
+			HiddenBy: progressRecord.HiddenBy,
+			Data:     recordEventData(progressRecord),
+		})
+	}
+
+	return tasks, nil
+}
+
+func (s *Storage) GetTaskByID(taskID int) (*QuestTask, error) {
+	task := QuestTask{
+		ID: taskID,
+	}
+
+	err := s.db.NewSelect().Model(&task).WherePK().Relation("Quest").Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+func (s *Storage) GetTaskProgress(task *QuestTask) ([]QuestTaskProgress, error) {
+	progress := []QuestTaskProgress{}
+
+	err := s.db.NewSelect().Model(&progress).
+		Where("quest_task_progress.task_id = ?", task.ID).
+		Relation("Player").
+		Relation("Session").
+		Order("quest_task_progress.created ASC", "quest_task_progress.id ASC").
+		Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return progress, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+func insertQuestRewards(ctx context.Context, tx bun.Tx, quest *Quest, rewardsCreate []reqData.RewardCreate, player *Player) error {
+	if len(rewardsCreate) == 0 {
+		return nil
+	}
+
+	questRewards := make([]*QuestReward, len(rewardsCreate))
+	for i, rewardCreate := range rewardsCreate {
+		questRewards[i] = &QuestReward{
+			GameID:              quest.GameID,
+			QuestID:             quest.ID,
+			Type:                QuestRewardType(rewardCreate.Type),
+			Name:                rewardCreate.Name,
+			Description:         rewardCreate.Description,
+			Amount:              rewardCreate.Amount,
+			HiddenUntilFinished: rewardCreate.HiddenUntilFinished,
+			HiddenBy:            gu.TernaryInt(rewardCreate.Hidden, player.ID, 0),
+		}
+	}
+
+	_, err := tx.NewInsert().Model(&questRewards).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to insert rewards: %w", err)
+	}
+
+	var rewardChars []QuestRewardChar
+	for i, rewardCreate := range rewardsCreate {
+		for _, charID := range uniqueInts(rewardCreate.CharIDs) {
+			rewardChars = append(rewardChars, QuestRewardChar{RewardID: questRewards[i].ID, CharID: charID})
+		}
+	}
+
+	if len(rewardChars) > 0 {
+		// Only chars of the quest game may be assigned
+		var gameCharsCount int
+		charIDs := make([]int, len(rewardChars))
+		for i, rewardChar := range rewardChars {
+			charIDs[i] = rewardChar.CharID
+		}
+		gameCharsCount, err = tx.NewSelect().Model((*Char)(nil)).
+			Where("id IN (?) AND game_id = ?", bun.In(charIDs), quest.GameID).
+			Count(ctx)
+		if err != nil {
+			return err
+		}
+		if gameCharsCount != len(uniqueInts(charIDs)) {
+			return errors.New("reward may be assigned only to chars of the current game")
+		}
+
+		_, err = tx.NewInsert().Model(&rewardChars).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to assign rewards: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func deleteQuestRewards(ctx context.Context, tx bun.Tx, rewardIDs []int) error {
+	if len(rewardIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.NewDelete().Model((*QuestRewardChar)(nil)).
+		Where("reward_id IN (?)", bun.In(rewardIDs)).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete reward assignments: %w", err)
+	}
+
+	_, err = tx.NewDelete().Model((*QuestReward)(nil)).Where("id IN (?)", bun.In(rewardIDs)).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete rewards: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) CompleteQuest(quest *Quest, successful bool, player *Player) ([]QuestRewardGrant, error) {
+	if quest.Finished != nil {
+		return nil, ErrQuestFinished
+	}
+
+	grants := []QuestRewardGrant{}
+	ctx := context.Background()
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		now := time.Now().UTC()
+		quest.Finished = &now
+		quest.Successful = successful
+
+		_, err := tx.NewUpdate().Model(quest).Column("finished", "successful").WherePK().Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to finish quest: %w", err)
+		}
+
+		// Failed quests grant nothing
+		if !successful {
+			return nil
+		}
+
+		var rewards []QuestReward
+		err = tx.NewSelect().Model(&rewards).Where("quest_id = ?", quest.ID).Relation("Chars").Scan(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load rewards: %w", err)
+		}
+
+		var gameChars []Char
+		err = tx.NewSelect().Model(&gameChars).Where("game_id = ? AND deleted IS NULL", quest.GameID).Scan(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load chars: %w", err)
+		}
+
+		for _, reward := range rewards {
+			recipients := reward.Chars
+			if len(recipients) == 0 {
+				recipients = gameChars
+			}
+			for _, char := range recipients {
+				grants = append(grants, QuestRewardGrant{
+					GameID:   quest.GameID,
+					QuestID:  quest.ID,
+					RewardID: reward.ID,
+					CharID:   char.ID,
+					Type:     reward.Type,
+					Name:     reward.Name,
+					Amount:   reward.Amount,
+				})
 			}
 		}
+
+		if len(grants) == 0 {
+			return nil
+		}
+
+		_, err = tx.NewInsert().Model(&grants).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to grant rewards: %w", err)
+		}
+
+		return tx.NewSelect().Model(&grants).Where("quest_reward_grant.quest_id = ?", quest.ID).Relation("Char").Scan(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(Event{
+		Type:     QuestStatusEvent,
+		GameID:   quest.GameID,
+		PlayerID: player.ID,
+		Username: player.Username,
+		HiddenBy: quest.HiddenBy,
+		Data: &QuestEventData{
+			ID:         quest.ID,
+			Name:       quest.Name,
+			Title:      quest.Title,
+			Status:     gu.Ternary(successful, "completed", "failed").(string),
+			Successful: successful,
+		},
+	})
+
+	return grants, nil
+}
+
+// GetCharRewardGrants skips the grants of the rewards and the quests
+// hidden from the player
+func (s *Storage) GetCharRewardGrants(char *Char, player *Player) ([]QuestRewardGrant, error) {
+	grants := []QuestRewardGrant{}
+
+	err := s.db.NewSelect().Model(&grants).
+		Where("quest_reward_grant.char_id = ?", char.ID).
+		Relation("Quest").
+		Where("quest.hidden_by IN (0, ?)", player.ID).
+		Where("NOT EXISTS (SELECT 1 FROM quest_reward AS reward WHERE reward.id = quest_reward_grant.reward_id AND reward.hidden_by NOT IN (0, ?))", player.ID).
+		Order("quest_reward_grant.created ASC").
+		Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return grants, nil
+	} else if err != nil {
+		return nil, err
 	}
 
-	_, err := s.db.NewUpdate().Model(&tasks).Column("current", "finished").Bulk().Returning("*").Exec(context.Background())
-	return tasks, err
+	return grants, nil
 }
 
 func (s *Storage) GetSuggestions(player *Player) ([]Suggestion, error) {
@@ -758,8 +1774,37 @@ func (s *Storage) GetSuggestions(player *Player) ([]Suggestion, error) {
 				ELSE true
 			END as hidden
 		FROM location
-		WHERE game_id = ?`,
+		WHERE game_id = ?
+
+		UNION ALL
+
+		SELECT
+			id,
+			CONCAT('item:', id) as sid,
+			'item' as type,
+			name,
+			CASE 
+				WHEN hidden_by = 0 OR hidden_by = ? THEN false
+				ELSE true
+			END as hidden
+		FROM item
+		WHERE game_id = ? AND deleted IS NULL
+
+		UNION ALL
+
+		SELECT
+			id,
+			CONCAT('faction:', id) as sid,
+			'faction' as type,
+			name,
+			CASE 
+				WHEN hidden_by = 0 OR hidden_by = ? THEN false
+				ELSE true
+			END as hidden
+		FROM faction
+		WHERE game_id = ? AND deleted IS NULL`,
 		player.ID, player.CurrentGameID, player.ID, player.CurrentGameID, player.ID, player.CurrentGameID,
+		player.ID, player.CurrentGameID, player.ID, player.CurrentGameID,
 	).Scan(context.Background(), &suggestions)
 
 	if suggestions == nil {
@@ -801,7 +1846,16 @@ func (s *Storage) UpdateGameSettings(gameSettingsUpdate *reqData.GameSettingsUpd
 		AllowAllEditRecords: gameSettingsUpdate.AllowAllEditRecords,
 	}
 
-	_, err := s.db.NewUpdate().Model(&gameSettings).Column("allow_all_edit_records").WherePK().Returning("*").Exec(context.Background(), &gameSettings)
+	columns := []string{"allow_all_edit_records"}
+	if gameSettingsUpdate.SheetTemplate != nil {
+		if GetSheetTemplate(*gameSettingsUpdate.SheetTemplate) == nil {
+			return nil, fmt.Errorf("%w %q", ErrUnknownSheetTemplate, *gameSettingsUpdate.SheetTemplate)
+		}
+		gameSettings.SheetTemplate = *gameSettingsUpdate.SheetTemplate
+		columns = append(columns, "sheet_template")
+	}
+
+	_, err := s.db.NewUpdate().Model(&gameSettings).Column(columns...).WherePK().Returning("*").Exec(context.Background(), &gameSettings)
 	if err != nil {
 		return nil, err
 	}
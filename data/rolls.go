@@ -0,0 +1,216 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/dice"
+	gu "personae-fasti/gewi-utils"
+
+	"github.com/uptrace/bun"
+)
+
+// Rolls in the record text. A rolled one gets its result after = and is
+// not rolled again
+var (
+	recordRollRegexp   = regexp.MustCompile(`\[\[roll:([^\]=]+)\]\]`)
+	recordRolledRegexp = regexp.MustCompile(`\[\[roll:([^\]=]+)=([^\]]*)\]\]`)
+)
+
+// Roll is a dice roll of a player. GM-only rolls are seen by the GM and
+// the player who rolled
+type Roll struct {
+	bun.BaseModel `bun:"table:roll"`
+
+	ID int `bun:"id,pk,autoincrement" json:"id"`
+
+	GameID    int      `bun:"game_id,notnull" json:"gameID"`
+	SessionID int      `bun:"session_id,nullzero" json:"sessionID,omitempty"`
+	Session   *Session `bun:"rel:belongs-to,join:session_id=id" json:"-"`
+	PlayerID  int      `bun:"player_id,notnull" json:"playerID"`
+	Player    *Player  `bun:"rel:belongs-to,join:player_id=id" json:"-"`
+	CharID    int      `bun:"char_id,nullzero" json:"charID,omitempty"`
+	Char      *Char    `bun:"rel:belongs-to,join:char_id=id" json:"-"`
+	RecordID  int      `bun:"record_id,nullzero" json:"recordID,omitempty"`
+
+	Expression string       `bun:"expression,notnull" json:"expression"`
+	Reason     string       `bun:"reason,notnull,default:''" json:"reason"`
+	Result     *dice.Result `bun:"result,type:jsonb" json:"result"`
+	Total      int          `bun:"total,notnull" json:"total"`
+	GMOnly     bool         `bun:"gm_only,notnull,default:false" json:"gmOnly"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp" json:"created"`
+}
+
+type RollEventData struct {
+	ID         int    `json:"id"`
+	Expression string `json:"expression"`
+	Reason     string `json:"reason,omitempty"`
+	Total      int    `json:"total"`
+	Detail     string `json:"detail"`
+	CharID     int    `json:"charID,omitempty"`
+}
+
+// currentSession returns nil if no session is started
+func (s *Storage) currentSession(game *Game) (*Session, error) {
+	session, err := s.GetCurrentGameSession(game)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *Storage) CreateRoll(rollCreate *reqData.RollCreate, result *dice.Result, player *Player) (*Roll, error) {
+	session, err := s.currentSession(player.CurrentGame)
+	if err != nil {
+		return nil, err
+	}
+
+	roll := Roll{
+		GameID:     player.CurrentGameID,
+		Session:    session,
+		PlayerID:   player.ID,
+		CharID:     rollCreate.CharID,
+		Expression: result.Expression,
+		Reason:     rollCreate.Reason,
+		Result:     result,
+		Total:      result.Total,
+		GMOnly:     rollCreate.GMOnly,
+	}
+	if session != nil {
+		roll.SessionID = session.ID
+	}
+
+	_, err = s.db.NewInsert().Model(&roll).Returning("*").Exec(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	roll.Player = player
+
+	// The player who rolled gets the roll in the response
+	s.publish(Event{
+		Type:     RollCreatedEvent,
+		GameID:   roll.GameID,
+		PlayerID: player.ID,
+		Username: player.Username,
+		HiddenBy: gu.TernaryInt(roll.GMOnly, player.CurrentGame.GMID, 0),
+		Data: &RollEventData{
+			ID:         roll.ID,
+			Expression: roll.Expression,
+			Reason:     roll.Reason,
+			Total:      roll.Total,
+			Detail:     result.Detail(),
+			CharID:     roll.CharID,
+		},
+	})
+
+	return &roll, nil
+}
+
+// GetGameRolls returns the last rolls the player may see, of the session
+// if its number is not zero
+func (s *Storage) GetGameRolls(game *Game, player *Player, sessionNumber int, limit int) ([]Roll, error) {
+	rolls := []Roll{}
+	query := s.db.NewSelect().Model(&rolls).
+		Where("roll.game_id = ?", game.ID).
+		// Rolls of the records hidden from the player are hidden too
+		Where(`roll.record_id IS NULL OR EXISTS (
+			SELECT 1 FROM record WHERE record.id = roll.record_id AND record.deleted IS NULL AND record.hidden_by IN (0, ?)
+		)`, player.ID).
+		Relation("Player").
+		Relation("Session").
+		Relation("Char").
+		Order("roll.id DESC").
+		Limit(limit)
+
+	if game.GMID != player.ID {
+		query = query.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("roll.gm_only = false").WhereOr("roll.player_id = ?", player.ID)
+		})
+	}
+	if sessionNumber != 0 {
+		query = query.Where("session.number = ?", sessionNumber)
+	}
+
+	err := query.Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return rolls, nil
+}
+
+// rollRecordText rolls the [[roll:...]] of the text and puts the results
+// in it. Expressions that are not valid are left as typed. A rolled one
+// keeps its result only if one of the rolls of the record has it,
+// otherwise the result is dropped and the expression is rolled again
+func rollRecordText(text string, rolls []Roll) (string, []*dice.Result) {
+	rolled := map[string]int{}
+	for _, roll := range rolls {
+		rolled[fmt.Sprintf("%s = %d", roll.Expression, roll.Total)]++
+	}
+	text = recordRolledRegexp.ReplaceAllStringFunc(text, func(token string) string {
+		match := recordRolledRegexp.FindStringSubmatch(token)
+		expression := strings.TrimSpace(match[1])
+		result := expression + " = " + strings.TrimSpace(match[2])
+		if rolled[result] > 0 {
+			rolled[result]--
+			return token
+		}
+
+		return fmt.Sprintf("[[roll:%s]]", expression)
+	})
+
+	results := []*dice.Result{}
+	text = recordRollRegexp.ReplaceAllStringFunc(text, func(token string) string {
+		result, err := dice.Roll(recordRollRegexp.FindStringSubmatch(token)[1])
+		if err != nil {
+			return token
+		}
+		results = append(results, result)
+
+		return fmt.Sprintf("[[roll:%s]]", result)
+	})
+
+	return text, results
+}
+
+func insertRecordRolls(ctx context.Context, tx bun.Tx, record *Record, results []*dice.Result, session *Session) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	sessionID := 0
+	if session != nil {
+		sessionID = session.ID
+	}
+
+	rolls := []Roll{}
+	for _, result := range results {
+		rolls = append(rolls, Roll{
+			GameID:     record.GameID,
+			SessionID:  sessionID,
+			PlayerID:   record.PlayerID,
+			RecordID:   record.ID,
+			Expression: result.Expression,
+			Result:     result,
+			Total:      result.Total,
+		})
+	}
+
+	_, err := tx.NewInsert().Model(&rolls).Returning("*").Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to insert record rolls: %w", err)
+	}
+	record.Rolls = rolls
+
+	return nil
+}
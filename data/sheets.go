@@ -0,0 +1,324 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"personae-fasti/api/models/reqData"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	DefaultSheetTemplate = "generic"
+
+	maxSheetAbilities     = 24
+	maxSheetNameLength    = 100
+	maxSheetSections      = 20
+	maxSheetSectionLength = 8000
+)
+
+var ErrUnknownSheetTemplate = errors.New("unknown sheet template")
+
+// SheetTemplate is the system the character sheets of a game follow
+type SheetTemplate struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	// Any abilities are allowed if the list is empty
+	Abilities []string `json:"abilities"`
+	MinScore  int      `json:"minScore"`
+	MaxScore  int      `json:"maxScore"`
+	// The scores give D&D-like modifiers
+	Modifiers bool `json:"modifiers"`
+	// Systems without levels keep the level zero
+	Levels   bool `json:"levels"`
+	MaxLevel int  `json:"maxLevel,omitempty"`
+}
+
+var sheetTemplates = []SheetTemplate{
+	{
+		Key:       "dnd5e",
+		Name:      "D&D 5e",
+		Abilities: []string{"STR", "DEX", "CON", "INT", "WIS", "CHA"},
+		MinScore:  1,
+		MaxScore:  30,
+		Modifiers: true,
+		Levels:    true,
+		MaxLevel:  20,
+	},
+	{
+		Key:       "coc7e",
+		Name:      "Call of Cthulhu 7e",
+		Abilities: []string{"STR", "CON", "SIZ", "DEX", "APP", "INT", "POW", "EDU"},
+		MinScore:  0,
+		MaxScore:  100,
+	},
+	{
+		Key:      DefaultSheetTemplate,
+		Name:     "Generic",
+		MinScore: -1000,
+		MaxScore: 1000,
+		Levels:   true,
+	},
+}
+
+func GetSheetTemplates() []SheetTemplate {
+	return sheetTemplates
+}
+
+// GetSheetTemplate returns nil for an unknown key
+func GetSheetTemplate(key string) *SheetTemplate {
+	for i := range sheetTemplates {
+		if sheetTemplates[i].Key == key {
+			return &sheetTemplates[i]
+		}
+	}
+
+	return nil
+}
+
+type SheetSection struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// CharSheet is the optional structured sheet of a char. The template is
+// the one of the game when the sheet was saved last
+type CharSheet struct {
+	bun.BaseModel `bun:"table:char_sheet"`
+
+	CharID   int    `bun:"char_id,pk"`
+	GameID   int    `bun:"game_id,notnull"`
+	Template string `bun:"template,notnull"`
+
+	Level      int            `bun:"level,notnull,default:0"`
+	HP         int            `bun:"hp,notnull,default:0"`
+	MaxHP      int            `bun:"max_hp,notnull,default:0"`
+	Class      string         `bun:"class,notnull,default:''"`
+	Background string         `bun:"background,notnull,default:''"`
+	Abilities  map[string]int `bun:"abilities,type:jsonb"`
+	Sections   []SheetSection `bun:"sections,type:jsonb"`
+
+	Updated *time.Time `bun:"updated,nullzero,notnull,default:current_timestamp"`
+}
+
+// CharLevelUp is a level change of the char sheet
+type CharLevelUp struct {
+	bun.BaseModel `bun:"table:char_level_up"`
+
+	ID int `bun:"id,pk,autoincrement"`
+
+	CharID        int `bun:"char_id,notnull"`
+	GameID        int `bun:"game_id,notnull"`
+	PreviousLevel int `bun:"previous_level,notnull"`
+	Level         int `bun:"level,notnull"`
+
+	PlayerID  int      `bun:"player_id,notnull"`
+	Player    *Player  `bun:"rel:belongs-to,join:player_id=id"`
+	SessionID int      `bun:"session_id,nullzero"`
+	Session   *Session `bun:"rel:belongs-to,join:session_id=id"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp"`
+}
+
+// Modifier is the D&D-like modifier of the ability score
+func Modifier(score int) int {
+	if score < 10 {
+		return (score - 11) / 2
+	}
+
+	return (score - 10) / 2
+}
+
+// Validate checks the sheet against the template and trims its texts
+func (t *SheetTemplate) Validate(sheetUpdate *reqData.CharSheetUpdate) error {
+	if len(sheetUpdate.Abilities) > maxSheetAbilities {
+		return fmt.Errorf("sheet may have at most %d abilities", maxSheetAbilities)
+	}
+	for ability, score := range sheetUpdate.Abilities {
+		if len(t.Abilities) > 0 && !slices.Contains(t.Abilities, ability) {
+			return fmt.Errorf("%s sheet has no ability %q", t.Name, ability)
+		} else if strings.TrimSpace(ability) == "" || len(ability) > maxSheetNameLength {
+			return fmt.Errorf("ability name %q is invalid", ability)
+		} else if score < t.MinScore || score > t.MaxScore {
+			return fmt.Errorf("ability %s must be between %d and %d", ability, t.MinScore, t.MaxScore)
+		}
+	}
+
+	if !t.Levels && sheetUpdate.Level != 0 {
+		return fmt.Errorf("%s sheet has no levels", t.Name)
+	} else if t.Levels && sheetUpdate.Level < 1 {
+		return fmt.Errorf("level must be positive")
+	} else if t.MaxLevel > 0 && sheetUpdate.Level > t.MaxLevel {
+		return fmt.Errorf("level must be at most %d", t.MaxLevel)
+	}
+
+	if sheetUpdate.MaxHP < 0 {
+		return fmt.Errorf("max HP must not be negative")
+	} else if sheetUpdate.MaxHP > 0 && sheetUpdate.HP > sheetUpdate.MaxHP {
+		return fmt.Errorf("HP must be at most max HP %d", sheetUpdate.MaxHP)
+	}
+
+	sheetUpdate.Class = strings.TrimSpace(sheetUpdate.Class)
+	sheetUpdate.Background = strings.TrimSpace(sheetUpdate.Background)
+	if len(sheetUpdate.Class) > maxSheetNameLength || len(sheetUpdate.Background) > maxSheetNameLength {
+		return fmt.Errorf("class and background must be at most %d bytes", maxSheetNameLength)
+	}
+
+	if len(sheetUpdate.Sections) > maxSheetSections {
+		return fmt.Errorf("sheet may have at most %d sections", maxSheetSections)
+	}
+	for i := range sheetUpdate.Sections {
+		section := &sheetUpdate.Sections[i]
+		section.Title = strings.TrimSpace(section.Title)
+		if section.Title == "" || len(section.Title) > maxSheetNameLength {
+			return fmt.Errorf("section %d must have a title of at most %d bytes", i+1, maxSheetNameLength)
+		} else if len(section.Text) > maxSheetSectionLength {
+			return fmt.Errorf("section %q is longer than %d bytes", section.Title, maxSheetSectionLength)
+		}
+	}
+
+	return nil
+}
+
+// GameSheetTemplate returns the template the game chose
+func (s *Storage) GameSheetTemplate(game *Game) (*SheetTemplate, error) {
+	var key string
+	err := s.db.NewSelect().Model((*GameSettings)(nil)).
+		Column("sheet_template").
+		Where("game_id = ?", game.ID).
+		Scan(context.Background(), &key)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if template := GetSheetTemplate(key); template != nil {
+		return template, nil
+	}
+
+	return GetSheetTemplate(DefaultSheetTemplate), nil
+}
+
+// GetCharSheet returns nil if the char has no sheet
+func (s *Storage) GetCharSheet(charID int) (*CharSheet, error) {
+	var sheet CharSheet
+	err := s.db.NewSelect().Model(&sheet).
+		Where("char_id = ?", charID).
+		Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &sheet, nil
+}
+
+func (s *Storage) GetCharLevelUps(charID int) ([]CharLevelUp, error) {
+	levelUps := []CharLevelUp{}
+	err := s.db.NewSelect().Model(&levelUps).
+		Where("char_level_up.char_id = ?", charID).
+		Relation("Player").
+		Relation("Session").
+		Order("char_level_up.id ASC").
+		Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return levelUps, nil
+}
+
+// SaveCharSheet creates or replaces the sheet of the char as a change of
+// the char, so the char version guards the sheet too. A level change of
+// an existing sheet is saved to the history with the current session
+func (s *Storage) SaveCharSheet(char *Char, sheetUpdate *reqData.CharSheetUpdate, template *SheetTemplate, player *Player) (*CharSheet, error) {
+	session, err := s.currentSession(player.CurrentGame)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &CharSheet{
+		CharID:     char.ID,
+		GameID:     char.GameID,
+		Template:   template.Key,
+		Level:      sheetUpdate.Level,
+		HP:         sheetUpdate.HP,
+		MaxHP:      sheetUpdate.MaxHP,
+		Class:      sheetUpdate.Class,
+		Background: sheetUpdate.Background,
+		Abilities:  map[string]int{},
+		Sections:   []SheetSection{},
+	}
+	for ability, score := range sheetUpdate.Abilities {
+		sheet.Abilities[ability] = score
+	}
+	for _, section := range sheetUpdate.Sections {
+		sheet.Sections = append(sheet.Sections, SheetSection{Title: section.Title, Text: section.Text})
+	}
+
+	err = s.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := updateVersion(tx.NewUpdate().Model(char).WherePK(), sheetUpdate.Version).
+			Returning("*").Exec(ctx)
+		if err == nil {
+			err = checkVersion(result)
+		}
+		if err != nil {
+			return err
+		}
+
+		previous := CharSheet{}
+		err = tx.NewSelect().Model(&previous).Where("char_id = ?", char.ID).For("UPDATE").Scan(ctx)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		exists := err == nil
+
+		_, err = tx.NewInsert().Model(sheet).
+			On("CONFLICT (char_id) DO UPDATE").
+			Set("template = EXCLUDED.template").
+			Set("level = EXCLUDED.level").
+			Set("hp = EXCLUDED.hp").
+			Set("max_hp = EXCLUDED.max_hp").
+			Set("class = EXCLUDED.class").
+			Set("background = EXCLUDED.background").
+			Set("abilities = EXCLUDED.abilities").
+			Set("sections = EXCLUDED.sections").
+			Set("updated = current_timestamp").
+			Returning("*").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !exists || previous.Level == sheet.Level {
+			return nil
+		}
+		levelUp := CharLevelUp{
+			CharID:        char.ID,
+			GameID:        char.GameID,
+			PreviousLevel: previous.Level,
+			Level:         sheet.Level,
+			PlayerID:      player.ID,
+		}
+		if session != nil {
+			levelUp.SessionID = session.ID
+		}
+		_, err = tx.NewInsert().Model(&levelUp).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishEntityUpdate(char.GameID, player, char.HiddenBy, char.HiddenBy, &EntityEventData{
+		EntityType: CharEntity, ID: char.ID, Name: char.Name, Title: char.Title, Description: char.Description,
+	})
+
+	return sheet, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
 	"personae-fasti/opt"
@@ -16,6 +17,12 @@ import (
 
 type Storage struct {
 	db *bun.DB
+
+	// Webhook hosts allowed to resolve to private addresses
+	webhookHosts []string
+
+	listeners   []func(Event)
+	listenersMu sync.RWMutex
 }
 
 type Log struct {
@@ -41,7 +48,8 @@ func NewStorage(c *opt.Conf) *Storage {
 	db.AddQueryHook(bundebug.NewQueryHook(bundebug.WithVerbose(true)))
 
 	storage := &Storage{
-		db: db,
+		db:           db,
+		webhookHosts: c.Webhooks.AllowedHosts,
 	}
 	storage.InitTables()
 
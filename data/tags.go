@@ -0,0 +1,378 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+type Tag struct {
+	bun.BaseModel `bun:"table:tag"`
+
+	ID     int    `bun:"id,pk,autoincrement"`
+	GameID int    `bun:"game_id,notnull,unique:game_tag_name"`
+	Game   *Game  `bun:"rel:belongs-to,join:game_id=id"`
+	Name   string `bun:"name,notnull,unique:game_tag_name"`
+}
+
+type EntityTag struct {
+	bun.BaseModel `bun:"table:entity_tag"`
+
+	TagID      int    `bun:"tag_id,pk"`
+	Tag        *Tag   `bun:"rel:belongs-to,join:tag_id=id"`
+	EntityType string `bun:"entity_type,pk"`
+	EntityID   int    `bun:"entity_id,pk"`
+	GameID     int    `bun:"game_id,notnull"`
+}
+
+type TagUsage struct {
+	ID    int    `bun:"id"`
+	Name  string `bun:"name"`
+	Count int    `bun:"count"`
+}
+
+var taggedEntities = []string{RecordEntity, CharEntity, NPCEntity, LocationEntity, QuestEntity}
+
+var tagRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/#])#([\p{L}\p{N}_-]+)`)
+
+// ParseTags finds #tag marks in text skipping @type:id`name` mentions
+func ParseTags(text string) []string {
+	var tags []string
+	for _, match := range tagRegexp.FindAllStringSubmatch(mentionRegexp.ReplaceAllString(text, ""), -1) {
+		tags = append(tags, match[1])
+	}
+
+	return tags
+}
+
+func normalizeTags(names []string) []string {
+	tags := []string{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "#")))
+		if name != "" && !slices.Contains(tags, name) {
+			tags = append(tags, name)
+		}
+	}
+
+	return tags
+}
+
+// SetEntityTags replaces tags of the entity creating missing game tags
+func (s *Storage) SetEntityTags(ctx context.Context, db bun.IDB, gameID int, entityType string, entityID int, names []string) error {
+	if !slices.Contains(taggedEntities, entityType) {
+		return fmt.Errorf("tags are not supported for %q", entityType)
+	}
+
+	_, err := db.NewDelete().Model((*EntityTag)(nil)).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete tags: %w", err)
+	}
+
+	names = normalizeTags(names)
+	if len(names) == 0 {
+		return nil
+	}
+
+	tags := make([]Tag, len(names))
+	for i, name := range names {
+		tags[i] = Tag{GameID: gameID, Name: name}
+	}
+
+	_, err = db.NewInsert().Model(&tags).
+		On("CONFLICT (game_id, name) DO UPDATE").
+		Set("name = EXCLUDED.name").
+		Returning("id").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to insert tags: %w", err)
+	}
+
+	entityTags := make([]EntityTag, len(tags))
+	for i, tag := range tags {
+		entityTags[i] = EntityTag{TagID: tag.ID, EntityType: entityType, EntityID: entityID, GameID: gameID}
+	}
+
+	_, err = db.NewInsert().Model(&entityTags).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to tag %s %d: %w", entityType, entityID, err)
+	}
+
+	return nil
+}
+
+// UpdateEntityTags replaces tags when they are present in the request
+func (s *Storage) UpdateEntityTags(gameID int, entityType string, entityID int, names []string) error {
+	if names == nil {
+		return nil
+	}
+
+	return s.SetEntityTags(context.Background(), s.db, gameID, entityType, entityID, names)
+}
+
+func (s *Storage) GetEntityTags(entityType string, entityID int) ([]string, error) {
+	tags, err := s.GetEntitiesTags(entityType, []int{entityID})
+	if err != nil {
+		return nil, err
+	}
+
+	if tags[entityID] == nil {
+		return []string{}, nil
+	}
+
+	return tags[entityID], nil
+}
+
+// GetEntitiesTags returns tag names by entity ID
+func (s *Storage) GetEntitiesTags(entityType string, entityIDs []int) (map[int][]string, error) {
+	tags := map[int][]string{}
+	if len(entityIDs) == 0 {
+		return tags, nil
+	}
+
+	var entityTags []EntityTag
+	err := s.db.NewSelect().Model(&entityTags).
+		Relation("Tag").
+		Where("entity_tag.entity_type = ? AND entity_tag.entity_id IN (?)", entityType, bun.In(entityIDs)).
+		Order("tag.name ASC").
+		Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	for _, entityTag := range entityTags {
+		tags[entityTag.EntityID] = append(tags[entityTag.EntityID], entityTag.Tag.Name)
+	}
+
+	return tags, nil
+}
+
+// FillRecordTags sets tags of every record in place
+func (s *Storage) FillRecordTags(records []Record) error {
+	ids := make([]int, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+
+	tags, err := s.GetEntitiesTags(RecordEntity, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range records {
+		records[i].Tags = tags[records[i].ID]
+		if records[i].Tags == nil {
+			records[i].Tags = []string{}
+		}
+	}
+
+	return nil
+}
+
+// GetGameTags counts only the usages on the entities the player sees and
+// skips the tags without such usages
+func (s *Storage) GetGameTags(gameID int, player *Player) ([]TagUsage, error) {
+	tags := []TagUsage{}
+
+	err := s.db.NewSelect().
+		TableExpr("tag").
+		ColumnExpr("tag.id, tag.name, COUNT(entity_tag.tag_id) AS count").
+		Join("JOIN entity_tag ON entity_tag.tag_id = tag.id").
+		Where("tag.game_id = ?", gameID).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			for _, entityType := range taggedEntities {
+				q = q.WhereOr(
+					"entity_tag.entity_type = ? AND EXISTS (SELECT 1 FROM ? AS entity WHERE entity.id = entity_tag.entity_id AND entity.deleted IS NULL AND entity.hidden_by IN (0, ?))",
+					entityType, bun.Ident(entityType), player.ID,
+				)
+			}
+			return q
+		}).
+		Group("tag.id", "tag.name").
+		Order("count DESC", "tag.name ASC").
+		Scan(context.Background(), &tags)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (s *Storage) GetTagByID(tagID int) (*Tag, error) {
+	tag := Tag{ID: tagID}
+
+	err := s.db.NewSelect().Model(&tag).WherePK().Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// RenameTag merges the tag into an existing one if the new name is taken
+func (s *Storage) RenameTag(tag *Tag, name string) (*Tag, error) {
+	names := normalizeTags([]string{name})
+	if len(names) == 0 {
+		return nil, fmt.Errorf("tag name cannot be empty")
+	}
+
+	existing := Tag{}
+	err := s.db.NewSelect().Model(&existing).Where("game_id = ? AND name = ?", tag.GameID, names[0]).Scan(context.Background())
+	if err == nil && existing.ID != tag.ID {
+		return s.MergeTags([]*Tag{tag}, &existing)
+	} else if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	err = s.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		err := retagRecordTexts(ctx, tx, tag.ID, []string{tag.Name}, names[0])
+		if err != nil {
+			return err
+		}
+
+		tag.Name = names[0]
+		_, err = tx.NewUpdate().Model(tag).Column("name").WherePK().Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// MergeTags moves every usage of the sources to the target and removes the sources
+func (s *Storage) MergeTags(sources []*Tag, target *Tag) (*Tag, error) {
+	var sourceIDs []int
+	var sourceNames []string
+	for _, source := range sources {
+		if source.GameID != target.GameID {
+			return nil, fmt.Errorf("tag %d is not from the game %d", source.ID, target.GameID)
+		}
+		if source.ID != target.ID {
+			sourceIDs = append(sourceIDs, source.ID)
+			sourceNames = append(sourceNames, source.Name)
+		}
+	}
+	if len(sourceIDs) == 0 {
+		return target, nil
+	}
+
+	ctx := context.Background()
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewRaw(
+			`INSERT INTO entity_tag (tag_id, entity_type, entity_id, game_id)
+			SELECT ?, entity_type, entity_id, game_id FROM entity_tag WHERE tag_id IN (?)
+			ON CONFLICT DO NOTHING`,
+			target.ID, bun.In(sourceIDs),
+		).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to move tag usages: %w", err)
+		}
+
+		_, err = tx.NewDelete().Model((*EntityTag)(nil)).Where("tag_id IN (?)", bun.In(sourceIDs)).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete merged tag usages: %w", err)
+		}
+
+		_, err = tx.NewDelete().Model((*Tag)(nil)).Where("id IN (?)", bun.In(sourceIDs)).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete merged tags: %w", err)
+		}
+
+		return retagRecordTexts(ctx, tx, target.ID, sourceNames, target.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+// retagRecordTexts replaces the #tag marks of the names in the record texts
+// tagged with the tag, so the tags parsed on the next edit stay the same
+func retagRecordTexts(ctx context.Context, tx bun.Tx, tagID int, names []string, name string) error {
+	var records []Record
+	err := tx.NewSelect().Model(&records).
+		Column("id", "text").
+		Where("EXISTS (SELECT 1 FROM entity_tag WHERE entity_tag.entity_type = ? AND entity_tag.entity_id = record.id AND entity_tag.tag_id = ?)", RecordEntity, tagID).
+		For("UPDATE").
+		Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get tagged records: %w", err)
+	}
+
+	for _, record := range records {
+		text := ReplaceTag(record.Text, names, name)
+		if text == record.Text {
+			continue
+		}
+
+		_, err = tx.NewUpdate().Model((*Record)(nil)).
+			Set("text = ?", text).
+			Set("version = version + 1").
+			Where("id = ?", record.ID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to retag record %d: %w", record.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// ReplaceTag changes the #tag marks of any of the names to the name
+// keeping the @type:id`name` mentions intact
+func ReplaceTag(text string, names []string, name string) string {
+	mentions := mentionRegexp.FindAllStringIndex(text, -1)
+
+	var result strings.Builder
+	last := 0
+	for _, match := range tagRegexp.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+		inMention := slices.ContainsFunc(mentions, func(mention []int) bool {
+			return start >= mention[0] && start < mention[1]
+		})
+		if inMention || !slices.Contains(names, strings.ToLower(text[start:end])) {
+			continue
+		}
+
+		result.WriteString(text[last:start])
+		result.WriteString(name)
+		last = end
+	}
+	result.WriteString(text[last:])
+
+	return result.String()
+}
+
+// FilterEntitiesByTags returns IDs of entities having every tag
+func (s *Storage) FilterEntitiesByTags(gameID int, entityType string, names []string) ([]int, error) {
+	ids := []int{}
+	names = normalizeTags(names)
+	if len(names) == 0 {
+		return ids, nil
+	}
+
+	err := s.db.NewSelect().Model((*EntityTag)(nil)).
+		Column("entity_tag.entity_id").
+		Join("JOIN tag ON tag.id = entity_tag.tag_id").
+		Where("entity_tag.game_id = ? AND entity_tag.entity_type = ?", gameID, entityType).
+		Where("tag.name IN (?)", bun.In(names)).
+		Group("entity_tag.entity_id").
+		Having("COUNT(DISTINCT tag.id) = ?", len(names)).
+		Scan(context.Background(), &ids)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,207 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	telegramLinkTTL = 15 * time.Minute
+	DefaultLang     = "en"
+)
+
+var ErrTelegramLink = errors.New("telegram link code is invalid or expired")
+
+// TelegramLink is a one-time code the player sends to the bot
+// to link the Telegram account
+type TelegramLink struct {
+	bun.BaseModel `bun:"table:telegram_link"`
+
+	Code     string     `bun:"code,pk"`
+	PlayerID int        `bun:"player_id,notnull"`
+	Expires  *time.Time `bun:"expires,notnull"`
+}
+
+// Lang is the preferred language of the player taken from the linked
+// Telegram account
+func (p *Player) Lang() string {
+	if p.Telegram == nil || p.Telegram.Lang == "" {
+		return DefaultLang
+	}
+
+	return p.Telegram.Lang
+}
+
+func (s *Storage) CreateTelegramLink(player *Player) (*TelegramLink, error) {
+	code := make([]byte, 8)
+	if _, err := rand.Read(code); err != nil {
+		return nil, err
+	}
+
+	expires := time.Now().UTC().Add(telegramLinkTTL)
+	link := &TelegramLink{
+		Code:     hex.EncodeToString(code),
+		PlayerID: player.ID,
+		Expires:  &expires,
+	}
+
+	err := s.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		// Only the last code of the player is valid
+		_, err := tx.NewDelete().Model((*TelegramLink)(nil)).Where("player_id = ? OR expires < now()", player.ID).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewInsert().Model(link).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// LinkTelegram links the account to the player of the code. The account
+// is unlinked from any other player before
+func (s *Storage) LinkTelegram(code string, telegram *Telegram) (*Player, error) {
+	var player Player
+
+	err := s.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		var link TelegramLink
+		err := tx.NewSelect().Model(&link).Where("code = ? AND expires > now()", code).Scan(ctx)
+		if err == sql.ErrNoRows {
+			return ErrTelegramLink
+		} else if err != nil {
+			return err
+		}
+
+		_, err = tx.NewInsert().Model(telegram).
+			On("CONFLICT (id) DO UPDATE").
+			Set("username = EXCLUDED.username").
+			Set("lang = EXCLUDED.lang").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().Model((*Player)(nil)).Set("telegram_id = 0").Where("telegram_id = ?", telegram.ID).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().Model((*Player)(nil)).Set("telegram_id = ?", telegram.ID).Where("id = ?", link.PlayerID).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewDelete().Model(&link).WherePK().Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return tx.NewSelect().Model(&player).Where("player.id = ?", link.PlayerID).Relation("CurrentGame").Relation("Telegram").Scan(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &player, nil
+}
+
+func (s *Storage) UnlinkTelegram(player *Player) error {
+	_, err := s.db.NewUpdate().Model((*Player)(nil)).Set("telegram_id = 0").Where("id = ?", player.ID).Exec(context.Background())
+	return err
+}
+
+func (s *Storage) GetPlayerByTelegramID(telegramID int64) (*Player, error) {
+	var player Player
+
+	err := s.db.NewSelect().Model(&player).
+		Where("player.telegram_id = ? AND player.deleted IS NULL", telegramID).
+		Relation("CurrentGame.Settings").
+		Relation("CurrentGame.Sessions").
+		Relation("Telegram").
+		Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &player, nil
+}
+
+func (s *Storage) UpdateTelegram(telegram *Telegram) error {
+	_, err := s.db.NewUpdate().Model(telegram).Column("username", "lang").WherePK().Exec(context.Background())
+	return err
+}
+
+// GetLastRecordsForPlayer returns the newest records of the game first
+func (s *Storage) GetLastRecordsForPlayer(game *Game, player *Player, limit int) ([]Record, error) {
+	records, err := s.GetCurrentGameRecordsForPlayer(game, player)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(records, func(a, b Record) int { return b.Created.Compare(*a.Created) })
+	if len(records) > limit {
+		records = records[:limit]
+	}
+
+	return records, nil
+}
+
+func (s *Storage) GetActiveQuestsForPlayer(game *Game, player *Player) ([]Quest, error) {
+	var quests []Quest
+	err := s.db.NewSelect().Model(&quests).
+		Where("quest.game_id = ? AND quest.deleted IS NULL AND quest.finished IS NULL", game.ID).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("quest.hidden_by = 0").WhereOr("quest.hidden_by = ?", player.ID)
+		}).
+		Relation("Tasks", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.Where("hidden_by = 0").WhereOr("hidden_by = ?", player.ID)
+			})
+		}).
+		Order("quest.created").
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return quests, nil
+}
+
+// FindNPCsForPlayer looks up the visible NPCs by a part of the name
+func (s *Storage) FindNPCsForPlayer(game *Game, player *Player, name string, limit int) ([]NPC, error) {
+	var npcs []NPC
+	err := s.db.NewSelect().Model(&npcs).
+		Where("game_id = ? AND deleted IS NULL", game.ID).
+		Where("name ILIKE ?", "%"+escapeLike(name)+"%").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("hidden_by = 0").WhereOr("hidden_by = ?", player.ID)
+		}).
+		OrderExpr("length(name)").
+		Limit(limit).
+		Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return npcs, nil
+}
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeReplacer.Replace(s)
+}
@@ -0,0 +1,387 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"personae-fasti/api/models/reqData"
+	gu "personae-fasti/gewi-utils"
+)
+
+const (
+	TimelineRecord        = "record"
+	TimelineSessionStart  = "session_start"
+	TimelineSessionEnd    = "session_end"
+	TimelineQuestStarted  = "quest_started"
+	TimelineQuestFinished = "quest_finished"
+	TimelineEventEntry    = "event"
+)
+
+type TimelineEntry struct {
+	Type string
+	ID   int
+	Name string
+	Text string
+
+	WorldStart *WorldDate
+	WorldEnd   *WorldDate
+	// Derived is set when the in-world date is taken from the session
+	Derived bool
+
+	SessionNumber *int
+	Time          *time.Time
+}
+
+func WorldDateFromRequest(d *reqData.WorldDate) *WorldDate {
+	if d == nil {
+		return nil
+	}
+
+	return &WorldDate{Year: d.Year, Month: d.Month, Day: d.Day}
+}
+
+func (s *Storage) GetGameCalendar(gameID int) (*GameCalendar, error) {
+	calendar := GameCalendar{GameID: gameID}
+
+	err := s.db.NewSelect().Model(&calendar).WherePK().Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return DefaultCalendar(gameID), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &calendar, nil
+}
+
+func (s *Storage) UpdateGameCalendar(calendarUpdate *reqData.GameCalendarUpdate, game *Game) (*GameCalendar, error) {
+	calendar := GameCalendar{
+		GameID:     game.ID,
+		WeekLength: calendarUpdate.WeekLength,
+		Weekdays:   calendarUpdate.Weekdays,
+		Months:     []CalendarMonth{},
+		Eras:       []CalendarEra{},
+	}
+	for _, month := range calendarUpdate.Months {
+		calendar.Months = append(calendar.Months, CalendarMonth{Name: month.Name, Days: month.Days})
+	}
+	for _, era := range calendarUpdate.Eras {
+		calendar.Eras = append(calendar.Eras, CalendarEra{Name: era.Name, StartYear: era.StartYear})
+	}
+	if calendar.Weekdays == nil {
+		calendar.Weekdays = []string{}
+	}
+
+	if err := calendar.Validate(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.db.NewInsert().Model(&calendar).
+		On("CONFLICT (game_id) DO UPDATE").
+		Set("months = EXCLUDED.months").
+		Set("week_length = EXCLUDED.week_length").
+		Set("weekdays = EXCLUDED.weekdays").
+		Set("eras = EXCLUDED.eras").
+		Exec(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return &calendar, nil
+}
+
+func (s *Storage) validateWorldRange(gameID int, start, end *WorldDate) error {
+	if start == nil && end == nil {
+		return nil
+	}
+
+	calendar, err := s.GetGameCalendar(gameID)
+	if err != nil {
+		return err
+	}
+
+	return calendar.ValidateRange(start, end)
+}
+
+func (s *Storage) UpdateGameSession(sessionUpdate *reqData.GameSessionUpdate, game *Game) (*Session, error) {
+	session := Session{}
+	err := s.db.NewSelect().Model(&session).Where("game_id = ? AND number = ?", game.ID, sessionUpdate.Number).Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	session.Name = sessionUpdate.Name
+	session.WorldStart = WorldDateFromRequest(sessionUpdate.WorldStart)
+	session.WorldEnd = WorldDateFromRequest(sessionUpdate.WorldEnd)
+	if err := s.validateWorldRange(game.ID, session.WorldStart, session.WorldEnd); err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.NewUpdate().Model(&session).Column("name", "world_start", "world_end").WherePK().Exec(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(Event{
+		Type:     SessionUpdatedEvent,
+		GameID:   game.ID,
+		PlayerID: game.GMID,
+		Data:     &SessionEventData{Number: session.Number, Name: session.Name},
+	})
+
+	return &session, nil
+}
+
+func (s *Storage) GetTimelineEventByID(eventID int) (*TimelineEvent, error) {
+	event := TimelineEvent{ID: eventID}
+
+	err := s.db.NewSelect().Model(&event).WherePK().Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func (s *Storage) CreateTimelineEvent(eventCreate *reqData.TimelineEventCreate, player *Player) (*TimelineEvent, error) {
+	event := TimelineEvent{
+		Name:        eventCreate.Name,
+		Description: eventCreate.Description,
+		WorldStart:  WorldDateFromRequest(eventCreate.WorldStart),
+		WorldEnd:    WorldDateFromRequest(eventCreate.WorldEnd),
+		GameID:      player.CurrentGameID,
+		CreatedByID: player.ID,
+		HiddenBy:    gu.TernaryInt(eventCreate.Hidden, player.ID, 0),
+	}
+
+	if event.WorldStart == nil {
+		return nil, fmt.Errorf("timeline event must have an in-world date")
+	}
+	if err := s.validateWorldRange(event.GameID, event.WorldStart, event.WorldEnd); err != nil {
+		return nil, err
+	}
+
+	_, err := s.db.NewInsert().Model(&event).Returning("*").Exec(context.Background())
+
+	return &event, err
+}
+
+func (s *Storage) UpdateTimelineEvent(eventUpdate *reqData.TimelineEventUpdate, event *TimelineEvent, player *Player) (*TimelineEvent, error) {
+	event.Name = eventUpdate.Name
+	event.Description = eventUpdate.Description
+	event.WorldStart = WorldDateFromRequest(eventUpdate.WorldStart)
+	event.WorldEnd = WorldDateFromRequest(eventUpdate.WorldEnd)
+	event.HiddenBy = gu.TernaryInt(eventUpdate.Hidden, player.ID, 0)
+
+	if event.WorldStart == nil {
+		return nil, fmt.Errorf("timeline event must have an in-world date")
+	}
+	if err := s.validateWorldRange(event.GameID, event.WorldStart, event.WorldEnd); err != nil {
+		return nil, err
+	}
+
+	result, err := updateVersion(s.db.NewUpdate().Model(event).WherePK().
+		Set("name = ?", event.Name).
+		Set("description = ?", event.Description).
+		Set("world_start = ?", event.WorldStart).
+		Set("world_end = ?", event.WorldEnd).
+		Set("hidden_by = ?", event.HiddenBy), eventUpdate.Version).
+		Returning("version").Exec(context.Background())
+	if err == nil {
+		err = checkVersion(result)
+	}
+
+	return event, err
+}
+
+func (s *Storage) DeleteTimelineEvent(event *TimelineEvent) error {
+	now := time.Now().UTC()
+	event.Deleted = &now
+
+	_, err := s.db.NewUpdate().Model(event).Column("deleted").WherePK().Exec(context.Background())
+	return err
+}
+
+// GetTimeline merges everything visible to the player in in-world order.
+// Entries without own in-world date take it from the session they happened in,
+// entries still undated are placed after the dated ones by real time
+func (s *Storage) GetTimeline(game *Game, player *Player) ([]TimelineEntry, error) {
+	ctx := context.Background()
+	entries := []TimelineEntry{}
+
+	sessions, err := s.GetCurrentGameSessions(game)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(sessions, func(a, b Session) int { return a.Number - b.Number })
+
+	for _, session := range sessions {
+		number := session.Number
+		if session.WorldStart != nil {
+			entries = append(entries, TimelineEntry{
+				Type:          TimelineSessionStart,
+				ID:            session.ID,
+				Name:          session.Name,
+				WorldStart:    session.WorldStart,
+				SessionNumber: &number,
+			})
+		}
+		if session.WorldEnd != nil {
+			entries = append(entries, TimelineEntry{
+				Type:          TimelineSessionEnd,
+				ID:            session.ID,
+				Name:          session.Name,
+				WorldStart:    session.WorldEnd,
+				SessionNumber: &number,
+				Time:          session.EndTime,
+			})
+		}
+	}
+
+	records, err := s.GetCurrentGameRecordsForPlayer(game, player)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		entries = append(entries, TimelineEntry{
+			Type:       TimelineRecord,
+			ID:         record.ID,
+			Text:       record.Text,
+			WorldStart: record.WorldStart,
+			WorldEnd:   record.WorldEnd,
+			Time:       record.Created,
+		})
+	}
+
+	quests, err := s.GetCurrentGameQuests(game)
+	if err != nil {
+		return nil, err
+	}
+	for _, quest := range quests {
+		if quest.HiddenBy != 0 && quest.HiddenBy != player.ID {
+			continue
+		}
+		entries = append(entries, TimelineEntry{
+			Type: TimelineQuestStarted,
+			ID:   quest.ID,
+			Name: quest.Name,
+			Time: quest.Created,
+		})
+		if quest.Finished != nil {
+			entries = append(entries, TimelineEntry{
+				Type: TimelineQuestFinished,
+				ID:   quest.ID,
+				Name: quest.Name,
+				Text: gu.Ternary(quest.Successful, "successful", "failed").(string),
+				Time: quest.Finished,
+			})
+		}
+	}
+
+	var events []TimelineEvent
+	err = s.db.NewSelect().Model(&events).
+		Where("game_id = ? AND deleted IS NULL", game.ID).
+		Where("hidden_by = 0 OR hidden_by = ?", player.ID).
+		Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	for _, event := range events {
+		entries = append(entries, TimelineEntry{
+			Type:       TimelineEventEntry,
+			ID:         event.ID,
+			Name:       event.Name,
+			Text:       event.Description,
+			WorldStart: event.WorldStart,
+			WorldEnd:   event.WorldEnd,
+			Time:       event.Created,
+		})
+	}
+
+	for i := range entries {
+		if entries[i].SessionNumber != nil || entries[i].Time == nil {
+			continue
+		}
+		session := sessionAtTime(sessions, *entries[i].Time)
+		if session == nil {
+			continue
+		}
+		number := session.Number
+		entries[i].SessionNumber = &number
+		if entries[i].WorldStart == nil && session.WorldStart != nil {
+			entries[i].WorldStart = session.WorldStart
+			entries[i].Derived = true
+		}
+	}
+
+	slices.SortStableFunc(entries, func(a, b TimelineEntry) int {
+		if c := a.WorldStart.Compare(b.WorldStart); c != 0 {
+			return c
+		}
+		// Entries without the time go after the others
+		switch {
+		case a.Time != nil && b.Time != nil:
+			if c := a.Time.Compare(*b.Time); c != 0 {
+				return c
+			}
+		case a.Time != nil:
+			return -1
+		case b.Time != nil:
+			return 1
+		}
+		if c := cmp.Compare(a.Type, b.Type); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return entries, nil
+}
+
+// sessionAtTime finds the session that was running at the moment t
+func sessionAtTime(sessions []Session, t time.Time) *Session {
+	for i := range sessions {
+		if sessions[i].EndTime == nil || sessions[i].EndTime.After(t) {
+			return &sessions[i]
+		}
+	}
+
+	return nil
+}
+
+// GetSessionRecordsForPlayer returns records written while the session was running
+func (s *Storage) GetSessionRecordsForPlayer(game *Game, player *Player, number int) (*Session, []Record, error) {
+	sessions, err := s.GetCurrentGameSessions(game)
+	if err != nil {
+		return nil, nil, err
+	}
+	slices.SortFunc(sessions, func(a, b Session) int { return cmp.Compare(a.Number, b.Number) })
+
+	i := slices.IndexFunc(sessions, func(session Session) bool { return session.Number == number })
+	if i < 0 {
+		return nil, nil, nil
+	}
+
+	records, err := s.GetCurrentGameRecordsForPlayer(game, player)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sessionRecords := []Record{}
+	for _, record := range records {
+		if session := sessionAtTime(sessions, *record.Created); session != nil && session.ID == sessions[i].ID {
+			sessionRecords = append(sessionRecords, record)
+		}
+	}
+	slices.SortFunc(sessionRecords, func(a, b Record) int { return a.Created.Compare(*b.Created) })
+
+	return &sessions[i], sessionRecords, nil
+}
@@ -1,5 +1,12 @@
 package data
 
+import "errors"
+
+var (
+	ErrQuestFinished = errors.New("quest is already finished")
+	ErrItemQuantity  = errors.New("item quantity must be positive")
+)
+
 type Suggestion struct {
 	ID       int    `bun:"id" json:"id"`
 	StringID string `bun:"sid" json:"sid"`
@@ -6,17 +6,22 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/uptrace/bun"
 )
 
+var mentionRegexp = regexp.MustCompile(`@(?P<type>\w+):(?P<id>\d+)` + "`(?P<name>[^`]+)`")
+
+// StripMentions leaves only the names of mentions
+func StripMentions(text string) string {
+	return mentionRegexp.ReplaceAllString(text, "$name")
+}
+
 func (s *Storage) InsertMentionsForRecord(record *Record) error {
-	re, err := regexp.Compile(`@(?P<type>\w+):(?P<id>\d+)` + "`(?P<name>[^`]+)`")
-	if err != nil {
-		return err
-	}
+	var err error
 
-	matches := re.FindAllStringSubmatch(record.Text, -1)
+	matches := mentionRegexp.FindAllStringSubmatch(record.Text, -1)
 	for _, match := range matches {
 		// Parse mention ID
 		id, err := strconv.Atoi(match[2]) //ParseInt(match[2], 10, 64)
@@ -34,6 +39,12 @@ func (s *Storage) InsertMentionsForRecord(record *Record) error {
 		case "location":
 			_, err = s.db.NewInsert().Model(&RecordLocation{RecordID: record.ID, LocationID: id}).Exec(context.Background())
 			break
+		case "item":
+			_, err = s.db.NewInsert().Model(&RecordItem{RecordID: record.ID, ItemID: id}).Exec(context.Background())
+			break
+		case "faction":
+			_, err = s.db.NewInsert().Model(&RecordFaction{RecordID: record.ID, FactionID: id}).Exec(context.Background())
+			break
 		default:
 			fmt.Printf("error during record mention extracting: mention %s is incorrect in record %d", match[0], record.ID)
 			// add error logger
@@ -61,6 +72,14 @@ func (s *Storage) DeleteMentionsForRecord(record *Record) error {
 	if err != nil {
 		return err
 	}
+	_, err = s.db.NewDelete().Model(&RecordItem{}).Where("record_id = ?", record.ID).Exec(context.Background())
+	if err != nil {
+		return err
+	}
+	_, err = s.db.NewDelete().Model(&RecordFaction{}).Where("record_id = ?", record.ID).Exec(context.Background())
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -79,6 +98,25 @@ func (s *Storage) GetAllowedRecords(records []Record, playerID int) ([]Record, e
 	return records, nil
 }
 
+func (s *Storage) GetAllowedChars(chars []Char, playerID int) ([]Char, error) {
+	if len(chars) == 0 {
+		return []Char{}, nil
+	}
+
+	err := s.db.NewSelect().Model(&chars).WherePK().
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("hidden_by = 0").WhereOr("hidden_by = ?", playerID)
+		}).
+		Scan(context.Background(), &chars)
+	if err != nil {
+		return nil, err
+	} else if err == sql.ErrNoRows {
+		return []Char{}, nil
+	}
+
+	return chars, nil
+}
+
 func (s *Storage) GetAllowedNPCs(npcs []NPC, playerID int) ([]NPC, error) {
 	err := s.db.NewSelect().Model(&npcs).WherePK().
 		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
@@ -109,6 +147,44 @@ func (s *Storage) GetAllowedLocations(locations []Location, playerID int) ([]Loc
 	return locations, nil
 }
 
+func (s *Storage) GetAllowedItems(items []Item, playerID int) ([]Item, error) {
+	if len(items) == 0 {
+		return []Item{}, nil
+	}
+
+	err := s.db.NewSelect().Model(&items).WherePK().
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("hidden_by = 0").WhereOr("hidden_by = ?", playerID)
+		}).
+		Scan(context.Background(), &items)
+	if err != nil {
+		return nil, err
+	} else if err == sql.ErrNoRows {
+		return []Item{}, nil
+	}
+
+	return items, nil
+}
+
+func (s *Storage) GetAllowedFactions(factions []Faction, playerID int) ([]Faction, error) {
+	if len(factions) == 0 {
+		return []Faction{}, nil
+	}
+
+	err := s.db.NewSelect().Model(&factions).WherePK().
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("hidden_by = 0").WhereOr("hidden_by = ?", playerID)
+		}).
+		Scan(context.Background(), &factions)
+	if err != nil {
+		return nil, err
+	} else if err == sql.ErrNoRows {
+		return []Faction{}, nil
+	}
+
+	return factions, nil
+}
+
 func (s *Storage) GetAllowedQuests(quests []Quest, playerID int) ([]Quest, error) {
 	err := s.db.NewSelect().Model(&quests).WherePK().
 		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
@@ -123,3 +199,93 @@ func (s *Storage) GetAllowedQuests(quests []Quest, playerID int) ([]Quest, error
 
 	return quests, nil
 }
+
+func formTaskProgressText(quest *Quest, tasks []QuestTask, progress []*QuestTaskProgress) string {
+	var lines []string
+	for i, task := range tasks {
+		line := fmt.Sprintf("%s: %d → %d", task.Name, progress[i].Previous, progress[i].Current)
+		if task.Type == Decimal {
+			line = fmt.Sprintf("%s: %d → %d/%d", task.Name, progress[i].Previous, progress[i].Current, task.Capacity)
+		}
+		if progress[i].Finished {
+			line += " (finished)"
+		}
+		lines = append(lines, line)
+	}
+
+	return fmt.Sprintf("Quest progress — %s\n%s", quest.Name, strings.Join(lines, "\n"))
+}
+
+func uniqueInts(values []int) []int {
+	seen := make(map[int]bool, len(values))
+	unique := []int{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+
+	return unique
+}
+
+// AllowedQuestRewards hides rewards kept secret until the quest is finished
+// from everyone except GM
+func AllowedQuestRewards(quest *Quest, player *Player) []QuestReward {
+	rewards := []QuestReward{}
+	isGM := player.CurrentGame != nil && player.CurrentGame.GMID == player.ID
+	for _, reward := range quest.Rewards {
+		if reward.HiddenBy != 0 && reward.HiddenBy != player.ID {
+			continue
+		}
+		if reward.HiddenUntilFinished && quest.Finished == nil && !isGM {
+			continue
+		}
+		rewards = append(rewards, reward)
+	}
+
+	return rewards
+}
+
+// AllowedFactionMembers skips members hidden from the player
+func AllowedFactionMembers(faction *Faction, playerID int) []FactionMember {
+	members := []FactionMember{}
+	for _, member := range faction.Members {
+		if member.Char != nil && member.Char.HiddenBy != 0 && member.Char.HiddenBy != playerID {
+			continue
+		}
+		if member.NPC != nil && member.NPC.HiddenBy != 0 && member.NPC.HiddenBy != playerID {
+			continue
+		}
+		members = append(members, member)
+	}
+
+	return members
+}
+
+// AllowedFactionStandings skips standings of chars hidden from the player
+func AllowedFactionStandings(standings []FactionStanding, playerID int) []FactionStanding {
+	allowed := []FactionStanding{}
+	for _, standing := range standings {
+		if standing.Char != nil && standing.Char.HiddenBy != 0 && standing.Char.HiddenBy != playerID {
+			continue
+		}
+		allowed = append(allowed, standing)
+	}
+
+	return allowed
+}
+
+// AllowedFactionStandingChanges skips standing changes of chars hidden
+// from the player
+func AllowedFactionStandingChanges(changes []FactionStandingChange, playerID int) []FactionStandingChange {
+	allowed := []FactionStandingChange{}
+	for _, change := range changes {
+		if change.Char != nil && change.Char.HiddenBy != 0 && change.Char.HiddenBy != playerID {
+			continue
+		}
+		allowed = append(allowed, change)
+	}
+
+	return allowed
+}
@@ -0,0 +1,512 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VaultNote is a Markdown file of an Obsidian vault
+type VaultNote struct {
+	Path    string
+	Content string
+}
+
+var vaultFolders = map[string]string{
+	CharEntity:     "Chars",
+	NPCEntity:      "NPCs",
+	LocationEntity: "Locations",
+	ItemEntity:     "Items",
+	FactionEntity:  "Factions",
+	QuestEntity:    "Quests",
+}
+
+var vaultNameReplacer = strings.NewReplacer(
+	"[", "(", "]", ")", "#", "", "^", "", "|", "-",
+	"\\", "-", "/", "-", ":", " -", "*", "", "?", "", "\"", "'", "<", "", ">", "",
+	"\n", " ", "\r", "",
+)
+
+// vault keeps note names so mentions can be turned into wikilinks
+type vault struct {
+	names    map[string]map[int]string
+	used     map[string]bool
+	calendar *GameCalendar
+	players  map[int]string
+}
+
+// add reserves a unique note name for the entity
+func (v *vault) add(entityType string, id int, name string) string {
+	note := strings.TrimSpace(vaultNameReplacer.Replace(name))
+	if note == "" {
+		note = fmt.Sprintf("%s %d", entityType, id)
+	}
+	if v.used[strings.ToLower(note)] {
+		note = fmt.Sprintf("%s (%s %d)", note, entityType, id)
+	}
+
+	v.used[strings.ToLower(note)] = true
+	if v.names[entityType] == nil {
+		v.names[entityType] = map[int]string{}
+	}
+	v.names[entityType][id] = note
+
+	return note
+}
+
+func (v *vault) link(entityType string, id int, text string) string {
+	note, ok := v.names[entityType][id]
+	if !ok {
+		return text
+	}
+	if text == "" || text == note {
+		return "[[" + note + "]]"
+	}
+
+	return "[[" + note + "|" + text + "]]"
+}
+
+// linkMentions turns mentions into wikilinks, mentions of notes
+// the player cannot see are left as plain names
+func (v *vault) linkMentions(text string) string {
+	return mentionRegexp.ReplaceAllStringFunc(text, func(mention string) string {
+		match := mentionRegexp.FindStringSubmatch(mention)
+		id, _ := strconv.Atoi(match[2])
+		return v.link(match[1], id, match[3])
+	})
+}
+
+// frontMatter renders YAML front matter, keys keep the order they were added
+type frontMatter struct {
+	lines []string
+}
+
+func (f *frontMatter) add(key string, value any) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case string:
+		if v == "" {
+			return
+		}
+		f.lines = append(f.lines, fmt.Sprintf("%s: %s", key, strconv.Quote(v)))
+	case []string:
+		if len(v) == 0 {
+			return
+		}
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = strconv.Quote(s)
+		}
+		f.lines = append(f.lines, fmt.Sprintf("%s: [%s]", key, strings.Join(quoted, ", ")))
+	case *time.Time:
+		if v == nil {
+			return
+		}
+		f.lines = append(f.lines, fmt.Sprintf("%s: %s", key, v.UTC().Format(time.RFC3339)))
+	default:
+		f.lines = append(f.lines, fmt.Sprintf("%s: %v", key, v))
+	}
+}
+
+func (f *frontMatter) String() string {
+	return "---\n" + strings.Join(f.lines, "\n") + "\n---\n"
+}
+
+// ExportVault renders the game as Obsidian notes visible to the player
+func (s *Storage) ExportVault(game *Game, player *Player) ([]VaultNote, error) {
+	visible := func(hiddenBy int, deleted *time.Time) bool {
+		return deleted == nil && (hiddenBy == 0 || hiddenBy == player.ID)
+	}
+
+	players, err := s.GetCurrentGamePlayers(game)
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := s.GetGameCalendar(game.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &vault{
+		names:    map[string]map[int]string{},
+		used:     map[string]bool{},
+		calendar: calendar,
+		players:  map[int]string{},
+	}
+	for _, p := range players {
+		v.players[p.ID] = p.Username
+	}
+
+	chars, err := s.GetCurrentGameChars(game)
+	if err != nil {
+		return nil, err
+	}
+	chars = slices.DeleteFunc(chars, func(c Char) bool { return !visible(c.HiddenBy, c.Deleted) })
+
+	npcs, err := s.GetCurrentGameNPCs(game)
+	if err != nil {
+		return nil, err
+	}
+	npcs = slices.DeleteFunc(npcs, func(n NPC) bool { return !visible(n.HiddenBy, n.Deleted) })
+
+	locations, err := s.GetCurrentGameLocations(game)
+	if err != nil {
+		return nil, err
+	}
+	locations = slices.DeleteFunc(locations, func(l Location) bool { return !visible(l.HiddenBy, l.Deleted) })
+
+	items, err := s.GetCurrentGameItems(game)
+	if err != nil {
+		return nil, err
+	}
+	items = slices.DeleteFunc(items, func(i Item) bool { return !visible(i.HiddenBy, i.Deleted) })
+
+	var factions []Faction
+	err = s.db.NewSelect().Model(&factions).Relation("Members").
+		Where("faction.game_id = ? AND faction.deleted IS NULL", game.ID).
+		Order("faction.id ASC").Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	factions = slices.DeleteFunc(factions, func(f Faction) bool { return !visible(f.HiddenBy, f.Deleted) })
+
+	var quests []Quest
+	err = s.db.NewSelect().Model(&quests).Relation("Tasks").
+		Where("quest.game_id = ? AND quest.deleted IS NULL", game.ID).
+		Order("quest.id ASC").Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	quests = slices.DeleteFunc(quests, func(q Quest) bool { return !visible(q.HiddenBy, q.Deleted) })
+
+	records, err := s.GetCurrentGameRecordsForPlayer(game, player)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(records, func(a, b Record) int { return a.Created.Compare(*b.Created) })
+
+	sessions, err := s.GetCurrentGameSessions(game)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(sessions, func(a, b Session) int { return cmp.Compare(a.Number, b.Number) })
+
+	// Every name is reserved before rendering so links between notes resolve
+	for _, char := range chars {
+		v.add(CharEntity, char.ID, char.Name)
+	}
+	for _, npc := range npcs {
+		v.add(NPCEntity, npc.ID, npc.Name)
+	}
+	for _, location := range locations {
+		v.add(LocationEntity, location.ID, location.Name)
+	}
+	for _, item := range items {
+		v.add(ItemEntity, item.ID, item.Name)
+	}
+	for _, faction := range factions {
+		v.add(FactionEntity, faction.ID, faction.Name)
+	}
+	for _, quest := range quests {
+		v.add(QuestEntity, quest.ID, quest.Name)
+	}
+
+	fields, err := s.getVaultFields(game.ID, v)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]map[int][]string{}
+	for entityType, ids := range map[string][]int{
+		CharEntity:     entityIDs(chars, func(c Char) int { return c.ID }),
+		NPCEntity:      entityIDs(npcs, func(n NPC) int { return n.ID }),
+		LocationEntity: entityIDs(locations, func(l Location) int { return l.ID }),
+		QuestEntity:    entityIDs(quests, func(q Quest) int { return q.ID }),
+	} {
+		tags[entityType], err = s.GetEntitiesTags(entityType, ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	notes := []VaultNote{}
+	entityNote := func(entityType string, id int, fm *frontMatter, title, description string, sections ...string) {
+		fm.add("tags", tags[entityType][id])
+		if entityFields := fields[entityType][id]; len(entityFields) > 0 {
+			fm.lines = append(fm.lines, "fields:")
+			for _, line := range entityFields {
+				fm.lines = append(fm.lines, "  "+line)
+			}
+		}
+
+		name := v.names[entityType][id]
+		body := fm.String() + "\n# " + name + "\n"
+		if title != "" {
+			body += "\n*" + title + "*\n"
+		}
+		if description != "" {
+			body += "\n" + v.linkMentions(description) + "\n"
+		}
+		for _, section := range sections {
+			if section != "" {
+				body += "\n" + section
+			}
+		}
+
+		notes = append(notes, VaultNote{Path: vaultFolders[entityType] + "/" + name + ".md", Content: body})
+	}
+
+	for _, char := range chars {
+		fm := &frontMatter{}
+		fm.add("type", CharEntity)
+		fm.add("id", char.ID)
+		fm.add("title", char.Title)
+		fm.add("player", v.players[char.PlayerID])
+		fm.add("created", char.Created)
+		entityNote(CharEntity, char.ID, fm, char.Title, char.Description)
+	}
+
+	for _, npc := range npcs {
+		fm := &frontMatter{}
+		fm.add("type", NPCEntity)
+		fm.add("id", npc.ID)
+		fm.add("title", npc.Title)
+		fm.add("created", npc.Created)
+		entityNote(NPCEntity, npc.ID, fm, npc.Title, npc.Description)
+	}
+
+	for _, location := range locations {
+		fm := &frontMatter{}
+		fm.add("type", LocationEntity)
+		fm.add("id", location.ID)
+		fm.add("title", location.Title)
+		if _, ok := v.names[LocationEntity][location.ParentID]; ok {
+			fm.add("parent", v.link(LocationEntity, location.ParentID, ""))
+		}
+		fm.add("created", location.Created)
+
+		children := []string{}
+		for _, child := range locations {
+			if child.ParentID == location.ID {
+				children = append(children, "- "+v.link(LocationEntity, child.ID, ""))
+			}
+		}
+		entityNote(LocationEntity, location.ID, fm, location.Title, location.Description, vaultSection("Locations", children))
+	}
+
+	for _, item := range items {
+		fm := &frontMatter{}
+		fm.add("type", ItemEntity)
+		fm.add("id", item.ID)
+		fm.add("title", item.Title)
+		fm.add("quantity", item.Quantity)
+		if ownerType := itemOwnerEntities[item.OwnerType]; ownerType == "" {
+			fm.add("owner", "party stash")
+		} else if _, ok := v.names[ownerType][item.OwnerID]; ok {
+			fm.add("owner", v.link(ownerType, item.OwnerID, ""))
+		}
+		fm.add("created", item.Created)
+		entityNote(ItemEntity, item.ID, fm, item.Title, item.Description)
+	}
+
+	for _, faction := range factions {
+		fm := &frontMatter{}
+		fm.add("type", FactionEntity)
+		fm.add("id", faction.ID)
+		fm.add("title", faction.Title)
+		fm.add("created", faction.Created)
+
+		members := []string{}
+		for _, member := range faction.Members {
+			link := v.link(CharEntity, member.CharID, "")
+			if member.NPCID != 0 {
+				link = v.link(NPCEntity, member.NPCID, "")
+			}
+			if !strings.HasPrefix(link, "[[") {
+				continue
+			}
+			if member.Rank != "" {
+				link += " - " + member.Rank
+			}
+			members = append(members, "- "+link)
+		}
+		entityNote(FactionEntity, faction.ID, fm, faction.Title, faction.Description, vaultSection("Members", members))
+	}
+
+	for _, quest := range quests {
+		fm := &frontMatter{}
+		fm.add("type", QuestEntity)
+		fm.add("id", quest.ID)
+		fm.add("title", quest.Title)
+		switch {
+		case quest.Finished == nil:
+			fm.add("status", "active")
+		case quest.Successful:
+			fm.add("status", "completed")
+		default:
+			fm.add("status", "failed")
+		}
+		if _, ok := v.names[QuestEntity][quest.ParentID]; ok {
+			fm.add("previous", v.link(QuestEntity, quest.ParentID, ""))
+		}
+		if _, ok := v.names[QuestEntity][quest.ChildID]; ok {
+			fm.add("next", v.link(QuestEntity, quest.ChildID, ""))
+		}
+		fm.add("created", quest.Created)
+		fm.add("finished", quest.Finished)
+
+		tasks := []string{}
+		slices.SortFunc(quest.Tasks, func(a, b QuestTask) int { return cmp.Compare(a.ID, b.ID) })
+		for _, task := range quest.Tasks {
+			if task.HiddenBy != 0 && task.HiddenBy != player.ID {
+				continue
+			}
+			check := " "
+			if task.Finished != nil {
+				check = "x"
+			}
+			line := fmt.Sprintf("- [%s] %s", check, task.Name)
+			if task.Type == Decimal {
+				line += fmt.Sprintf(" (%d/%d)", task.Current, task.Capacity)
+			}
+			tasks = append(tasks, line)
+		}
+		entityNote(QuestEntity, quest.ID, fm, quest.Title, quest.Description, vaultSection("Tasks", tasks))
+	}
+
+	notes = append(notes, v.sessionNotes(sessions, records)...)
+
+	return notes, nil
+}
+
+// sessionNotes puts every record into the note of the session it was written in
+func (v *vault) sessionNotes(sessions []Session, records []Record) []VaultNote {
+	sessionRecords := map[int][]Record{}
+	unsorted := []Record{}
+	for _, record := range records {
+		if session := sessionAtTime(sessions, *record.Created); session != nil {
+			sessionRecords[session.ID] = append(sessionRecords[session.ID], record)
+		} else {
+			unsorted = append(unsorted, record)
+		}
+	}
+
+	notes := []VaultNote{}
+	for _, session := range sessions {
+		fm := &frontMatter{}
+		fm.add("type", "session")
+		fm.add("number", session.Number)
+		fm.add("name", session.Name)
+		fm.add("ended", session.EndTime)
+		fm.add("worldStart", v.calendar.Format(session.WorldStart))
+		fm.add("worldEnd", v.calendar.Format(session.WorldEnd))
+
+		name := fmt.Sprintf("Session %d", session.Number)
+		if session.Name != "" {
+			name += " - " + strings.TrimSpace(vaultNameReplacer.Replace(session.Name))
+		}
+
+		body := fm.String() + "\n# " + name + "\n" + v.recordsMarkdown(sessionRecords[session.ID])
+		notes = append(notes, VaultNote{Path: "Sessions/" + name + ".md", Content: body})
+	}
+
+	if len(unsorted) > 0 {
+		fm := &frontMatter{}
+		fm.add("type", "session")
+		body := fm.String() + "\n# Records\n" + v.recordsMarkdown(unsorted)
+		notes = append(notes, VaultNote{Path: "Sessions/Records.md", Content: body})
+	}
+
+	return notes
+}
+
+func (v *vault) recordsMarkdown(records []Record) string {
+	var sb strings.Builder
+	for _, record := range records {
+		sb.WriteString("\n## " + record.Created.UTC().Format("2006-01-02 15:04"))
+		if username := v.players[record.PlayerID]; username != "" {
+			sb.WriteString(" - " + username)
+		}
+		sb.WriteString("\n")
+
+		if date := v.calendar.Format(record.WorldStart); date != "" {
+			if end := v.calendar.Format(record.WorldEnd); end != "" {
+				date += " - " + end
+			}
+			sb.WriteString("\n*" + date + "*\n")
+		}
+		if _, ok := v.names[QuestEntity][record.QuestID]; ok {
+			sb.WriteString("\nQuest: " + v.link(QuestEntity, record.QuestID, "") + "\n")
+		}
+
+		sb.WriteString("\n" + v.linkMentions(record.Text) + "\n")
+	}
+
+	return sb.String()
+}
+
+// getVaultFields renders custom field values as front matter lines
+func (s *Storage) getVaultFields(gameID int, v *vault) (map[string]map[int][]string, error) {
+	var values []CustomFieldValue
+	err := s.db.NewSelect().Model(&values).
+		Relation("Field").
+		Where("custom_field_value.game_id = ?", gameID).
+		Where("field.deleted IS NULL").
+		OrderExpr("field.sort_order ASC, field.id ASC").
+		Scan(context.Background())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	fields := map[string]map[int][]string{}
+	for _, value := range values {
+		if _, ok := v.names[value.EntityType][value.EntityID]; !ok {
+			continue
+		}
+
+		var rendered string
+		switch value.Field.Type {
+		case NumberField, BooleanField:
+			rendered = value.Value
+		case ReferenceField:
+			refID, _ := strconv.Atoi(value.Value)
+			if _, ok := v.names[value.Field.RefType][refID]; !ok {
+				continue
+			}
+			rendered = strconv.Quote(v.link(value.Field.RefType, refID, ""))
+		default:
+			rendered = strconv.Quote(value.Value)
+		}
+
+		if fields[value.EntityType] == nil {
+			fields[value.EntityType] = map[int][]string{}
+		}
+		fields[value.EntityType][value.EntityID] = append(fields[value.EntityType][value.EntityID],
+			fmt.Sprintf("%s: %s", strconv.Quote(value.Field.Key), rendered))
+	}
+
+	return fields, nil
+}
+
+func vaultSection(heading string, lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "## " + heading + "\n\n" + strings.Join(lines, "\n") + "\n"
+}
+
+func entityIDs[T any](entities []T, getID func(T) int) []int {
+	ids := make([]int, len(entities))
+	for i, entity := range entities {
+		ids[i] = getID(entity)
+	}
+
+	return ids
+}
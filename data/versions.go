@@ -0,0 +1,36 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/uptrace/bun"
+)
+
+// ErrVersionConflict is returned when the entity was changed after the
+// version the update is based on
+var ErrVersionConflict = errors.New("entity was changed by another player")
+
+// updateVersion increments the version of the updated entity. A non zero
+// version limits the update to it, zero keeps the write blind for the
+// clients not sending versions. The API refuses such writes when
+// app.requireVersions is set, the blind write is the compatibility mode
+func updateVersion(query *bun.UpdateQuery, version int) *bun.UpdateQuery {
+	query = query.Set("version = version + 1")
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	return query
+}
+
+func checkVersion(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrVersionConflict
+	}
+
+	return nil
+}
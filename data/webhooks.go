@@ -0,0 +1,298 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"personae-fasti/api/models/reqData"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	JSONWebhookFormat    = "json"
+	DiscordWebhookFormat = "discord"
+
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+
+	// Deliveries older than this are removed from the log
+	webhookDeliveryTTL = 30 * 24 * time.Hour
+	// How long the host of a new webhook is resolved
+	webhookLookupTimeout = 5 * time.Second
+)
+
+// Webhook is a GM subscription of a game to the events. Empty
+// Events means all of them
+type Webhook struct {
+	bun.BaseModel `bun:"table:webhook"`
+
+	ID     int      `bun:"id,pk,autoincrement" json:"id"`
+	GameID int      `bun:"game_id,notnull" json:"gameID"`
+	URL    string   `bun:"url,notnull" json:"url"`
+	Secret string   `bun:"secret,notnull" json:"secret"`
+	Format string   `bun:"format,notnull,default:'json'" json:"format"`
+	Events []string `bun:"events,type:jsonb" json:"events"`
+	Active bool     `bun:"active,notnull,default:true" json:"active"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp" json:"created"`
+	Deleted *time.Time `bun:"deleted,default:null" json:"-"`
+}
+
+func (w *Webhook) Subscribed(eventType EventType) bool {
+	if len(w.Events) == 0 {
+		return IsWebhookEvent(eventType)
+	}
+
+	for _, event := range w.Events {
+		if event == string(eventType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type WebhookDelivery struct {
+	bun.BaseModel `bun:"table:webhook_delivery"`
+
+	ID        int    `bun:"id,pk,autoincrement" json:"id"`
+	WebhookID int    `bun:"webhook_id,notnull" json:"webhookID"`
+	Event     string `bun:"event,notnull" json:"event"`
+	Payload   string `bun:"payload,notnull" json:"payload"`
+
+	Status       string `bun:"status,notnull,default:'pending'" json:"status"`
+	Attempts     int    `bun:"attempts,notnull,default:0" json:"attempts"`
+	ResponseCode int    `bun:"response_code,notnull,default:0" json:"responseCode"`
+	Error        string `bun:"error,notnull,default:''" json:"error"`
+
+	Created *time.Time `bun:"created,nullzero,notnull,default:current_timestamp" json:"created"`
+	Updated *time.Time `bun:"updated,nullzero,notnull,default:current_timestamp" json:"updated"`
+}
+
+func (s *Storage) validateWebhook(webhookURL string, format string, events []string) error {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("webhook url %q must be an absolute http or https url", webhookURL)
+	}
+	if err := s.validateWebhookHost(parsed.Hostname()); err != nil {
+		return err
+	}
+
+	if format != JSONWebhookFormat && format != DiscordWebhookFormat {
+		return fmt.Errorf("unknown webhook format %s", format)
+	}
+
+	for _, event := range events {
+		if !IsWebhookEvent(EventType(event)) {
+			return fmt.Errorf("unknown webhook event %s", event)
+		}
+	}
+
+	return nil
+}
+
+// validateWebhookHost keeps the webhooks out of the server network: the
+// host must resolve only to public addresses unless the config allows it
+func (s *Storage) validateWebhookHost(host string) error {
+	if s.WebhookHostAllowed(host) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookLookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("cannot resolve webhook host %s: %v", host, err)
+	}
+	for _, addr := range addrs {
+		if !PublicIP(addr.IP) {
+			return fmt.Errorf("webhook host %s resolves to the non-public address %s", host, addr.IP)
+		}
+	}
+
+	return nil
+}
+
+// WebhookHostAllowed is true for the hosts of the config that may resolve
+// to private addresses
+func (s *Storage) WebhookHostAllowed(host string) bool {
+	return slices.ContainsFunc(s.webhookHosts, func(allowed string) bool {
+		return strings.EqualFold(allowed, host)
+	})
+}
+
+// reservedPrefixes are the special-purpose ranges netip does not check
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // this network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64 of any IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local NAT64
+	netip.MustParsePrefix("100::/64"),        // discard
+	netip.MustParsePrefix("2001::/32"),       // Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4 of any IPv4
+}
+
+// PublicIP is true only for the global unicast addresses out of the
+// private and reserved ranges
+func PublicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *Storage) GetGameWebhooks(gameID int) ([]Webhook, error) {
+	webhooks := []Webhook{}
+	err := s.db.NewSelect().Model(&webhooks).Where("game_id = ? AND deleted IS NULL", gameID).Order("id").Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (s *Storage) GetWebhookByID(webhookID int) (*Webhook, error) {
+	webhook := Webhook{ID: webhookID}
+	err := s.db.NewSelect().Model(&webhook).WherePK().Where("deleted IS NULL").Scan(context.Background())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (s *Storage) CreateWebhook(webhookCreate *reqData.WebhookCreate, player *Player) (*Webhook, error) {
+	if webhookCreate.Format == "" {
+		webhookCreate.Format = JSONWebhookFormat
+	}
+	if err := s.validateWebhook(webhookCreate.URL, webhookCreate.Format, webhookCreate.Events); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	webhook := Webhook{
+		GameID: player.CurrentGameID,
+		URL:    webhookCreate.URL,
+		Secret: hex.EncodeToString(secret),
+		Format: webhookCreate.Format,
+		Events: webhookCreate.Events,
+		Active: true,
+	}
+
+	_, err := s.db.NewInsert().Model(&webhook).Returning("*").Exec(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (s *Storage) UpdateWebhook(webhookUpdate *reqData.WebhookUpdate, webhook *Webhook) (*Webhook, error) {
+	if webhookUpdate.Format == "" {
+		webhookUpdate.Format = JSONWebhookFormat
+	}
+	if err := s.validateWebhook(webhookUpdate.URL, webhookUpdate.Format, webhookUpdate.Events); err != nil {
+		return nil, err
+	}
+
+	webhook.URL = webhookUpdate.URL
+	webhook.Format = webhookUpdate.Format
+	webhook.Events = webhookUpdate.Events
+	webhook.Active = webhookUpdate.Active
+
+	_, err := s.db.NewUpdate().Model(webhook).Column("url", "format", "events", "active").WherePK().Exec(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (s *Storage) DeleteWebhook(webhook *Webhook) error {
+	now := time.Now().UTC()
+	webhook.Deleted = &now
+
+	_, err := s.db.NewUpdate().Model(webhook).Column("deleted").WherePK().Exec(context.Background())
+	return err
+}
+
+func (s *Storage) GetWebhookDeliveries(webhook *Webhook, limit int) ([]WebhookDelivery, error) {
+	deliveries := []WebhookDelivery{}
+	err := s.db.NewSelect().Model(&deliveries).Where("webhook_id = ?", webhook.ID).Order("id DESC").Limit(limit).Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// GetPendingWebhookDeliveries returns the deliveries left unfinished
+// when the server stopped
+func (s *Storage) GetPendingWebhookDeliveries() ([]WebhookDelivery, error) {
+	deliveries := []WebhookDelivery{}
+	err := s.db.NewSelect().Model(&deliveries).Where("status = ?", DeliveryPending).Order("id").Scan(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (s *Storage) CreateWebhookDelivery(delivery *WebhookDelivery) error {
+	ctx := context.Background()
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().Model((*WebhookDelivery)(nil)).
+			Where("webhook_id = ? AND created < ?", delivery.WebhookID, time.Now().UTC().Add(-webhookDeliveryTTL)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewInsert().Model(delivery).Returning("*").Exec(ctx)
+		return err
+	})
+}
+
+func (s *Storage) UpdateWebhookDelivery(delivery *WebhookDelivery) error {
+	now := time.Now().UTC()
+	delivery.Updated = &now
+
+	_, err := s.db.NewUpdate().Model(delivery).Column("status", "attempts", "response_code", "error", "updated").WherePK().Exec(context.Background())
+	return err
+}
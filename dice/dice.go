@@ -0,0 +1,374 @@
+package dice
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+
+	gu "personae-fasti/gewi-utils"
+)
+
+const (
+	MaxExpressionLength = 200
+	MaxTerms            = 20
+	MaxDice             = 100
+	MaxSides            = 1000
+
+	// Extra dice an exploding term may add
+	maxExplosions = 100
+)
+
+type Die struct {
+	Value    int  `json:"value"`
+	Dropped  bool `json:"dropped,omitempty"`
+	Exploded bool `json:"exploded,omitempty"`
+}
+
+// Term is a dice group or a number of the expression. Value is the sum of
+// the kept dice without the sign
+type Term struct {
+	Sign  int    `json:"sign"`
+	Dice  string `json:"dice,omitempty"`
+	Rolls []Die  `json:"rolls,omitempty"`
+	Value int    `json:"value"`
+}
+
+type Result struct {
+	Expression string `json:"expression"`
+	Terms      []Term `json:"terms"`
+	Total      int    `json:"total"`
+}
+
+// Roll evaluates the expression like 4d6kh3+2. Terms are dice NdS or
+// numbers joined by + and -. Dice take the modifiers:
+//   - ! explodes: a die rolled at max adds one more die
+//   - khN, klN keep the N highest or lowest dice, k is kh
+//   - dhN, dlN drop the N highest or lowest dice
+//
+// d% is d100, adv and dis are 2d20kh1 and 2d20kl1
+func Roll(expression string) (*Result, error) {
+	return roll(expression, func(sides int) int {
+		return rand.IntN(sides) + 1
+	})
+}
+
+func roll(expression string, die func(sides int) int) (*Result, error) {
+	terms, err := parse(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	result := Result{Terms: []Term{}}
+	for i, term := range terms {
+		rolled := term.roll(die)
+		result.Terms = append(result.Terms, rolled)
+		result.Total += rolled.Sign * rolled.Value
+
+		if i > 0 || term.sign < 0 {
+			result.Expression += signString(term.sign)
+		}
+		result.Expression += term.String()
+	}
+
+	return &result, nil
+}
+
+func signString(sign int) string {
+	if sign < 0 {
+		return "-"
+	}
+	return "+"
+}
+
+// String is the short form of the result to put in the text
+func (r *Result) String() string {
+	return fmt.Sprintf("%s = %d", r.Expression, r.Total)
+}
+
+// Detail shows every die: dropped ones in parentheses and exploded ones
+// with !
+func (r *Result) Detail() string {
+	var b strings.Builder
+	for i, term := range r.Terms {
+		if i > 0 {
+			b.WriteString(" " + signString(term.Sign) + " ")
+		} else if term.Sign < 0 {
+			b.WriteString("-")
+		}
+		if term.Dice == "" {
+			b.WriteString(strconv.Itoa(term.Value))
+			continue
+		}
+
+		rolls := []string{}
+		for _, die := range term.Rolls {
+			value := strconv.Itoa(die.Value)
+			if die.Exploded {
+				value += "!"
+			}
+			if die.Dropped {
+				value = "(" + value + ")"
+			}
+			rolls = append(rolls, value)
+		}
+		fmt.Fprintf(&b, "%s [%s]", term.Dice, strings.Join(rolls, ", "))
+	}
+	fmt.Fprintf(&b, " = %d", r.Total)
+
+	return b.String()
+}
+
+type termSpec struct {
+	sign  int
+	count int
+	// Zero sides is a number
+	sides   int
+	value   int
+	explode bool
+	keep    string
+	keepN   int
+}
+
+func (t *termSpec) String() string {
+	if t.sides == 0 {
+		return strconv.Itoa(t.value)
+	}
+
+	s := fmt.Sprintf("%dd%d", t.count, t.sides)
+	if t.explode {
+		s += "!"
+	}
+	if t.keep != "" {
+		s += t.keep + strconv.Itoa(t.keepN)
+	}
+
+	return s
+}
+
+func (t *termSpec) roll(die func(sides int) int) Term {
+	term := Term{Sign: t.sign}
+	if t.sides == 0 {
+		term.Value = t.value
+		return term
+	}
+
+	term.Dice = t.String()
+	term.Rolls = []Die{}
+	explosions := 0
+	for i := 0; i < t.count; i++ {
+		value := die(t.sides)
+		for t.explode && value == t.sides && explosions < maxExplosions {
+			term.Rolls = append(term.Rolls, Die{Value: value, Exploded: true})
+			value = die(t.sides)
+			explosions++
+		}
+		term.Rolls = append(term.Rolls, Die{Value: value})
+	}
+
+	if t.keep != "" {
+		order := make([]int, len(term.Rolls))
+		for i := range order {
+			order[i] = i
+		}
+		// Highest first, the earlier die wins a tie
+		sort.SliceStable(order, func(i, j int) bool {
+			return term.Rolls[order[i]].Value > term.Rolls[order[j]].Value
+		})
+
+		var dropped []int
+		n := min(t.keepN, len(order))
+		switch t.keep {
+		case "kh":
+			dropped = order[n:]
+		case "kl":
+			dropped = order[:len(order)-n]
+		case "dh":
+			dropped = order[:n]
+		case "dl":
+			dropped = order[len(order)-n:]
+		}
+		for _, i := range dropped {
+			term.Rolls[i].Dropped = true
+		}
+	}
+
+	for _, die := range term.Rolls {
+		if !die.Dropped {
+			term.Value += die.Value
+		}
+	}
+
+	return term
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func parse(expression string) ([]termSpec, error) {
+	input := strings.ToLower(strings.Join(strings.Fields(expression), ""))
+	if input == "" {
+		return nil, fmt.Errorf("empty dice expression")
+	} else if len(input) > MaxExpressionLength {
+		return nil, fmt.Errorf("dice expression is longer than %d characters", MaxExpressionLength)
+	}
+
+	p := &parser{input: input}
+	terms := []termSpec{}
+	sign := 1
+	if p.peek() == '+' || p.peek() == '-' {
+		sign = parseSign(p.next())
+	}
+
+	for {
+		term, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		term.sign = sign
+		terms = append(terms, term)
+
+		if p.done() {
+			break
+		} else if len(terms) == MaxTerms {
+			return nil, fmt.Errorf("dice expression has more than %d terms", MaxTerms)
+		}
+
+		c := p.next()
+		if c != '+' && c != '-' {
+			return nil, fmt.Errorf("unexpected %q at %d in dice expression", c, p.pos)
+		}
+		sign = parseSign(c)
+	}
+
+	return terms, nil
+}
+
+func parseSign(c byte) int {
+	if c == '-' {
+		return -1
+	}
+	return 1
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) next() byte {
+	c := p.peek()
+	p.pos++
+	return c
+}
+
+func (p *parser) skip(prefix string) bool {
+	if strings.HasPrefix(p.input[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// number returns -1 if there are no digits
+func (p *parser) number() (int, error) {
+	start := p.pos
+	for !p.done() && p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return -1, nil
+	}
+
+	n, err := strconv.Atoi(p.input[start:p.pos])
+	if err != nil || n > 1000000 {
+		return 0, fmt.Errorf("number %s in dice expression is too big", p.input[start:p.pos])
+	}
+
+	return n, nil
+}
+
+func (p *parser) term() (termSpec, error) {
+	if p.skip("adv") {
+		return termSpec{count: 2, sides: 20, keep: "kh", keepN: 1}, nil
+	} else if p.skip("dis") {
+		return termSpec{count: 2, sides: 20, keep: "kl", keepN: 1}, nil
+	}
+
+	start := p.pos
+	count, err := p.number()
+	if err != nil {
+		return termSpec{}, err
+	}
+	if p.peek() != 'd' {
+		if count < 0 {
+			return termSpec{}, fmt.Errorf("expected dice or number at %d in dice expression", start+1)
+		}
+		return termSpec{value: count}, nil
+	}
+	p.next()
+
+	if count < 0 {
+		count = 1
+	} else if count == 0 || count > MaxDice {
+		return termSpec{}, fmt.Errorf("dice count must be from 1 to %d", MaxDice)
+	}
+
+	term := termSpec{count: count}
+	if p.skip("%") {
+		term.sides = 100
+	} else {
+		term.sides, err = p.number()
+		if err != nil {
+			return termSpec{}, err
+		} else if term.sides < 0 {
+			return termSpec{}, fmt.Errorf("expected dice sides at %d in dice expression", p.pos+1)
+		} else if term.sides < 1 || term.sides > MaxSides {
+			return termSpec{}, fmt.Errorf("dice sides must be from 1 to %d", MaxSides)
+		}
+	}
+
+	for !p.done() {
+		if p.skip("!") {
+			if term.sides == 1 {
+				return termSpec{}, fmt.Errorf("d1 cannot explode")
+			}
+			term.explode = true
+			continue
+		}
+
+		keep := ""
+		for _, modifier := range []string{"kh", "kl", "dh", "dl", "k"} {
+			if p.skip(modifier) {
+				keep = modifier
+				break
+			}
+		}
+		if keep == "" {
+			break
+		} else if term.keep != "" {
+			return termSpec{}, fmt.Errorf("dice may have only one keep or drop modifier")
+		}
+
+		n, err := p.number()
+		if err != nil {
+			return termSpec{}, err
+		}
+		term.keep = keep
+		if keep == "k" {
+			term.keep = "kh"
+		}
+		term.keepN = gu.TernaryInt(n < 0, 1, n)
+	}
+
+	return term, nil
+}
@@ -0,0 +1,129 @@
+package dice
+
+import (
+	"strings"
+	"testing"
+)
+
+// sequence returns a die giving the values in order
+func sequence(t *testing.T, values ...int) func(sides int) int {
+	return func(sides int) int {
+		if len(values) == 0 {
+			t.Fatalf("die rolled more times than expected")
+		}
+		value := values[0]
+		values = values[1:]
+		if value < 1 || value > sides {
+			t.Fatalf("die value %d is not on d%d", value, sides)
+		}
+		return value
+	}
+}
+
+func TestRoll(t *testing.T) {
+	tests := []struct {
+		name        string
+		expression  string
+		rolls       []int
+		expression2 string
+		total       int
+		detail      string
+	}{
+		{"number", "5", nil, "5", 5, "5 = 5"},
+		{"single die", "d20", []int{13}, "1d20", 13, "1d20 [13] = 13"},
+		{"sum", "2d6 + 3", []int{4, 2}, "2d6+3", 9, "2d6 [4, 2] + 3 = 9"},
+		{"negative first", "-1d4+10", []int{3}, "-1d4+10", 7, "-1d4 [3] + 10 = 7"},
+		{"spaces and case", " 1D8 - 1 ", []int{8}, "1d8-1", 7, "1d8 [8] - 1 = 7"},
+		{"percent", "d%", []int{42}, "1d100", 42, "1d100 [42] = 42"},
+		{"keep highest", "4d6kh3", []int{5, 1, 4, 4}, "4d6kh3", 13, "4d6kh3 [5, (1), 4, 4] = 13"},
+		{"keep is keep highest", "2d20k", []int{3, 17}, "2d20kh1", 17, "2d20kh1 [(3), 17] = 17"},
+		{"keep lowest", "3d6kl1", []int{5, 2, 6}, "3d6kl1", 2, "3d6kl1 [(5), 2, (6)] = 2"},
+		{"drop highest", "3d6dh1", []int{5, 2, 6}, "3d6dh1", 7, "3d6dh1 [5, 2, (6)] = 7"},
+		{"drop lowest", "4d6dl1", []int{3, 3, 6, 1}, "4d6dl1", 12, "4d6dl1 [3, 3, 6, (1)] = 12"},
+		{"keep tie takes earlier", "3d6kh1", []int{6, 6, 2}, "3d6kh1", 6, "3d6kh1 [6, (6), (2)] = 6"},
+		{"keep more than rolled", "2d6kh5", []int{1, 2}, "2d6kh5", 3, "2d6kh5 [1, 2] = 3"},
+		{"explode", "2d6!", []int{6, 6, 2, 3}, "2d6!", 17, "2d6! [6!, 6!, 2, 3] = 17"},
+		{"explode and keep", "2d6!kh1", []int{6, 1, 5}, "2d6!kh1", 6, "2d6!kh1 [6!, (1), (5)] = 6"},
+		{"advantage", "adv+5", []int{4, 15}, "2d20kh1+5", 20, "2d20kh1 [(4), 15] + 5 = 20"},
+		{"disadvantage", "dis", []int{4, 15}, "2d20kl1", 4, "2d20kl1 [4, (15)] = 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := roll(tt.expression, sequence(t, tt.rolls...))
+			if err != nil {
+				t.Fatalf("roll(%q) failed: %v", tt.expression, err)
+			}
+			if result.Expression != tt.expression2 {
+				t.Errorf("expression is %q, want %q", result.Expression, tt.expression2)
+			}
+			if result.Total != tt.total {
+				t.Errorf("total is %d, want %d", result.Total, tt.total)
+			}
+			if detail := result.Detail(); detail != tt.detail {
+				t.Errorf("detail is %q, want %q", detail, tt.detail)
+			}
+		})
+	}
+}
+
+func TestRollExplosionLimit(t *testing.T) {
+	result, err := roll("1d6!", func(sides int) int { return sides })
+	if err != nil {
+		t.Fatalf("roll failed: %v", err)
+	}
+
+	if len(result.Terms[0].Rolls) != maxExplosions+1 {
+		t.Errorf("rolled %d dice, want %d", len(result.Terms[0].Rolls), maxExplosions+1)
+	}
+}
+
+func TestRollErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		err        string
+	}{
+		{"empty", "  ", "empty dice expression"},
+		{"too long", strings.Repeat("1+", 100) + "1", "longer than"},
+		{"too many terms", strings.Repeat("1+", MaxTerms) + "1", "more than 20 terms"},
+		{"no term", "1d6+", "expected dice or number"},
+		{"unknown operator", "1d6*2", "unexpected '*'"},
+		{"letters", "foo", "expected dice or number"},
+		{"zero dice", "0d6", "dice count must be"},
+		{"too many dice", "101d6", "dice count must be"},
+		{"no sides", "2d", "expected dice sides"},
+		{"zero sides", "1d0", "dice sides must be"},
+		{"too many sides", "1d1001", "dice sides must be"},
+		{"big number", "10000000", "too big"},
+		{"d1 explodes", "1d1!", "d1 cannot explode"},
+		{"two keeps", "4d6kh3dl1", "only one keep or drop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := roll(tt.expression, func(sides int) int {
+				t.Fatalf("die rolled for an invalid expression")
+				return 0
+			})
+			if err == nil {
+				t.Fatalf("roll(%q) succeeded", tt.expression)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error is %q, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRollRange(t *testing.T) {
+	for range 100 {
+		result, err := Roll("3d6")
+		if err != nil {
+			t.Fatalf("roll failed: %v", err)
+		}
+		if result.Total < 3 || result.Total > 18 {
+			t.Fatalf("3d6 gave %d", result.Total)
+		}
+	}
+}
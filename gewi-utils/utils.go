@@ -15,3 +15,13 @@ func TernaryInt(condition bool, pos, neg int) int {
 		return neg
 	}
 }
+
+// Truncate cuts the text to the length in runes ending it with an ellipsis
+func Truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length-1]) + "…"
+}
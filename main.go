@@ -3,17 +3,29 @@ package main
 import (
 	"personae-fasti/api"
 	"personae-fasti/data"
+	"personae-fasti/notify"
 	"personae-fasti/opt"
+	"personae-fasti/telegram"
+	"personae-fasti/webhook"
 )
 
 var Config *opt.Conf
 var Storage *data.Storage
 var Api *api.APIServer
+var Bot *telegram.Bot
+var Webhooks *webhook.Dispatcher
+var Notifier *notify.Notifier
 
 func main() {
 
 	Config = opt.InitConfig()
 	Storage = data.NewStorage(Config)
-	Api = api.InitServer(Config, Storage)
+	Bot = telegram.InitBot(Config, Storage)
+	Webhooks = webhook.InitDispatcher(Storage)
+	Notifier = notify.InitNotifier(Storage)
+	if Bot != nil {
+		Notifier.AddChannel(Bot)
+	}
+	Api = api.InitServer(Config, Storage, Webhooks)
 
 }
@@ -0,0 +1,209 @@
+// Package notify turns the game events into the notifications of the
+// players and delivers them through the channels
+package notify
+
+import (
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	"personae-fasti/data"
+	gu "personae-fasti/gewi-utils"
+)
+
+const (
+	queueSize     = 256
+	maxTextLength = 300
+)
+
+// Channel delivers the notification outside the inbox. The player has the
+// Telegram account loaded
+type Channel interface {
+	Name() string
+	Deliver(player *data.Player, notification *data.Notification) error
+}
+
+type Notifier struct {
+	storage *data.Storage
+	events  chan data.Event
+
+	mu       sync.RWMutex
+	channels []Channel
+}
+
+// InitNotifier subscribes to the storage events. Notifications are saved
+// to the inbox and passed to the channels added later
+func InitNotifier(s *data.Storage) *Notifier {
+	n := &Notifier{
+		storage: s,
+		events:  make(chan data.Event, queueSize),
+	}
+
+	s.Subscribe(n.enqueue)
+	go n.run()
+
+	return n
+}
+
+func (n *Notifier) AddChannel(channel Channel) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.channels = append(n.channels, channel)
+}
+
+func (n *Notifier) enqueue(event data.Event) {
+	switch event.Type {
+	case data.RecordCreatedEvent, data.RecordUpdatedEvent, data.EntityRevealedEvent, data.SessionStartedEvent:
+	default:
+		return
+	}
+
+	select {
+	case n.events <- event:
+	default:
+		log.Printf("notification queue is full, event %s of the game %d is dropped", event.Type, event.GameID)
+	}
+}
+
+func (n *Notifier) run() {
+	for event := range n.events {
+		notifications, err := n.notifications(&event)
+		if err != nil {
+			log.Printf("failed to get notifications of the event %s of the game %d: %v", event.Type, event.GameID, err)
+			continue
+		}
+
+		if err := n.storage.CreateNotifications(notifications); err != nil {
+			log.Printf("failed to save notifications of the game %d: %v", event.GameID, err)
+			continue
+		}
+
+		for i := range notifications {
+			n.deliver(&notifications[i], &event)
+		}
+	}
+}
+
+// notifications returns one notification for every player the event is
+// about, never for the player who made the change
+func (n *Notifier) notifications(event *data.Event) ([]data.Notification, error) {
+	notification := data.Notification{
+		GameID:  event.GameID,
+		ActorID: event.PlayerID,
+	}
+	recipients := []int{}
+	// Players whose chars are newly mentioned in the record, they get a
+	// mention instead of the main notification
+	mentioned := []int{}
+
+	switch eventData := event.Data.(type) {
+	case *data.RecordEventData:
+		notification.EntityType = data.RecordEntity
+		notification.EntityID = eventData.ID
+		notification.Text = shorten(eventData.Text)
+
+		charIDs := data.MentionedIDs(eventData.Text, "char")
+		if event.Type == data.RecordUpdatedEvent {
+			charIDs = newIDs(charIDs, data.MentionedIDs(eventData.OldText, "char"))
+			notification.Type = data.RecordEditedNotification
+			recipients = append(recipients, eventData.Author)
+		}
+		chars, err := n.storage.GetCharsByIDs(event.GameID, charIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, char := range chars {
+			mentioned = append(mentioned, char.PlayerID)
+		}
+	case *data.EntityEventData:
+		notification.Type = data.SharedNotification
+		notification.EntityType = eventData.EntityType
+		notification.EntityID = eventData.ID
+		notification.Text = shorten(eventData.Name)
+		if eventData.EntityType == data.RecordEntity {
+			notification.Text = shorten(eventData.Description)
+		}
+
+		players, err := n.storage.GetCurrentGamePlayers(&data.Game{ID: event.GameID})
+		if err != nil {
+			return nil, err
+		}
+		for _, player := range players {
+			recipients = append(recipients, player.ID)
+		}
+	case *data.SessionEventData:
+		notification.Type = data.SessionNotification
+		notification.Text = eventData.Name
+
+		players, err := n.storage.GetCurrentGamePlayers(&data.Game{ID: event.GameID})
+		if err != nil {
+			return nil, err
+		}
+		for _, player := range players {
+			recipients = append(recipients, player.ID)
+		}
+	default:
+		return nil, nil
+	}
+
+	notifications := []data.Notification{}
+	seen := map[int]bool{}
+	add := func(notificationType string, playerIDs []int) {
+		for _, playerID := range playerIDs {
+			if playerID == 0 || playerID == event.PlayerID || seen[playerID] || !event.VisibleTo(playerID) {
+				continue
+			}
+			seen[playerID] = true
+
+			notification.Type = notificationType
+			notification.PlayerID = playerID
+			notifications = append(notifications, notification)
+		}
+	}
+	notificationType := notification.Type
+	add(data.MentionNotification, mentioned)
+	add(notificationType, recipients)
+
+	return notifications, nil
+}
+
+func (n *Notifier) deliver(notification *data.Notification, event *data.Event) {
+	n.mu.RLock()
+	channels := n.channels
+	n.mu.RUnlock()
+	if len(channels) == 0 {
+		return
+	}
+
+	player, err := n.storage.GetPlayerByID(notification.PlayerID)
+	if err != nil || player == nil {
+		log.Printf("failed to get the player %d to deliver the notification %d: %v", notification.PlayerID, notification.ID, err)
+		return
+	}
+	notification.Actor = &data.Player{ID: event.PlayerID, Username: event.Username}
+
+	for _, channel := range channels {
+		if err := channel.Deliver(player, notification); err != nil {
+			log.Printf("failed to deliver the notification %d by %s: %v", notification.ID, channel.Name(), err)
+		}
+	}
+}
+
+// newIDs returns the IDs that are not among the old ones
+func newIDs(ids []int, oldIDs []int) []int {
+	result := []int{}
+	for _, id := range ids {
+		if !slices.Contains(oldIDs, id) {
+			result = append(result, id)
+		}
+	}
+
+	return result
+}
+
+// shorten leaves the start of the text without mention syntax
+func shorten(text string) string {
+	return gu.Truncate(strings.TrimSpace(data.StripMentions(text)), maxTextLength)
+}
@@ -11,6 +11,9 @@ type Conf struct {
 	App struct {
 		Port  string `json:"port"`
 		Debug bool   `json:"debug"`
+		// Updates without If-Match or version are refused with 428.
+		// Off keeps the blind writes of the old clients
+		RequireVersions bool `json:"requireVersions"`
 	} `json:"app"`
 	DB struct {
 		Host     string `json:"host"`
@@ -20,6 +23,8 @@ type Conf struct {
 		Name     string `json:"name"`
 	} `json:"db"`
 	FileServer FileServer `json:"fileServer"`
+	Telegram   Telegram   `json:"telegram"`
+	Webhooks   Webhooks   `json:"webhooks"`
 }
 
 type FileServer struct {
@@ -28,6 +33,20 @@ type FileServer struct {
 	Proj string `json:"proj"`
 }
 
+// Telegram bot is disabled without a token. APIURL points the bot
+// to another Bot API server, e.g. a local one for testing
+type Telegram struct {
+	Token       string `json:"token"`
+	APIURL      string `json:"apiURL"`
+	PollTimeout int    `json:"pollTimeout"`
+}
+
+// Webhooks reach only public addresses. AllowedHosts may resolve to
+// private ones, e.g. a receiver in the same network
+type Webhooks struct {
+	AllowedHosts []string `json:"allowedHosts"`
+}
+
 func InitConfig() *Conf {
 
 	config := new(Conf)
@@ -0,0 +1,139 @@
+// Package telegram runs the bot that links Telegram accounts to players
+// and lets them write and read records of the current game
+package telegram
+
+import (
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/data"
+	gu "personae-fasti/gewi-utils"
+	"personae-fasti/opt"
+)
+
+const (
+	defaultAPIURL      = "https://api.telegram.org"
+	defaultPollTimeout = 30
+	maxPollBackoff     = time.Minute
+)
+
+type Bot struct {
+	client      *client
+	storage     storage
+	pollTimeout int
+}
+
+// storage is the part of the data storage the bot uses
+type storage interface {
+	LinkTelegram(code string, telegram *data.Telegram) (*data.Player, error)
+	UnlinkTelegram(player *data.Player) error
+	GetPlayerByTelegramID(telegramID int64) (*data.Player, error)
+	UpdateTelegram(telegram *data.Telegram) error
+	InsertNewRecord(recordInsert *reqData.RecordInsert, p *data.Player) error
+	GetLastRecordsForPlayer(game *data.Game, player *data.Player, limit int) ([]data.Record, error)
+	GetActiveQuestsForPlayer(game *data.Game, player *data.Player) ([]data.Quest, error)
+	FindNPCsForPlayer(game *data.Game, player *data.Player, name string, limit int) ([]data.NPC, error)
+}
+
+// InitBot starts polling updates in the background. The bot is not
+// started without a token in the config
+func InitBot(c *opt.Conf, s *data.Storage) *Bot {
+	if c.Telegram.Token == "" {
+		log.Println("Telegram bot is disabled: no token in the config")
+		return nil
+	}
+
+	apiURL := strings.TrimRight(c.Telegram.APIURL, "/")
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+	pollTimeout := c.Telegram.PollTimeout
+	if pollTimeout <= 0 {
+		pollTimeout = defaultPollTimeout
+	}
+
+	bot := &Bot{
+		client:      newClient(apiURL, c.Telegram.Token, pollTimeout),
+		storage:     s,
+		pollTimeout: pollTimeout,
+	}
+
+	if me, err := bot.client.getMe(); err != nil {
+		log.Printf("Telegram bot cannot get its info: %v", err)
+	} else {
+		log.Printf("Telegram bot @%s running on %s", me.Username, apiURL)
+	}
+
+	go bot.poll()
+
+	return bot
+}
+
+func (b *Bot) poll() {
+	offset := 0
+	backoff := time.Second
+
+	for {
+		next, err := b.handleUpdates(offset)
+		if err != nil {
+			log.Printf("Telegram bot polling error: %v", err)
+			time.Sleep(backoff)
+			backoff = min(backoff*2, maxPollBackoff)
+			continue
+		}
+		backoff = time.Second
+		offset = next
+	}
+}
+
+// handleUpdates handles the updates after the offset and returns the
+// offset of the next ones
+func (b *Bot) handleUpdates(offset int) (int, error) {
+	updates, err := b.client.getUpdates(offset, b.pollTimeout)
+	if err != nil {
+		return offset, err
+	}
+
+	for _, update := range updates {
+		offset = update.UpdateID + 1
+		b.handleUpdate(&update)
+	}
+
+	return offset, nil
+}
+
+func (b *Bot) handleUpdate(update *Update) {
+	message := update.Message
+	if message == nil || message.From == nil || message.From.IsBot {
+		return
+	}
+
+	reply := b.handleMessage(message)
+	if reply == "" {
+		return
+	}
+
+	if err := b.client.sendMessage(message.Chat.ID, gu.Truncate(reply, maxMessageLength)); err != nil {
+		log.Printf("Telegram bot cannot reply to the chat %d: %v", message.Chat.ID, err)
+	}
+}
+
+// parseCommand splits "/cmd@bot args" into the command and its arguments.
+// Plain text gives an empty command
+func parseCommand(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return "", text
+	}
+
+	command, args := text[1:], ""
+	if i := strings.IndexFunc(command, unicode.IsSpace); i >= 0 {
+		command, args = command[:i], command[i:]
+	}
+	command, _, _ = strings.Cut(command, "@")
+
+	return strings.ToLower(command), strings.TrimSpace(args)
+}
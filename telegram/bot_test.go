@@ -0,0 +1,356 @@
+package telegram
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/data"
+)
+
+type sentMessage struct {
+	ChatID int64  `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// fakeBotAPI serves getUpdates and sendMessage of the Bot API
+type fakeBotAPI struct {
+	mu      sync.Mutex
+	updates []Update
+	sent    []sentMessage
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result any
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/bottest-token/getUpdates"):
+		var params struct {
+			Offset int `json:"offset"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		updates := []Update{}
+		for _, update := range f.updates {
+			if update.UpdateID >= params.Offset {
+				updates = append(updates, update)
+			}
+		}
+		result = updates
+	case strings.HasSuffix(r.URL.Path, "/bottest-token/sendMessage"):
+		var message sentMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.sent = append(f.sent, message)
+		result = true
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": 404, "description": "Not Found"})
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+// queue adds the message from the user and returns its update ID
+func (f *fakeBotAPI) queue(text string, chatType string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	updateID := len(f.updates) + 100
+	f.updates = append(f.updates, Update{
+		UpdateID: updateID,
+		Message: &Message{
+			MessageID: len(f.updates) + 1,
+			From:      &User{ID: 42, Username: "alice_tg", LanguageCode: "en-US"},
+			Chat:      Chat{ID: 42, Type: chatType},
+			Text:      text,
+		},
+	})
+
+	return updateID
+}
+
+func (f *fakeBotAPI) replies() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	replies := []string{}
+	for _, message := range f.sent {
+		replies = append(replies, message.Text)
+	}
+
+	return replies
+}
+
+// fakeStorage knows one player linked with the code "good-code"
+type fakeStorage struct {
+	player  *data.Player
+	records []data.Record
+	quests  []data.Quest
+	npcs    []data.NPC
+
+	inserted    []reqData.RecordInsert
+	recordLimit int
+	npcName     string
+}
+
+func (s *fakeStorage) LinkTelegram(code string, telegram *data.Telegram) (*data.Player, error) {
+	if code != "good-code" {
+		return nil, data.ErrTelegramLink
+	}
+	s.player.TelegramID = telegram.ID
+	s.player.Telegram = telegram
+
+	return s.player, nil
+}
+
+func (s *fakeStorage) UnlinkTelegram(player *data.Player) error {
+	player.TelegramID = 0
+	player.Telegram = nil
+	return nil
+}
+
+func (s *fakeStorage) GetPlayerByTelegramID(telegramID int64) (*data.Player, error) {
+	if s.player.TelegramID != telegramID {
+		return nil, nil
+	}
+	return s.player, nil
+}
+
+func (s *fakeStorage) UpdateTelegram(telegram *data.Telegram) error {
+	s.player.Telegram = telegram
+	return nil
+}
+
+func (s *fakeStorage) InsertNewRecord(recordInsert *reqData.RecordInsert, p *data.Player) error {
+	s.inserted = append(s.inserted, *recordInsert)
+	return nil
+}
+
+func (s *fakeStorage) GetLastRecordsForPlayer(game *data.Game, player *data.Player, limit int) ([]data.Record, error) {
+	s.recordLimit = limit
+	return s.records[:min(limit, len(s.records))], nil
+}
+
+func (s *fakeStorage) GetActiveQuestsForPlayer(game *data.Game, player *data.Player) ([]data.Quest, error) {
+	return s.quests, nil
+}
+
+func (s *fakeStorage) FindNPCsForPlayer(game *data.Game, player *data.Player, name string, limit int) ([]data.NPC, error) {
+	s.npcName = name
+	return s.npcs, nil
+}
+
+func newTestBot(t *testing.T, linked bool) (*Bot, *fakeBotAPI, *fakeStorage) {
+	api := &fakeBotAPI{}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	storage := &fakeStorage{
+		player: &data.Player{
+			ID:            1,
+			Username:      "alice",
+			CurrentGameID: 7,
+			CurrentGame:   &data.Game{ID: 7, Name: "Curse of Strahd"},
+		},
+	}
+	if linked {
+		storage.player.TelegramID = 42
+		storage.player.Telegram = &data.Telegram{ID: 42, Username: "alice_tg", Lang: "en"}
+	}
+
+	bot := &Bot{
+		client:  newClient(server.URL, "test-token", 0),
+		storage: storage,
+	}
+
+	return bot, api, storage
+}
+
+// exchange sends the message to the bot through the fake API and returns
+// the replies
+func exchange(t *testing.T, bot *Bot, api *fakeBotAPI, text string) []string {
+	t.Helper()
+
+	sent := len(api.replies())
+	updateID := api.queue(text, "private")
+
+	// The offset skips the updates handled before
+	next, err := bot.handleUpdates(updateID)
+	if err != nil {
+		t.Fatalf("handleUpdates failed: %v", err)
+	} else if next != updateID+1 {
+		t.Errorf("next offset is %d, want %d", next, updateID+1)
+	}
+
+	return api.replies()[sent:]
+}
+
+func expectReply(t *testing.T, replies []string, want string) {
+	t.Helper()
+
+	if len(replies) != 1 {
+		t.Fatalf("got %d replies %q, want one", len(replies), replies)
+	} else if !strings.Contains(replies[0], want) {
+		t.Errorf("reply %q does not contain %q", replies[0], want)
+	}
+}
+
+func TestLink(t *testing.T) {
+	bot, api, storage := newTestBot(t, false)
+
+	expectReply(t, exchange(t, bot, api, "hello"), "is not linked")
+	expectReply(t, exchange(t, bot, api, "/link"), "Send /link <code>")
+	expectReply(t, exchange(t, bot, api, "/link bad-code"), "invalid or expired")
+	if storage.player.TelegramID != 0 {
+		t.Fatalf("player is linked with a bad code")
+	}
+
+	expectReply(t, exchange(t, bot, api, "/link good-code"), "Linked to the player alice.")
+	if storage.player.TelegramID != 42 {
+		t.Errorf("player is linked to %d, want 42", storage.player.TelegramID)
+	} else if storage.player.Telegram.Lang != "en" {
+		t.Errorf("player language is %q, want en", storage.player.Telegram.Lang)
+	}
+
+	expectReply(t, exchange(t, bot, api, "/start@personae_bot good-code"), "Linked to the player alice.")
+	expectReply(t, exchange(t, bot, api, "/unlink"), "unlinked")
+	if storage.player.TelegramID != 0 {
+		t.Errorf("player is still linked to %d", storage.player.TelegramID)
+	}
+}
+
+func TestAddRecord(t *testing.T) {
+	bot, api, storage := newTestBot(t, true)
+
+	expectReply(t, exchange(t, bot, api, "The party rests at the inn"), "Record added to Curse of Strahd.")
+	expectReply(t, exchange(t, bot, api, "/hidden I steal the ring"), "Hidden record added to Curse of Strahd.")
+	expectReply(t, exchange(t, bot, api, "/hidden"), "The record is empty.")
+
+	want := []reqData.RecordInsert{
+		{Text: "The party rests at the inn"},
+		{Text: "I steal the ring", Hidden: true},
+	}
+	if len(storage.inserted) != len(want) {
+		t.Fatalf("inserted %d records, want %d", len(storage.inserted), len(want))
+	}
+	for i := range want {
+		if storage.inserted[i].Text != want[i].Text || storage.inserted[i].Hidden != want[i].Hidden {
+			t.Errorf("record %d is %+v, want %+v", i, storage.inserted[i], want[i])
+		}
+	}
+}
+
+func TestAddRecordWithoutGame(t *testing.T) {
+	bot, api, storage := newTestBot(t, true)
+	storage.player.CurrentGameID = 0
+	storage.player.CurrentGame = nil
+
+	expectReply(t, exchange(t, bot, api, "The party rests"), "Choose the current game")
+	if len(storage.inserted) != 0 {
+		t.Errorf("record is inserted without a game")
+	}
+}
+
+func TestLastRecords(t *testing.T) {
+	bot, api, storage := newTestBot(t, true)
+
+	expectReply(t, exchange(t, bot, api, "/records"), "No records yet.")
+	if storage.recordLimit != defaultRecords {
+		t.Errorf("limit is %d, want %d", storage.recordLimit, defaultRecords)
+	}
+
+	created := time.Date(2025, 3, 14, 20, 0, 0, 0, time.UTC)
+	storage.records = []data.Record{
+		{ID: 3, Text: "Met @npc:5`Ismark` at the gates", Created: &created},
+		{ID: 2, Text: "Secret plan", HiddenBy: 1},
+		{ID: 1, Text: strings.Repeat("a", maxRecordPreview+10)},
+	}
+
+	replies := exchange(t, bot, api, "/records 100")
+	if storage.recordLimit != maxRecords {
+		t.Errorf("limit is %d, want %d", storage.recordLimit, maxRecords)
+	}
+	want := strings.Repeat("a", maxRecordPreview-1) + "…\n\n" +
+		"(hidden) Secret plan\n\n" +
+		"2025-03-14 — Met Ismark at the gates"
+	if len(replies) != 1 || replies[0] != want {
+		t.Errorf("replies are %q, want %q", replies, want)
+	}
+
+	exchange(t, bot, api, "/records 2")
+	if storage.recordLimit != 2 {
+		t.Errorf("limit is %d, want 2", storage.recordLimit)
+	}
+}
+
+func TestFindNPC(t *testing.T) {
+	bot, api, storage := newTestBot(t, true)
+
+	expectReply(t, exchange(t, bot, api, "/npc"), "Send /npc <name>.")
+	expectReply(t, exchange(t, bot, api, "/npc Strahd"), "No NPC found.")
+	if storage.npcName != "Strahd" {
+		t.Errorf("searched %q, want Strahd", storage.npcName)
+	}
+
+	storage.npcs = []data.NPC{
+		{Name: "Ismark", Title: "the Lesser", Description: "Brother of @npc:6`Ireena`"},
+		{Name: "Ireena"},
+	}
+	replies := exchange(t, bot, api, "/npc  I ")
+	want := "Ismark — the Lesser\nBrother of Ireena\n\nIreena"
+	if len(replies) != 1 || replies[0] != want {
+		t.Errorf("replies are %q, want %q", replies, want)
+	}
+	if storage.npcName != "I" {
+		t.Errorf("searched %q, want I", storage.npcName)
+	}
+}
+
+func TestGroupChat(t *testing.T) {
+	bot, api, storage := newTestBot(t, true)
+
+	api.queue("/records", "group")
+	api.queue("just chatting", "group")
+	if _, err := bot.handleUpdates(0); err != nil {
+		t.Fatalf("handleUpdates failed: %v", err)
+	}
+
+	replies := api.replies()
+	if len(replies) != 1 || !strings.Contains(replies[0], "only in a private chat") {
+		t.Errorf("replies are %q, want one private chat warning", replies)
+	}
+	if storage.recordLimit != 0 || len(storage.inserted) != 0 {
+		t.Errorf("group chat reached the storage")
+	}
+}
+
+func TestBotAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": 401, "description": "Unauthorized"})
+	}))
+	defer server.Close()
+
+	bot := &Bot{client: newClient(server.URL, "test-token", 0), storage: &fakeStorage{}}
+	offset, err := bot.handleUpdates(5)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("error is %v, want the API error 401", err)
+	} else if strings.Contains(err.Error(), "test-token") {
+		t.Errorf("error %q leaks the token", err)
+	}
+	if offset != 5 {
+		t.Errorf("offset is %d, want 5 to retry", offset)
+	}
+}
@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Only the parts of the Bot API used by the bot
+
+type Update struct {
+	UpdateID int      `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+type Message struct {
+	MessageID int    `json:"message_id"`
+	From      *User  `json:"from"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+type User struct {
+	ID           int64  `json:"id"`
+	IsBot        bool   `json:"is_bot"`
+	Username     string `json:"username"`
+	FirstName    string `json:"first_name"`
+	LanguageCode string `json:"language_code"`
+}
+
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+	ErrorCode   int             `json:"error_code"`
+}
+
+type client struct {
+	baseURL string
+	http    *http.Client
+}
+
+func newClient(apiURL, token string, pollTimeout int) *client {
+	return &client{
+		baseURL: fmt.Sprintf("%s/bot%s", apiURL, token),
+		// Long polling holds the request for the poll timeout
+		http: &http.Client{Timeout: time.Duration(pollTimeout+10) * time.Second},
+	}
+}
+
+func (c *client) call(method string, params any, result any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.http.Post(c.baseURL+"/"+method, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The error contains the URL with the token
+		return fmt.Errorf("telegram %s request failed", method)
+	}
+	defer res.Body.Close()
+
+	var apiRes apiResponse
+	if err := json.NewDecoder(res.Body).Decode(&apiRes); err != nil {
+		return fmt.Errorf("telegram %s response: %v", method, err)
+	} else if !apiRes.OK {
+		return fmt.Errorf("telegram %s error %d: %s", method, apiRes.ErrorCode, apiRes.Description)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(apiRes.Result, result)
+}
+
+func (c *client) getMe() (*User, error) {
+	var me User
+	if err := c.call("getMe", struct{}{}, &me); err != nil {
+		return nil, err
+	}
+
+	return &me, nil
+}
+
+func (c *client) getUpdates(offset int, timeout int) ([]Update, error) {
+	params := map[string]any{
+		"offset":          offset,
+		"timeout":         timeout,
+		"allowed_updates": []string{"message"},
+	}
+
+	var updates []Update
+	if err := c.call("getUpdates", params, &updates); err != nil {
+		return nil, err
+	}
+
+	return updates, nil
+}
+
+func (c *client) sendMessage(chatID int64, text string) error {
+	params := map[string]any{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}
+
+	return c.call("sendMessage", params, nil)
+}
@@ -0,0 +1,219 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"personae-fasti/api/models/reqData"
+	"personae-fasti/data"
+	gu "personae-fasti/gewi-utils"
+)
+
+const (
+	maxMessageLength = 4096
+	maxRecordPreview = 500
+	defaultRecords   = 5
+	maxRecords       = 20
+	maxNPCs          = 5
+)
+
+// handleMessage returns the reply to the message
+func (b *Bot) handleMessage(message *Message) string {
+	command, args := parseCommand(message.Text)
+	lang := normalizeLang(message.From.LanguageCode)
+
+	// Records and pages of the game must not leak into group chats
+	if message.Chat.Type != "private" {
+		if command != "" {
+			return text(lang, "privateOnly")
+		}
+		return ""
+	}
+
+	telegram := &data.Telegram{
+		ID:       message.From.ID,
+		Username: message.From.Username,
+		Lang:     lang,
+	}
+
+	if command == "link" || (command == "start" && args != "") {
+		return b.link(args, telegram)
+	}
+
+	player, err := b.storage.GetPlayerByTelegramID(telegram.ID)
+	if err != nil {
+		log.Printf("Telegram bot cannot get the player of %d: %v", telegram.ID, err)
+		return text(lang, "error")
+	} else if player == nil {
+		if command == "start" || command == "help" {
+			return text(lang, "help") + "\n\n" + text(lang, "notLinked")
+		}
+		return text(lang, "notLinked")
+	}
+
+	if player.Telegram == nil || player.Telegram.Username != telegram.Username || player.Telegram.Lang != telegram.Lang {
+		if err := b.storage.UpdateTelegram(telegram); err != nil {
+			log.Printf("Telegram bot cannot update the account %d: %v", telegram.ID, err)
+		}
+	}
+
+	switch command {
+	case "start", "help":
+		return text(lang, "help")
+	case "unlink":
+		if err := b.storage.UnlinkTelegram(player); err != nil {
+			log.Printf("Telegram bot cannot unlink the player %d: %v", player.ID, err)
+			return text(lang, "error")
+		}
+		return text(lang, "unlinked")
+	}
+
+	if player.CurrentGameID == 0 || player.CurrentGame == nil {
+		return text(lang, "noGame")
+	}
+
+	switch command {
+	case "":
+		return b.addRecord(args, false, player, lang)
+	case "hidden":
+		return b.addRecord(args, true, player, lang)
+	case "records":
+		return b.lastRecords(args, player, lang)
+	case "quests":
+		return b.activeQuests(player, lang)
+	case "npc":
+		return b.findNPC(args, player, lang)
+	default:
+		return text(lang, "unknown")
+	}
+}
+
+func (b *Bot) link(code string, telegram *data.Telegram) string {
+	if code == "" {
+		return text(telegram.Lang, "linkUsage")
+	}
+
+	player, err := b.storage.LinkTelegram(code, telegram)
+	if errors.Is(err, data.ErrTelegramLink) {
+		return text(telegram.Lang, "linkFailed")
+	} else if err != nil {
+		log.Printf("Telegram bot cannot link the account %d: %v", telegram.ID, err)
+		return text(telegram.Lang, "error")
+	}
+
+	return fmt.Sprintf(text(telegram.Lang, "linked"), player.Username) + "\n\n" + text(telegram.Lang, "help")
+}
+
+func (b *Bot) addRecord(recordText string, hidden bool, player *data.Player, lang string) string {
+	if recordText == "" {
+		return text(lang, "emptyRecord")
+	}
+
+	recordInsert := &reqData.RecordInsert{
+		Text:   recordText,
+		Hidden: hidden,
+	}
+	if err := b.storage.InsertNewRecord(recordInsert, player); err != nil {
+		log.Printf("Telegram bot cannot add a record of the player %d: %v", player.ID, err)
+		return text(lang, "error")
+	}
+
+	if hidden {
+		return fmt.Sprintf(text(lang, "hiddenAdded"), player.CurrentGame.Name)
+	}
+	return fmt.Sprintf(text(lang, "recordAdded"), player.CurrentGame.Name)
+}
+
+func (b *Bot) lastRecords(args string, player *data.Player, lang string) string {
+	limit := defaultRecords
+	if n, err := strconv.Atoi(args); err == nil && n > 0 {
+		limit = min(n, maxRecords)
+	}
+
+	records, err := b.storage.GetLastRecordsForPlayer(player.CurrentGame, player, limit)
+	if err != nil {
+		log.Printf("Telegram bot cannot get records of the player %d: %v", player.ID, err)
+		return text(lang, "error")
+	} else if len(records) == 0 {
+		return text(lang, "noRecords")
+	}
+
+	lines := make([]string, 0, len(records))
+	// Oldest first to read them as a story
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		line := gu.Truncate(strings.TrimSpace(data.StripMentions(record.Text)), maxRecordPreview)
+		if record.HiddenBy != 0 {
+			line = text(lang, "hiddenMarker") + " " + line
+		}
+		if record.Created != nil {
+			line = record.Created.Format("2006-01-02") + " — " + line
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n\n")
+}
+
+func (b *Bot) activeQuests(player *data.Player, lang string) string {
+	quests, err := b.storage.GetActiveQuestsForPlayer(player.CurrentGame, player)
+	if err != nil {
+		log.Printf("Telegram bot cannot get quests of the player %d: %v", player.ID, err)
+		return text(lang, "error")
+	} else if len(quests) == 0 {
+		return text(lang, "noQuests")
+	}
+
+	blocks := make([]string, 0, len(quests))
+	for _, quest := range quests {
+		lines := []string{"• " + quest.Name}
+		if quest.Title != "" {
+			lines[0] += " — " + quest.Title
+		}
+		for _, task := range quest.Tasks {
+			check := "[ ]"
+			if task.Finished != nil {
+				check = "[x]"
+			}
+			line := "  " + check + " " + task.Name
+			if task.Type == data.Decimal {
+				line += fmt.Sprintf(" (%d/%d)", task.Current, task.Capacity)
+			}
+			lines = append(lines, line)
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+func (b *Bot) findNPC(name string, player *data.Player, lang string) string {
+	if name == "" {
+		return text(lang, "npcUsage")
+	}
+
+	npcs, err := b.storage.FindNPCsForPlayer(player.CurrentGame, player, name, maxNPCs)
+	if err != nil {
+		log.Printf("Telegram bot cannot find NPCs for the player %d: %v", player.ID, err)
+		return text(lang, "error")
+	} else if len(npcs) == 0 {
+		return text(lang, "noNPCs")
+	}
+
+	blocks := make([]string, 0, len(npcs))
+	for _, npc := range npcs {
+		block := npc.Name
+		if npc.Title != "" {
+			block += " — " + npc.Title
+		}
+		if description := strings.TrimSpace(data.StripMentions(npc.Description)); description != "" {
+			block += "\n" + gu.Truncate(description, maxRecordPreview)
+		}
+		blocks = append(blocks, block)
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
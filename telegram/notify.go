@@ -0,0 +1,42 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	"personae-fasti/data"
+	gu "personae-fasti/gewi-utils"
+)
+
+// Name and Deliver make the bot a notification channel
+func (b *Bot) Name() string {
+	return "telegram"
+}
+
+// Deliver sends the notification to the linked Telegram account. Players
+// without one are skipped
+func (b *Bot) Deliver(player *data.Player, notification *data.Notification) error {
+	if player.TelegramID == 0 {
+		return nil
+	}
+	lang := player.Lang()
+
+	actor := text(lang, "notify.someone")
+	if notification.Actor != nil && notification.Actor.Username != "" {
+		actor = notification.Actor.Username
+	}
+
+	var message string
+	switch notification.Type {
+	case data.MentionNotification, data.RecordEditedNotification:
+		message = fmt.Sprintf(text(lang, "notify."+notification.Type), actor, notification.Text)
+	case data.SharedNotification:
+		message = fmt.Sprintf(text(lang, "notify.shared"), actor, notification.EntityType, notification.Text)
+	case data.SessionNotification:
+		message = strings.TrimSpace(fmt.Sprintf(text(lang, "notify.session.started"), notification.Text))
+	default:
+		return nil
+	}
+
+	return b.client.sendMessage(player.TelegramID, gu.Truncate(message, maxMessageLength))
+}
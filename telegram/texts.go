@@ -0,0 +1,89 @@
+package telegram
+
+import "strings"
+
+const defaultLang = "en"
+
+var texts = map[string]map[string]string{
+	"en": {
+		"help": "Send a message to add it as a record to your current game.\n\n" +
+			"/hidden <text> - add a record visible only to you\n" +
+			"/records [n] - last records\n" +
+			"/quests - active quests\n" +
+			"/npc <name> - look up an NPC\n" +
+			"/unlink - unlink this Telegram account",
+		"notLinked":    "This Telegram account is not linked. Get a link code in Personae Fasti and send /link <code>.",
+		"linked":       "Linked to the player %s.",
+		"linkFailed":   "The link code is invalid or expired.",
+		"linkUsage":    "Send /link <code> with the code from Personae Fasti.",
+		"unlinked":     "This Telegram account is unlinked.",
+		"noGame":       "Choose the current game in Personae Fasti first.",
+		"recordAdded":  "Record added to %s.",
+		"hiddenAdded":  "Hidden record added to %s.",
+		"emptyRecord":  "The record is empty.",
+		"noRecords":    "No records yet.",
+		"noQuests":     "No active quests.",
+		"npcUsage":     "Send /npc <name>.",
+		"noNPCs":       "No NPC found.",
+		"unknown":      "Unknown command. Send /help.",
+		"privateOnly":  "The bot works only in a private chat.",
+		"error":        "Something went wrong, try again later.",
+		"hiddenMarker": "(hidden)",
+
+		"notify.mention":         "%s mentioned your character:\n%s",
+		"notify.record.edited":   "%s edited your record:\n%s",
+		"notify.shared":          "%s shared %s:\n%s",
+		"notify.session.started": "Session started. %s",
+		"notify.someone":         "Someone",
+	},
+	"ru": {
+		"help": "Отправьте сообщение, чтобы добавить его записью в текущую игру.\n\n" +
+			"/hidden <текст> - запись, видимая только вам\n" +
+			"/records [n] - последние записи\n" +
+			"/quests - активные квесты\n" +
+			"/npc <имя> - найти NPC\n" +
+			"/unlink - отвязать этот аккаунт Telegram",
+		"notLinked":    "Этот аккаунт Telegram не привязан. Получите код привязки в Personae Fasti и отправьте /link <код>.",
+		"linked":       "Привязано к игроку %s.",
+		"linkFailed":   "Код привязки неверный или устарел.",
+		"linkUsage":    "Отправьте /link <код> с кодом из Personae Fasti.",
+		"unlinked":     "Аккаунт Telegram отвязан.",
+		"noGame":       "Сначала выберите текущую игру в Personae Fasti.",
+		"recordAdded":  "Запись добавлена в %s.",
+		"hiddenAdded":  "Скрытая запись добавлена в %s.",
+		"emptyRecord":  "Запись пустая.",
+		"noRecords":    "Записей пока нет.",
+		"noQuests":     "Активных квестов нет.",
+		"npcUsage":     "Отправьте /npc <имя>.",
+		"noNPCs":       "NPC не найден.",
+		"unknown":      "Неизвестная команда. Отправьте /help.",
+		"privateOnly":  "Бот работает только в личном чате.",
+		"error":        "Что-то пошло не так, попробуйте позже.",
+		"hiddenMarker": "(скрыто)",
+
+		"notify.mention":         "%s упомянул вашего персонажа:\n%s",
+		"notify.record.edited":   "%s изменил вашу запись:\n%s",
+		"notify.shared":          "%s открыл %s:\n%s",
+		"notify.session.started": "Сессия началась. %s",
+		"notify.someone":         "Кто-то",
+	},
+}
+
+// text falls back to English for the languages without translation
+func text(lang, key string) string {
+	if langTexts, ok := texts[normalizeLang(lang)]; ok {
+		return langTexts[key]
+	}
+
+	return texts[defaultLang][key]
+}
+
+// normalizeLang turns Telegram language codes like "ru-RU" into "ru"
+func normalizeLang(lang string) string {
+	lang, _, _ = strings.Cut(strings.ToLower(lang), "-")
+	if lang == "" {
+		return defaultLang
+	}
+
+	return lang
+}
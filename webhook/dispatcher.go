@@ -0,0 +1,267 @@
+// Package webhook delivers the public game events to the GM webhooks
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"personae-fasti/data"
+)
+
+const (
+	SignatureHeader = "X-Fasti-Signature"
+	EventHeader     = "X-Fasti-Event"
+	DeliveryHeader  = "X-Fasti-Delivery"
+
+	TestEvent data.EventType = "webhook.test"
+
+	maxAttempts     = 6
+	firstRetryDelay = 5 * time.Second
+	requestTimeout  = 10 * time.Second
+	queueSize       = 256
+	maxErrorLength  = 512
+)
+
+type Dispatcher struct {
+	storage *data.Storage
+	client  *http.Client
+	events  chan data.Event
+}
+
+// InitDispatcher subscribes to the storage events and resumes
+// the deliveries left pending before the restart
+func InitDispatcher(s *data.Storage) *Dispatcher {
+	d := &Dispatcher{
+		storage: s,
+		events:  make(chan data.Event, queueSize),
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = d.dialContext
+	d.client = &http.Client{Timeout: requestTimeout, Transport: transport}
+
+	s.Subscribe(d.enqueue)
+	go d.run()
+	go d.resumePending()
+
+	return d
+}
+
+// dialContext connects only to the public addresses unless the host is
+// allowed in the config, so a host that resolves to a private address
+// after the check or a redirect cannot reach the server network
+func (d *Dispatcher) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: requestTimeout}
+	if !d.storage.WebhookHostAllowed(host) {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			ip, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			} else if parsed := net.ParseIP(ip); parsed == nil || !data.PublicIP(parsed) {
+				return fmt.Errorf("webhook address %s is not public", ip)
+			}
+			return nil
+		}
+	}
+
+	return dialer.DialContext(ctx, network, address)
+}
+
+func (d *Dispatcher) enqueue(event data.Event) {
+	// Hidden content never leaves the server
+	if event.HiddenBy != 0 {
+		return
+	}
+
+	select {
+	case d.events <- event:
+	default:
+		log.Printf("webhook queue is full, event %s of the game %d is dropped", event.Type, event.GameID)
+	}
+}
+
+func (d *Dispatcher) run() {
+	for event := range d.events {
+		webhooks, err := d.storage.GetGameWebhooks(event.GameID)
+		if err != nil {
+			log.Printf("failed to get webhooks of the game %d: %v", event.GameID, err)
+			continue
+		}
+
+		for _, webhook := range webhooks {
+			if !webhook.Active || !webhook.Subscribed(event.Type) {
+				continue
+			}
+
+			delivery, err := d.createDelivery(&webhook, &event)
+			if err != nil {
+				log.Printf("failed to create delivery of the webhook %d: %v", webhook.ID, err)
+				continue
+			}
+
+			go d.deliver(webhook, delivery)
+		}
+	}
+}
+
+func (d *Dispatcher) resumePending() {
+	deliveries, err := d.storage.GetPendingWebhookDeliveries()
+	if err != nil {
+		log.Printf("failed to get pending webhook deliveries: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		webhook, err := d.storage.GetWebhookByID(delivery.WebhookID)
+		if err != nil {
+			log.Printf("failed to get webhook %d: %v", delivery.WebhookID, err)
+			continue
+		} else if webhook == nil || !webhook.Active {
+			delivery.Status = data.DeliveryFailed
+			delivery.Error = "webhook is deleted or inactive"
+			d.updateDelivery(&delivery)
+			continue
+		}
+
+		go d.deliver(*webhook, &delivery)
+	}
+}
+
+// Test sends a test event once without retries and returns the result
+func (d *Dispatcher) Test(webhook *data.Webhook, player *data.Player) (*data.WebhookDelivery, error) {
+	event := data.Event{
+		Type:     TestEvent,
+		GameID:   webhook.GameID,
+		PlayerID: player.ID,
+		Username: player.Username,
+		Time:     time.Now().UTC(),
+		Data:     map[string]string{"message": "Test event from Personae Fasti"},
+	}
+
+	delivery, err := d.createDelivery(webhook, &event)
+	if err != nil {
+		return nil, err
+	}
+
+	d.attempt(webhook, delivery)
+	if delivery.Status == data.DeliveryPending {
+		delivery.Status = data.DeliveryFailed
+	}
+	d.updateDelivery(delivery)
+
+	return delivery, nil
+}
+
+func (d *Dispatcher) createDelivery(webhook *data.Webhook, event *data.Event) (*data.WebhookDelivery, error) {
+	payload, err := formatPayload(webhook, event)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &data.WebhookDelivery{
+		WebhookID: webhook.ID,
+		Event:     string(event.Type),
+		Payload:   string(payload),
+		Status:    data.DeliveryPending,
+	}
+	if err := d.storage.CreateWebhookDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// deliver retries the delivery with exponential backoff until it is
+// delivered or failed
+func (d *Dispatcher) deliver(webhook data.Webhook, delivery *data.WebhookDelivery) {
+	delay := firstRetryDelay
+	for {
+		d.attempt(&webhook, delivery)
+		d.updateDelivery(delivery)
+		if delivery.Status != data.DeliveryPending {
+			return
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// attempt sends the delivery once and sets its status. The delivery stays
+// pending if it can be retried
+func (d *Dispatcher) attempt(webhook *data.Webhook, delivery *data.WebhookDelivery) {
+	delivery.Attempts++
+
+	code, err := d.send(webhook, delivery)
+	delivery.ResponseCode = code
+	delivery.Error = ""
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = data.DeliveryDelivered
+	case code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests:
+		// The receiver rejects the payload, retries give the same
+		delivery.Status = data.DeliveryFailed
+	case delivery.Attempts >= maxAttempts:
+		delivery.Status = data.DeliveryFailed
+	}
+}
+
+func (d *Dispatcher) send(webhook *data.Webhook, delivery *data.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "personae-fasti-webhook")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(SignatureHeader, "sha256="+Sign(webhook.Secret, body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorLength))
+		return res.StatusCode, fmt.Errorf("webhook responded %d: %s", res.StatusCode, string(resBody))
+	}
+
+	return res.StatusCode, nil
+}
+
+func (d *Dispatcher) updateDelivery(delivery *data.WebhookDelivery) {
+	if err := d.storage.UpdateWebhookDelivery(delivery); err != nil {
+		log.Printf("failed to update webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// Sign is the hex HMAC-SHA256 of the body with the webhook secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"personae-fasti/data"
+)
+
+func TestDialPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("webhook reached the loopback server")
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Dispatcher{storage: &data.Storage{}}
+	for _, host := range []string{"127.0.0.1", "localhost", "::1"} {
+		conn, err := d.dialContext(context.Background(), "tcp", net.JoinHostPort(host, port))
+		if err == nil {
+			conn.Close()
+			t.Errorf("dialed the private address of %s", host)
+		} else if !strings.Contains(err.Error(), "is not public") {
+			t.Errorf("dial of %s failed with %q, want it not public", host, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"personae-fasti/data"
+	gu "personae-fasti/gewi-utils"
+)
+
+// Discord rejects longer messages
+const maxDiscordLength = 2000
+
+type discordPayload struct {
+	Content  string `json:"content"`
+	Username string `json:"username"`
+}
+
+func formatPayload(webhook *data.Webhook, event *data.Event) ([]byte, error) {
+	if webhook.Format == data.DiscordWebhookFormat {
+		return json.Marshal(&discordPayload{
+			Content:  gu.Truncate(discordMessage(event), maxDiscordLength),
+			Username: "Personae Fasti",
+		})
+	}
+
+	return json.Marshal(event)
+}
+
+func discordMessage(event *data.Event) string {
+	if event.Type == TestEvent {
+		return "Test message from Personae Fasti"
+	}
+
+	author := event.Username
+	if author == "" {
+		author = "GM"
+	}
+
+	switch eventData := event.Data.(type) {
+	case *data.RecordEventData:
+		switch event.Type {
+		case data.RecordCreatedEvent:
+			return fmt.Sprintf("**%s** wrote:\n%s", author, quote(eventData.Text))
+		case data.RecordUpdatedEvent:
+			return fmt.Sprintf("**%s** edited a record:\n%s", author, quote(eventData.Text))
+		case data.RecordDeletedEvent:
+			return fmt.Sprintf("**%s** removed a record", author)
+		}
+	case *data.SessionEventData:
+		if eventData.Name != "" {
+			return fmt.Sprintf("Session %d started: **%s**", eventData.Number, eventData.Name)
+		}
+		return fmt.Sprintf("Session %d started", eventData.Number)
+	case *data.QuestEventData:
+		return fmt.Sprintf("Quest **%s** %s", eventData.Name, eventData.Status)
+	case *data.EntityEventData:
+		if eventData.EntityType == data.RecordEntity {
+			return fmt.Sprintf("**%s** revealed a record:\n%s", author, quote(eventData.Description))
+		}
+		message := fmt.Sprintf("New %s revealed: **%s**", eventData.EntityType, eventData.Name)
+		if eventData.Title != "" {
+			message += " - " + eventData.Title
+		}
+		if eventData.Description != "" {
+			message += "\n" + quote(eventData.Description)
+		}
+		return message
+	}
+
+	return fmt.Sprintf("Personae Fasti event %s", event.Type)
+}
+
+func quote(text string) string {
+	text = strings.TrimSpace(data.StripMentions(text))
+
+	return "> " + strings.ReplaceAll(text, "\n", "\n> ")
+}